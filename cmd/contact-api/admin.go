@@ -0,0 +1,103 @@
+package main
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/health"
+	adminBuildInfo "contact-api/internal/app/http-server/handlers/admin/buildinfo"
+	"contact-api/internal/app/http-server/handlers/admin/effectiveconfig"
+	levelGet "contact-api/internal/app/http-server/handlers/admin/level/get"
+	levelSet "contact-api/internal/app/http-server/handlers/admin/level/set"
+	overrideAdd "contact-api/internal/app/http-server/handlers/admin/override/add"
+	overrideDelete "contact-api/internal/app/http-server/handlers/admin/override/delete"
+	"contact-api/internal/app/http-server/handlers/admin/poolstats"
+	"contact-api/internal/app/http-server/handlers/admin/runtimestats"
+	"contact-api/internal/app/http-server/handlers/health/liveness"
+	"contact-api/internal/app/http-server/handlers/health/readiness"
+	"contact-api/internal/app/http-server/middleware/auth"
+	"contact-api/internal/app/http-server/middleware/logger"
+	"contact-api/internal/app/http-server/middleware/recoverer"
+	"contact-api/internal/app/http-server/middleware/requestid"
+	"contact-api/internal/app/loglevel"
+	"contact-api/internal/app/openapi"
+	"contact-api/internal/app/storage/mongo"
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// adminDeps - компоненты, которые admin-listener показывает или которыми управляет.
+type adminDeps struct {
+	cfg       config.Admin
+	started   time.Time
+	level     *slog.LevelVar
+	overrides *loglevel.Overrides
+	checker   *health.Checker
+	reloader  *config.Reloader
+	storage   *mongo.DB
+	spec      *openapi.Spec
+}
+
+// newAdminRouter собирает маршруты служебного listener'а. Кроме /openapi.json, они не регистрируются на публичном порту.
+// Проверки состояния, метрики, версия и описание API открыты; конфигурация, отладочные эндпоинты,
+// pprof и управление логами требуют токена администратора независимо от loopback_only:
+// адрес listener'а не защищает от процессов на том же хосте и соседей по сети контейнеров.
+func newAdminRouter(log *slog.Logger, deps adminDeps) http.Handler {
+	router := chi.NewRouter()
+
+	router.Use(recoverer.New(log))
+	router.Use(requestid.New)
+	router.Use(auth.New(deps.cfg.Token))
+	router.Use(logger.New(log))
+
+	router.Get("/healthz", liveness.New())
+	router.Get("/readyz", readiness.New(log, deps.checker))
+
+	router.Get("/metrics", promhttp.Handler().ServeHTTP)
+
+	router.Get("/version", adminBuildInfo.New())
+
+	// Описание API и Swagger UI для него; /openapi.json отдаёт и публичный API
+	router.Get("/openapi.json", deps.spec.Handler())
+	router.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL("/openapi.json")))
+
+	if deps.cfg.Token == "" {
+		log.Warn("admin token is not set, config, debug and log level endpoints are disabled")
+	}
+
+	router.Group(func(r chi.Router) {
+		r.Use(auth.RequireAdmin)
+
+		r.Get("/config", effectiveconfig.New(deps.reloader))
+		r.Get("/debug/runtime", runtimestats.New(deps.started))
+		r.Get("/debug/mongo/pool", poolstats.New(deps.storage))
+
+		r.HandleFunc("/debug/pprof/", pprof.Index)
+		r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		r.Handle("/debug/pprof/{profile}", http.HandlerFunc(pprof.Index))
+
+		r.Get("/log/level", levelGet.New(deps.level, deps.overrides))
+		r.Put("/log/level", levelSet.New(log, deps.level))
+		r.Post("/log/overrides", overrideAdd.New(log, deps.overrides))
+		r.Delete("/log/overrides/{id}", overrideDelete.New(log, deps.overrides))
+	})
+
+	return router
+}
+
+// newAdminServer создаёт admin-listener. WriteTimeout не задаётся,
+// потому что /debug/pprof/profile и /debug/pprof/trace отвечают только после сбора данных.
+func newAdminServer(cfg config.Admin, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/health"
+	"contact-api/internal/app/loglevel"
+	"contact-api/internal/app/openapi"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testAdminToken = "test-admin-token"
+
+func testAdminRouter(t *testing.T, token string) http.Handler {
+	t.Helper()
+
+	spec, err := openapi.Load(context.Background())
+	if err != nil {
+		t.Fatalf("openapi.Load: %v", err)
+	}
+
+	return newAdminRouter(slog.New(slog.NewTextHandler(io.Discard, nil)), adminDeps{
+		cfg:       config.Admin{Token: token, MaxOverrideTTL: time.Hour},
+		started:   time.Now(),
+		level:     new(slog.LevelVar),
+		overrides: loglevel.NewOverrides(time.Hour),
+		checker:   health.New(time.Second),
+		spec:      spec,
+	})
+}
+
+func adminRequest(router http.Handler, method, path, token string) int {
+	r := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+// Защищённые маршруты; обработчики не вызываются, поэтому зависимости для них не нужны
+var protectedAdminRoutes = []struct{ method, path string }{
+	{http.MethodGet, "/config"},
+	{http.MethodGet, "/debug/runtime"},
+	{http.MethodGet, "/debug/mongo/pool"},
+	{http.MethodGet, "/debug/pprof/"},
+	{http.MethodGet, "/debug/pprof/cmdline"},
+	{http.MethodGet, "/debug/pprof/heap"},
+	{http.MethodGet, "/log/level"},
+	{http.MethodPut, "/log/level"},
+	{http.MethodPost, "/log/overrides"},
+	{http.MethodDelete, "/log/overrides/abc"},
+}
+
+func TestAdminRouterProtectedRoutes(t *testing.T) {
+	for _, token := range []string{testAdminToken, ""} {
+		router := testAdminRouter(t, token)
+
+		for _, rt := range protectedAdminRoutes {
+			for _, presented := range []string{"", "wrong-token"} {
+				if code := adminRequest(router, rt.method, rt.path, presented); code != http.StatusUnauthorized {
+					t.Errorf("configured token %q: %s %s with %q: status %d, want 401",
+						token, rt.method, rt.path, presented, code)
+				}
+			}
+		}
+	}
+}
+
+func TestAdminRouterOpenRoutes(t *testing.T) {
+	router := testAdminRouter(t, "")
+
+	for _, path := range []string{"/healthz", "/readyz", "/metrics", "/version", "/openapi.json"} {
+		if code := adminRequest(router, http.MethodGet, path, ""); code != http.StatusOK {
+			t.Errorf("GET %s: status %d, want 200", path, code)
+		}
+	}
+}
+
+func TestAdminRouterWithToken(t *testing.T) {
+	router := testAdminRouter(t, testAdminToken)
+
+	for _, path := range []string{"/debug/runtime", "/debug/pprof/cmdline", "/log/level"} {
+		if code := adminRequest(router, http.MethodGet, path, testAdminToken); code != http.StatusOK {
+			t.Errorf("GET %s with token: status %d, want 200", path, code)
+		}
+	}
+}
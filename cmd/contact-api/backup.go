@@ -0,0 +1,249 @@
+package main
+
+import (
+	"contact-api/internal/app/backup"
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/storage/mongo"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// runBackup выгружает контакты и квоты в архив. С -since или -base копия инкрементальная.
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	loadOpts := configFlags(fs)
+	out := fs.String("o", "", `archive path, "-" for stdout (default contacts-<time>.backup.gz)`)
+	since := fs.String("since", "", "incremental backup of contacts changed or deleted since this RFC 3339 time")
+	base := fs.String("base", "", "incremental backup of contacts changed or deleted since the given archive was taken")
+	_ = fs.Parse(args)
+
+	if *since != "" && *base != "" {
+		fmt.Fprintln(os.Stderr, "-since and -base are mutually exclusive")
+		return 2
+	}
+
+	var opts backup.Options
+
+	switch {
+	case *since != "":
+		t, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -since: %s\n", err)
+			return 2
+		}
+		opts.Since = t
+
+	case *base != "":
+		h, err := readArchiveHeader(*base)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -base: %s\n", err)
+			return 2
+		}
+		opts.Since = h.Until
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log, storage, code := openStorage(ctx, loadOpts())
+	if storage == nil {
+		return code
+	}
+	defer storage.Close(context.Background())
+
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("contacts-%s.backup.gz", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	var m backup.Manifest
+
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		var err error
+		m, err = backup.Write(ctx, w, storage, opts)
+		return err
+	})
+	if err != nil {
+		log.Error("backup failed", sl.Err(err))
+		return 1
+	}
+
+	kind := "full"
+	if m.Incremental() {
+		kind = "incremental since " + m.Since.Format(time.RFC3339Nano)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s backup written to %s: %d contacts, %d quotas, %d custom fields, %d groups, %d deletions, sha256 %s\n",
+		kind, path, m.Counts[backup.CollectionContacts], m.Counts[backup.CollectionQuotas], m.Counts[backup.CollectionFields],
+		m.Counts[backup.CollectionGroups], m.Counts[backup.CollectionDeletions], m.SHA256)
+
+	return 0
+}
+
+// runRestore загружает полную копию и следующие за ней инкрементальные.
+// С -dry-run архивы только проверяются, подключение к базе не требуется.
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	loadOpts := configFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "verify archives and print their contents without restoring")
+	replace := fs.Bool("replace", false, "delete existing contacts before restoring; otherwise archived contacts are upserted and others are kept unless an incremental archive records their deletion")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: contact-api restore [flags] <full archive> [incremental archive]...\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	open := make([]func() (io.ReadCloser, error), len(paths))
+	for i, path := range paths {
+		if path == "-" {
+			fmt.Fprintln(os.Stderr, "restore reads every archive twice and cannot use stdin")
+			return 2
+		}
+		open[i] = func() (io.ReadCloser, error) { return os.Open(path) }
+	}
+
+	if *dryRun {
+		manifests := make([]backup.Manifest, 0, len(paths))
+
+		for i, path := range paths {
+			f, err := open[i]()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+
+			m, err := backup.Verify(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+				return 1
+			}
+
+			printManifest(path, m)
+			manifests = append(manifests, m)
+		}
+
+		if err := backup.CheckChain(manifests); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		fmt.Println("archives are valid")
+
+		return 0
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log, storage, code := openStorage(ctx, loadOpts())
+	if storage == nil {
+		return code
+	}
+	defer storage.Close(context.Background())
+
+	manifests, err := backup.Restore(ctx, storage, open, backup.RestoreOptions{Replace: *replace})
+	if err != nil {
+		log.Error("restore failed", sl.Err(err))
+		return 1
+	}
+
+	for i, m := range manifests {
+		printManifest(paths[i], m)
+	}
+
+	return 0
+}
+
+// openStorage загружает конфигурацию и подключается к MongoDB для служебных команд.
+// Логи идут в stderr, чтобы не смешиваться с выводом команды.
+func openStorage(ctx context.Context, opts config.LoadOptions) (*slog.Logger, *mongo.DB, int) {
+	cfg, err := config.Load(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		return nil, nil, 2
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	storage, err := mongo.New(log, ctx, cfg.Mongo, cfg.Timeouts)
+	if err != nil {
+		log.Error("error connecting to database", sl.Err(err))
+		return nil, nil, 1
+	}
+
+	if err := storage.Connect(ctx); err != nil {
+		log.Error("error connecting to database", sl.Err(err))
+		storage.Close(context.Background())
+		return nil, nil, 1
+	}
+
+	return log, storage, 0
+}
+
+func printManifest(path string, m backup.Manifest) {
+	kind := "full"
+	if m.Incremental() {
+		kind = "incremental since " + m.Since.Format(time.RFC3339Nano)
+	}
+
+	fmt.Printf("%s: %s backup v%d taken %s, %d contacts, %d quotas, %d custom fields, %d groups, %d deletions\n",
+		path, kind, m.Version, m.Until.Format(time.RFC3339Nano),
+		m.Counts[backup.CollectionContacts], m.Counts[backup.CollectionQuotas], m.Counts[backup.CollectionFields],
+		m.Counts[backup.CollectionGroups], m.Counts[backup.CollectionDeletions])
+}
+
+func readArchiveHeader(path string) (backup.Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return backup.Header{}, err
+	}
+	defer f.Close()
+
+	return backup.ReadHeader(f)
+}
+
+// writeFileAtomic пишет во временный файл рядом с path и переименовывает его только после успешной записи,
+// чтобы прерванная копия не оставила файл, похожий на настоящий архив.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
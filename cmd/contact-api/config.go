@@ -0,0 +1,37 @@
+package main
+
+import (
+	"contact-api/internal/app/config"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+// runConfig обрабатывает подкоманды "config", сейчас поддерживается только print.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintf(os.Stderr, "usage: contact-api config print [flags]\n")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ExitOnError)
+	loadOpts := configFlags(fs)
+	_ = fs.Parse(args[1:])
+
+	cfg, err := config.Load(loadOpts())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		return 2
+	}
+
+	out, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding config: %s\n", err)
+		return 1
+	}
+
+	fmt.Print(string(out))
+
+	return 0
+}
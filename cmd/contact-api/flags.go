@@ -0,0 +1,54 @@
+package main
+
+import (
+	"contact-api/internal/app/config"
+	"flag"
+	"os"
+)
+
+// configFlags регистрирует флаги, общие для всех команд, работающих с конфигурацией.
+// Флаги перекрывают значения из файлов и окружения, но только если заданы явно.
+func configFlags(fs *flag.FlagSet) func() config.LoadOptions {
+	path := fs.String("config", os.Getenv("CONFIG_PATH"), "path to config file (env CONFIG_PATH)")
+	profile := fs.String("profile", envOr("CONFIG_PROFILE", config.EnvProd), "config profile loaded from ./config/<profile>.yaml (env CONFIG_PROFILE)")
+
+	env := fs.String("env", "", "override environment: local, staging, prod")
+	port := fs.String("port", "", "override listen address, e.g. :8080")
+	mongoURI := fs.String("mongo-uri", "", "override MongoDB connection string")
+	mongoDatabase := fs.String("mongo-database", "", "override MongoDB database name")
+
+	return func() config.LoadOptions {
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		return config.LoadOptions{
+			Path:      *path,
+			Profile:   *profile,
+			ConfigDir: "./config",
+			DotEnv:    "./.env",
+			Overrides: []func(*config.Config){
+				func(c *config.Config) {
+					if set["env"] {
+						c.Env = *env
+					}
+					if set["port"] {
+						c.Port = *port
+					}
+					if set["mongo-uri"] {
+						c.Mongo.URI = *mongoURI
+					}
+					if set["mongo-database"] {
+						c.Mongo.Database = *mongoDatabase
+					}
+				},
+			},
+		}
+	}
+}
+
+func envOr(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
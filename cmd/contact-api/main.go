@@ -1,119 +1,93 @@
 package main
 
 import (
-	//_ "contact-api/docs" // Импортируем сгенерированные документы Swagger
 	"contact-api/internal/app/config"
-	deleteAll "contact-api/internal/app/http-server/handlers/all/delete"
-	getAll "contact-api/internal/app/http-server/handlers/all/get"
-	"contact-api/internal/app/http-server/handlers/all/save"
-	deleteOne "contact-api/internal/app/http-server/handlers/one/delete"
-	getOne "contact-api/internal/app/http-server/handlers/one/get"
-	"contact-api/internal/app/http-server/handlers/one/update"
-	"contact-api/internal/app/storage/mongo"
+	"contact-api/internal/pkg/logger/handlers/sloglevel"
 	"contact-api/internal/pkg/logger/handlers/slogpretty"
-	"context"
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
-	"github.com/rs/cors"
-	httpSwagger "github.com/swaggo/http-swagger"
+	"contact-api/internal/pkg/logger/handlers/slogredact"
+	"contact-api/internal/pkg/logger/handlers/slogtrace"
+	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
 )
 
-// @title My Swagger API
-// @version 1.0
-// @description Swagger API for Golang Project.
-// @termsOfService http://swagger.io/terms/
-// @contact.name API Support
-// @BasePath /v1/contact
+const usage = `Usage:
+  contact-api [serve] [flags]     запустить HTTP-сервер
+  contact-api config print [flags] вывести итоговую конфигурацию без секретов
+  contact-api backup [flags]      сохранить контакты в архив
+  contact-api restore [flags] <archive>...
+                                  восстановить контакты из архивов
+  contact-api migrate up|down|status [flags]
+                                  применить, откатить или показать миграции схемы
 
-const (
-	EnvLocal = "local"
-	EnvProd  = "prod"
-)
+Run "contact-api <command> -h" for command flags.
+`
 
 func main() {
-	cfg := config.MustLoad("./config/prod.yaml")
-
-	log := SetupLogger(cfg.Env)
-
-	log.Info("Starting server at port", slog.String("port", cfg.Port))
-
-	router := chi.NewRouter()
-
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.RealIP)
-
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	})
-
-	// Применяем CORS middleware
-	router.Use(c.Handler)
-
-	ctx := context.Background()
+	args := os.Args[1:]
 
-	storage, err := mongo.New(log, ctx, cfg.DBConnection)
-	if err != nil {
-		log.Error("error connecting to database", err)
-		panic(err)
+	command := "serve"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		command, args = args[0], args[1:]
 	}
-	defer storage.Close()
 
-	// Настройка Swagger
-	router.Get("/swagger/*", httpSwagger.WrapHandler)
-
-	router.Route("/v1/contact", func(r chi.Router) {
-		r.Get("/", getAll.New(log, storage))
-		r.Post("/", save.New(log, storage))
-		r.Delete("/", deleteAll.New(log, storage))
-
-		r.Route("/{uid}", func(r chi.Router) {
-			r.Get("/", getOne.New(log, storage))
-			r.Delete("/", deleteOne.New(log, storage))
-			r.Put("/", update.New(log, storage))
-		})
-	})
-
-	err = http.ListenAndServe(cfg.Port, router)
-	if err != nil {
-		log.Error("Error starting server", err)
+	switch command {
+	case "serve":
+		os.Exit(runServe(args))
+	case "config":
+		os.Exit(runConfig(args))
+	case "backup":
+		os.Exit(runBackup(args))
+	case "restore":
+		os.Exit(runRestore(args))
+	case "migrate":
+		os.Exit(runMigrate(args))
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
 	}
 }
 
-func SetupLogger(env string) *slog.Logger {
-	log := &slog.Logger{}
+// SetupLogger создаёт логгер для окружения; уровень берётся из level и может меняться во время работы.
+// override позволяет понизить уровень для отдельных запросов.
+func SetupLogger(env string, level *slog.LevelVar, override sloglevel.OverrideFunc, redaction config.LogRedaction, pretty config.LogPretty) *slog.Logger {
+	var handler slog.Handler
 
+	// Уровень проверяет sloglevel, вложенные обработчики пропускают всё начиная с Debug
 	switch env {
-	case EnvLocal:
-		log = setupPrettySlog()
-	case EnvProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+	case config.EnvLocal:
+		handler = setupPrettySlog(pretty)
+	case config.EnvProd:
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 	default: // Если конфигурация окружения недействительна, по умолчанию устанавливаются параметры prod из соображений безопасности
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 	}
 
-	return log
+	if redaction.Enabled {
+		handler = slogredact.New(handler, slogredact.Options{
+			URICredentials: redaction.URICredentials,
+			Email:          slogredact.Mode(redaction.Email),
+			Phone:          slogredact.Mode(redaction.Phone),
+			EmailKeys:      redaction.EmailKeys,
+			PhoneKeys:      redaction.PhoneKeys,
+			HashSalt:       redaction.HashSalt,
+		})
+	}
+
+	return slog.New(sloglevel.New(slogtrace.New(handler), level, override))
 }
 
-func setupPrettySlog() *slog.Logger {
+func setupPrettySlog(pretty config.LogPretty) slog.Handler {
 	opts := slogpretty.PrettyHandlerOptions{
 		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
+			Level:     slog.LevelDebug,
+			AddSource: pretty.Source,
 		},
+		NoColor: pretty.NoColor,
+		Compact: pretty.Compact,
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
-
-	return slog.New(handler)
+	return opts.NewPrettyHandler(os.Stdout)
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"contact-api/internal/app/storage/mongo"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: contact-api migrate up|down|status [flags]\n"
+
+// runMigrate применяет, откатывает или показывает миграции схемы независимо от настройки auto.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command := args[0]
+
+	fs := flag.NewFlagSet("migrate "+command, flag.ExitOnError)
+	loadOpts := configFlags(fs)
+
+	var (
+		to   *int
+		lazy *bool
+	)
+
+	switch command {
+	case "up":
+		to = fs.Int("to", 0, "apply migrations up to this version (default: latest)")
+		lazy = fs.Bool("lazy", false, "do not rewrite collections for migrations that upgrade documents on read")
+	case "down":
+		to = fs.Int("to", -1, "revert migrations newer than this version, 0 reverts all (default: the latest one)")
+	case "status":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n%s", command, migrateUsage)
+		return 2
+	}
+	_ = fs.Parse(args[1:])
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log, storage, code := openStorage(ctx, loadOpts())
+	if storage == nil {
+		return code
+	}
+	defer storage.Close(context.Background())
+
+	switch command {
+	case "up":
+		done, err := storage.MigrateUp(ctx, mongo.MigrateOptions{To: *to, Lazy: *lazy})
+		printVersions("applied", done)
+		if err != nil {
+			log.Error("migrate up failed", sl.Err(err))
+			return 1
+		}
+
+	case "down":
+		done, err := storage.MigrateDown(ctx, mongo.MigrateOptions{To: *to})
+		printVersions("reverted", done)
+		if err != nil {
+			log.Error("migrate down failed", sl.Err(err))
+			return 1
+		}
+
+	case "status":
+		statuses, outdated, err := storage.MigrationStatus(ctx)
+		if err != nil {
+			log.Error("migrate status failed", sl.Err(err))
+			return 1
+		}
+		printMigrationStatus(statuses, outdated)
+	}
+
+	return 0
+}
+
+func printVersions(action string, versions []int) {
+	if len(versions) == 0 {
+		fmt.Printf("nothing %s\n", action)
+		return
+	}
+
+	for _, v := range versions {
+		fmt.Printf("%s %d\n", action, v)
+	}
+}
+
+func printMigrationStatus(statuses []mongo.MigrationStatus, outdated int64) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tREVERSIBLE\tDESCRIPTION")
+
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Unknown:
+			state = "unknown"
+		case s.Applied && s.Lazy:
+			state = "applied (lazy)"
+		case s.Applied:
+			state = "applied"
+		}
+
+		appliedAt := "-"
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+
+		reversible := "no"
+		if s.Reversible {
+			reversible = "yes"
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", s.Version, state, appliedAt, reversible, s.Description)
+	}
+
+	_ = tw.Flush()
+
+	fmt.Printf("\n%d contacts are stored in an older schema and upgraded on read\n", outdated)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/features"
+	"contact-api/internal/app/health"
+	"contact-api/internal/app/http-server/middleware/cors"
+	"contact-api/internal/app/http-server/middleware/ratelimit"
+	"contact-api/internal/app/http-server/middleware/validator"
+	httpRouter "contact-api/internal/app/http-server/router"
+	"contact-api/internal/app/loglevel"
+	"contact-api/internal/app/openapi"
+	"contact-api/internal/app/storage/mongo"
+	"contact-api/internal/app/tracing"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// runServe запускает HTTP-сервер и блокируется до его плавной остановки.
+func runServe(args []string) int {
+	started := time.Now()
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	loadOpts := configFlags(fs)
+	_ = fs.Parse(args)
+
+	opts := loadOpts()
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		return 2
+	}
+
+	// Уровень уже проверен при загрузке конфигурации
+	level := new(slog.LevelVar)
+	_ = level.UnmarshalText([]byte(cfg.LogLevel))
+
+	// Временные переопределения уровня для маршрутов и клиентов, управляются через /admin
+	overrides := loglevel.NewOverrides(cfg.Admin.MaxOverrideTTL)
+
+	log := SetupLogger(cfg.Env, level, overrides.Level, cfg.LogRedaction, cfg.LogPretty)
+
+	features.Set(cfg.Features)
+
+	log.Info("Starting server at port", slog.String("port", cfg.Port))
+
+	corsHandler := cors.New(log, cfg.CORS)
+
+	// Контекст отменяется по SIGINT/SIGTERM и запускает плавную остановку
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Error("error setting up tracing", sl.Err(err))
+		return 1
+	}
+	// Спаны сбрасываются при любом выходе, в том числе при ошибке запуска после этой точки
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Error("error flushing traces", sl.Err(err))
+		}
+	}()
+
+	storage, err := mongo.New(log, ctx, cfg.Mongo, cfg.Timeouts)
+	if err != nil {
+		log.Error("error connecting to database", sl.Err(err))
+		return 1
+	}
+
+	checker := health.New(cfg.Health.CheckTimeout)
+	checker.Register("mongo", storage.Ping)
+	checker.Register("storage_setup", storage.SetupDone)
+
+	// Фоновые задачи, которые нужно дождаться перед отключением от MongoDB
+	var jobs sync.WaitGroup
+	var exitCode atomic.Int32
+
+	// Сервер начинает слушать порт сразу, а /readyz сообщает о неготовности, пока хранилище не подключено
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+
+		if err := storage.Setup(ctx); err != nil && ctx.Err() == nil {
+			log.Error("error setting up storage", sl.Err(err))
+			exitCode.Store(1)
+			stop()
+		}
+	}()
+
+	limiter := ratelimit.New(log, cfg.RateLimit, storage)
+
+	// Перезагружаемые настройки применяются к уже работающим компонентам
+	reloader := config.NewReloader(log, opts, cfg)
+	reloader.Subscribe(func(rc config.Reloadable) {
+		_ = level.UnmarshalText([]byte(rc.LogLevel))
+		features.Set(rc.Features)
+		corsHandler.Update(rc.CORS)
+		limiter.Update(rc.RateLimit)
+		storage.SetTimeouts(rc.Timeouts)
+	})
+
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		reloader.Run(ctx, cfg.ReloadInterval)
+	}()
+
+	spec, err := openapi.Load(ctx)
+	if err != nil {
+		log.Error("error loading openapi spec", sl.Err(err))
+		return 1
+	}
+
+	validate, err := validator.New(log, spec, cfg.OpenAPIValidation)
+	if err != nil {
+		log.Error("error setting up openapi validation", sl.Err(err))
+		return 1
+	}
+
+	router := httpRouter.New(log, httpRouter.Deps{
+		Storage:    storage,
+		Limiter:    limiter,
+		Validator:  validate,
+		CORS:       corsHandler,
+		AdminToken: cfg.Admin.Token,
+		Spec:       spec,
+	})
+
+	if cfg.Admin.Token == "" {
+		log.Warn("admin token is not set, custom fields cannot be changed")
+	}
+
+	// Спецификация встроена в бинарник, поэтому расхождение - ошибка сборки, а не окружения
+	if err := spec.CheckRoutes(router); err != nil {
+		log.Error("openapi spec is out of date", sl.Err(err))
+		return 1
+	}
+
+	if err := corsHandler.SetRouteMethods(router); err != nil {
+		log.Error("error collecting route methods for cors", sl.Err(err))
+		return 1
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Port,
+		Handler:           router,
+		ReadTimeout:       cfg.HTTPServer.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPServer.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPServer.WriteTimeout,
+		IdleTimeout:       cfg.HTTPServer.IdleTimeout,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Error starting server", sl.Err(err))
+			exitCode.Store(1)
+			stop()
+		}
+	}()
+
+	adminSrv := newAdminServer(cfg.Admin, newAdminRouter(log, adminDeps{
+		cfg:       cfg.Admin,
+		started:   started,
+		level:     level,
+		overrides: overrides,
+		checker:   checker,
+		reloader:  reloader,
+		storage:   storage,
+		spec:      spec,
+	}))
+
+	log.Info("Starting admin server", slog.String("addr", adminSrv.Addr))
+
+	go func() {
+		if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Error starting admin server", sl.Err(err))
+			exitCode.Store(1)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	log.Info("shutting down server", slog.Duration("grace period", cfg.HTTPServer.ShutdownTimeout))
+
+	// Сначала /readyz начинает отвечать ошибкой, чтобы балансировщик успел убрать инстанс из ротации
+	checker.SetDraining(true)
+	time.Sleep(cfg.HTTPServer.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("error draining http connections", sl.Err(err))
+		exitCode.Store(1)
+	}
+
+	// Admin-listener останавливается последним, чтобы /readyz до конца сообщал о draining
+	if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+		log.Error("error stopping admin server", sl.Err(err))
+	}
+
+	if err := waitJobs(shutdownCtx, &jobs); err != nil {
+		log.Error("error waiting for background jobs", sl.Err(err))
+		exitCode.Store(1)
+	}
+
+	storage.Close(shutdownCtx)
+
+	log.Info("server stopped")
+
+	return int(exitCode.Load())
+}
+
+// waitJobs дожидается завершения фоновых задач, но не дольше, чем позволяет ctx.
+func waitJobs(ctx context.Context, jobs *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,608 @@
+package main
+
+import (
+	"contact-api/pkg/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// commandContext отменяется по Ctrl+C, чтобы длинные выгрузки и импорт прерывались без ожидания таймаута.
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+func runList(args []string) error {
+	fs := newFlagSet("list")
+	connect := clientFlags(fs)
+	output := outputFlag(fs)
+	limit := fs.Int("limit", 0, "page size, 0 - all contacts")
+	cursor := fs.String("cursor", "", "cursor of the page to fetch")
+	sort := fs.String("sort", "", "sort by custom field: field.NAME or -field.NAME")
+	var filters filterFlags
+	fs.Var(&filters, "field", "custom field filter name=value or name[op]=value, op: eq ne gt gte lt lte (repeatable)")
+	var tags stringsFlag
+	fs.Var(&tags, "tag", "only contacts with this tag (repeatable, all must match)")
+	group := fs.String("group", "", "only members of the group with this id")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	format, err := output()
+	if err != nil {
+		return err
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	page, err := c.List(ctx, client.ListOptions{
+		Limit:   *limit,
+		Cursor:  *cursor,
+		Filters: filters,
+		Sort:    *sort,
+		Tags:    tags,
+		Group:   *group,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := printContacts(stdout, format, page.Contacts); err != nil {
+		return err
+	}
+
+	if page.NextCursor != "" {
+		fmt.Fprintf(stderr, "next page: -cursor %s\n", page.NextCursor)
+	}
+
+	return nil
+}
+
+func runGet(args []string) error {
+	fs := newFlagSet("get")
+	connect := clientFlags(fs)
+	output := outputFlag(fs)
+
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errors.New("usage: contactctl get <id>...")
+	}
+
+	format, err := output()
+	if err != nil {
+		return err
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	contacts := make([]client.Contact, 0, len(ids))
+	for _, id := range ids {
+		contact, err := c.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		contacts = append(contacts, *contact)
+	}
+
+	return printContacts(stdout, format, contacts)
+}
+
+// runSearch ищет по всей коллекции на стороне клиента: сервер не поддерживает фильтрацию.
+func runSearch(args []string) error {
+	fs := newFlagSet("search")
+	connect := clientFlags(fs)
+	output := outputFlag(fs)
+
+	terms, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(terms) == 0 {
+		return errors.New("usage: contactctl search <text>")
+	}
+
+	format, err := output()
+	if err != nil {
+		return err
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	query := strings.ToLower(strings.Join(terms, " "))
+
+	var found []client.Contact
+	for contact, err := range c.All(ctx, client.MaxPageSize) {
+		if err != nil {
+			return err
+		}
+		if matches(contact, query) {
+			found = append(found, contact)
+		}
+	}
+
+	return printContacts(stdout, format, found)
+}
+
+func matches(c client.Contact, query string) bool {
+	fields := []string{c.UserName, c.Name.Display, c.Name.Given, c.Name.Family, c.Organization}
+	for _, e := range c.Emails {
+		fields = append(fields, e.Address)
+	}
+	for _, p := range c.Phones {
+		fields = append(fields, p.Number)
+	}
+
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// contactFlags регистрирует флаги полей контакта. set возвращает только явно заданные поля,
+// чтобы update не затирал остальные.
+type contactFlags struct {
+	username, name, email, mobile, home, organization, title, birthday, tags string
+
+	fs *flag.FlagSet
+}
+
+func newContactFlags(fs *flag.FlagSet) *contactFlags {
+	cf := &contactFlags{fs: fs}
+	fs.StringVar(&cf.username, "username", "", "contact username")
+	fs.StringVar(&cf.name, "name", "", "display name")
+	fs.StringVar(&cf.email, "email", "", "primary email, empty to remove it")
+	fs.StringVar(&cf.mobile, "mobile", "", "mobile phone, empty to remove it")
+	fs.StringVar(&cf.home, "home", "", "home phone, empty to remove it")
+	fs.StringVar(&cf.organization, "organization", "", "organization")
+	fs.StringVar(&cf.title, "title", "", "job title")
+	fs.StringVar(&cf.birthday, "birthday", "", "birthday, YYYY-MM-DD or --MM-DD")
+	fs.StringVar(&cf.tags, "tags", "", "comma-separated tags replacing the current ones, empty to remove all")
+	return cf
+}
+
+// apply переносит в контакт явно заданные флаги и сообщает, был ли задан хоть один.
+// Email и телефоны меняются в списках: устаревшие поля сервер учитывает, только если списки пусты.
+func (cf *contactFlags) apply(c *client.Contact) bool {
+	changed := false
+
+	// Контакт из файла старого формата: переносим устаревшие поля в списки так же, как сервер,
+	// иначе изменение одного телефона скрыло бы второй
+	if len(c.Emails) == 0 && c.Email != "" {
+		c.Emails = []client.Email{{Label: client.LabelOther, Address: c.Email}}
+	}
+	if len(c.Phones) == 0 {
+		if c.Telephone.Mobile != "" {
+			c.Phones = append(c.Phones, client.Phone{Label: client.LabelMobile, Number: c.Telephone.Mobile})
+		}
+		if c.Telephone.Home != "" {
+			c.Phones = append(c.Phones, client.Phone{Label: client.LabelHome, Number: c.Telephone.Home})
+		}
+	}
+	c.Email, c.Telephone = "", client.Telephone{}
+
+	cf.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "username":
+			c.UserName = cf.username
+		case "name":
+			c.Name.Display = cf.name
+		case "email":
+			c.Emails = setFirst(c.Emails, cf.email == "",
+				func(client.Email) bool { return true },
+				func(e *client.Email) { e.Address = cf.email },
+				client.Email{Label: client.LabelOther, Address: cf.email})
+		case "mobile":
+			c.Phones = setPhone(c.Phones, client.LabelMobile, cf.mobile)
+		case "home":
+			c.Phones = setPhone(c.Phones, client.LabelHome, cf.home)
+		case "organization":
+			c.Organization = cf.organization
+		case "title":
+			c.Title = cf.title
+		case "birthday":
+			c.Birthday = cf.birthday
+		case "tags":
+			c.Tags = splitList(cf.tags)
+		default:
+			return
+		}
+		changed = true
+	})
+
+	return changed
+}
+
+func setPhone(phones []client.Phone, label, number string) []client.Phone {
+	return setFirst(phones, number == "",
+		func(p client.Phone) bool { return p.Label == label },
+		func(p *client.Phone) { p.Number = number },
+		client.Phone{Label: label, Number: number})
+}
+
+// setFirst меняет первый подходящий элемент списка, удаляет его при remove
+// или добавляет item в конец, если подходящего нет.
+func setFirst[T any](list []T, remove bool, match func(T) bool, set func(*T), item T) []T {
+	for i := range list {
+		if !match(list[i]) {
+			continue
+		}
+		if remove {
+			return append(list[:i:i], list[i+1:]...)
+		}
+		set(&list[i])
+		return list
+	}
+
+	if remove {
+		return list
+	}
+	return append(list, item)
+}
+
+func runCreate(args []string) error {
+	fs := newFlagSet("create")
+	connect := clientFlags(fs)
+	fields := newContactFlags(fs)
+	file := fs.String("f", "", "read the contact from a JSON file, \"-\" for stdin")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	var contact client.Contact
+
+	if *file != "" {
+		contacts, err := readFile(*file, "json")
+		if err != nil {
+			return err
+		}
+		if len(contacts) != 1 {
+			return fmt.Errorf("%s: expected one contact, got %d; use import for several", *file, len(contacts))
+		}
+		contact = contacts[0]
+	}
+
+	// Флаги перекрывают поля из файла
+	if !fields.apply(&contact) && *file == "" {
+		return errors.New("nothing to create: pass -username and other fields or -f file")
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	id, err := c.Create(ctx, contact)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, id)
+
+	return nil
+}
+
+func runUpdate(args []string) error {
+	fs := newFlagSet("update")
+	connect := clientFlags(fs)
+	output := outputFlag(fs)
+	fields := newContactFlags(fs)
+
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return errors.New("usage: contactctl update <id> [-username ...] [-name ...] [-email ...] [-mobile ...] [-home ...] [-organization ...] [-title ...] [-birthday ...] [-tags ...]")
+	}
+
+	format, err := output()
+	if err != nil {
+		return err
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	// Отправляются только поля, изменённые флагами, поэтому остальные поля не затираются
+	contact, err := c.Patch(ctx, ids[0], func(contact *client.Contact) error {
+		if !fields.apply(contact) {
+			return errors.New("nothing to update: pass at least one of -username, -name, -email, -mobile, -home, -organization, -title, -birthday, -tags")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printContacts(stdout, format, []client.Contact{*contact})
+}
+
+func runDelete(args []string) error {
+	fs := newFlagSet("delete")
+	connect := clientFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "show what would be deleted")
+
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errors.New("usage: contactctl delete <id>...")
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	var failed int
+	for _, id := range ids {
+		if *dryRun {
+			// Проверяем существование, чтобы dry-run показывал то же, что и настоящее удаление
+			contact, err := c.Get(ctx, id)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s: %s\n", id, err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(stdout, "would delete %s (%s)\n", id, contact.UserName)
+			continue
+		}
+
+		if err := c.Delete(ctx, id); err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", id, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "deleted %s\n", id)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d contacts not deleted", failed, len(ids))
+	}
+
+	return nil
+}
+
+func runDeleteAll(args []string) error {
+	fs := newFlagSet("delete-all")
+	connect := clientFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "count contacts without deleting them")
+	yes := fs.Bool("yes", false, "confirm deletion of all contacts")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	if !*dryRun && !*yes {
+		return errors.New("refusing to delete all contacts without -yes (use -dry-run to count them)")
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if *dryRun {
+		var count int
+		for _, err := range c.All(ctx, client.MaxPageSize) {
+			if err != nil {
+				return err
+			}
+			count++
+		}
+
+		fmt.Fprintf(stdout, "would delete %d contacts\n", count)
+		return nil
+	}
+
+	deleted, err := c.DeleteAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "deleted %d contacts\n", deleted)
+
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := newFlagSet("import")
+	connect := clientFlags(fs)
+	format := fs.String("format", "", "file format: json, csv, vcard (default: by extension, json for stdin)")
+	dryRun := fs.Bool("dry-run", false, "parse the file and report contacts without creating them")
+
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return errors.New("usage: contactctl import <file|->")
+	}
+
+	f, err := detectFormat(*format, files[0])
+	if err != nil {
+		return err
+	}
+
+	contacts, err := readFile(files[0], f)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		for i, contact := range contacts {
+			if contact.UserName == "" {
+				fmt.Fprintf(stderr, "record %d: username is empty\n", i+1)
+			}
+		}
+		fmt.Fprintf(stdout, "would import %d contacts\n", len(contacts))
+		return nil
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	results, err := c.Batch(ctx, contacts)
+
+	var failed int
+	for _, res := range results {
+		if res.Err != nil {
+			fmt.Fprintf(stderr, "record %d (%s): %s\n", res.Index+1, contacts[res.Index].UserName, res.Err)
+			failed++
+		}
+	}
+
+	fmt.Fprintf(stdout, "imported %d of %d contacts\n", len(results)-failed, len(contacts))
+
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d contacts failed", failed)
+	}
+
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	connect := clientFlags(fs)
+	format := fs.String("format", "", "file format: json, csv, vcard (default: by extension of -o, json for stdout)")
+	out := fs.String("o", "-", "output file, \"-\" for stdout")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	f, err := detectFormat(*format, *out)
+	if err != nil {
+		return err
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	var w io.Writer = stdout
+	if *out != "-" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	// В CSV колонки нужны заранее, поэтому набор пользовательских полей берётся из описаний
+	var fields []string
+	if f == "csv" {
+		defs, err := c.Fields(ctx)
+		if err != nil {
+			return err
+		}
+		for _, d := range defs {
+			fields = append(fields, d.Name)
+		}
+	}
+
+	e, err := newExporter(f, w, fields)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	for contact, err := range c.All(ctx, client.MaxPageSize) {
+		if err != nil {
+			return err
+		}
+		if err := e.write(contact); err != nil {
+			return err
+		}
+		count++
+	}
+
+	if err := e.close(); err != nil {
+		return err
+	}
+
+	if *out != "-" {
+		fmt.Fprintf(stderr, "exported %d contacts to %s\n", count, *out)
+	}
+
+	return nil
+}
+
+// readFile читает контакты из файла или stdin ("-").
+func readFile(path, format string) ([]client.Contact, error) {
+	if path == "-" {
+		return readContacts(format, stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	contacts, err := readContacts(format, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return contacts, nil
+}
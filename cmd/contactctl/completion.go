@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const bashCompletion = `# bash completion for contactctl
+_contactctl() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+        return
+    fi
+    case "${COMP_WORDS[1]}" in
+        profile)
+            if [ "$COMP_CWORD" -eq 2 ]; then
+                COMPREPLY=($(compgen -W "list use set delete" -- "$cur"))
+            fi ;;
+        fields)
+            if [ "$COMP_CWORD" -eq 2 ]; then
+                COMPREPLY=($(compgen -W "list set delete" -- "$cur"))
+            fi ;;
+        groups)
+            if [ "$COMP_CWORD" -eq 2 ]; then
+                COMPREPLY=($(compgen -W "list create update delete members add remove" -- "$cur"))
+            fi ;;
+        tags)
+            if [ "$COMP_CWORD" -eq 2 ]; then
+                COMPREPLY=($(compgen -W "list add remove" -- "$cur"))
+            fi ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+        import)
+            COMPREPLY=($(compgen -f -- "$cur")) ;;
+    esac
+}
+complete -o default -F _contactctl contactctl
+`
+
+const zshCompletion = `#compdef contactctl
+_contactctl() {
+    if (( CURRENT == 2 )); then
+        compadd -- %[1]s
+        return
+    fi
+    case "${words[2]}" in
+        profile) (( CURRENT == 3 )) && compadd -- list use set delete ;;
+        fields) (( CURRENT == 3 )) && compadd -- list set delete ;;
+        groups) (( CURRENT == 3 )) && compadd -- list create update delete members add remove ;;
+        tags) (( CURRENT == 3 )) && compadd -- list add remove ;;
+        completion) compadd -- bash zsh fish ;;
+        *) _files ;;
+    esac
+}
+compdef _contactctl contactctl
+`
+
+const fishCompletion = `# fish completion for contactctl
+complete -c contactctl -f
+complete -c contactctl -n "__fish_use_subcommand" -a "%[1]s"
+complete -c contactctl -n "__fish_seen_subcommand_from profile" -a "list use set delete"
+complete -c contactctl -n "__fish_seen_subcommand_from fields" -a "list set delete"
+complete -c contactctl -n "__fish_seen_subcommand_from groups" -a "list create update delete members add remove"
+complete -c contactctl -n "__fish_seen_subcommand_from tags" -a "list add remove"
+complete -c contactctl -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c contactctl -n "__fish_seen_subcommand_from import" -F
+`
+
+// runCompletion выводит скрипт автодополнения; список команд берётся из commands.
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: contactctl completion bash|zsh|fish")
+	}
+
+	names := strings.Join(slices.Sorted(maps.Keys(commands)), " ")
+
+	switch args[0] {
+	case "bash":
+		fmt.Fprintf(stdout, bashCompletion, names)
+	case "zsh":
+		fmt.Fprintf(stdout, zshCompletion, names)
+	case "fish":
+		fmt.Fprintf(stdout, fishCompletion, names)
+	default:
+		return fmt.Errorf("unsupported shell %q", args[0])
+	}
+
+	return nil
+}
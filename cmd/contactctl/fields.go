@@ -0,0 +1,182 @@
+package main
+
+import (
+	"contact-api/pkg/client"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// runFields управляет пользовательскими полями: list, set <name> [flags], delete <name>.
+// set и delete требуют токен администратора (-token или token профиля).
+func runFields(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		fs := newFlagSet("fields list")
+		connect := clientFlags(fs)
+		output := outputFlag(fs)
+		if _, err := parseArgs(fs, args[1:]); err != nil {
+			return err
+		}
+
+		format, err := output()
+		if err != nil {
+			return err
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		defs, err := c.Fields(ctx)
+		if err != nil {
+			return err
+		}
+
+		return printFields(stdout, format, defs)
+
+	case "set":
+		fs := newFlagSet("fields set")
+		connect := clientFlags(fs)
+		typ := fs.String("type", "", "field type: string, number, date, enum, url")
+		required := fs.Bool("required", false, "reject contacts without a value")
+		values := fs.String("values", "", "comma-separated allowed values for enum")
+		description := fs.String("description", "", "field description")
+
+		names, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(names) != 1 || *typ == "" {
+			return errors.New("usage: contactctl fields set <name> -type TYPE [-required] [-values a,b] [-description TEXT]")
+		}
+
+		def := client.FieldDefinition{
+			Name:        names[0],
+			Type:        *typ,
+			Required:    *required,
+			Description: *description,
+		}
+		if *values != "" {
+			for _, v := range strings.Split(*values, ",") {
+				def.Values = append(def.Values, strings.TrimSpace(v))
+			}
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		created, err := c.PutField(ctx, def)
+		if err != nil {
+			if errors.Is(err, client.ErrConflict) {
+				return fmt.Errorf("%w (delete the field to change its type)", err)
+			}
+			return err
+		}
+
+		if created {
+			fmt.Fprintf(stdout, "created field %s\n", def.Name)
+		} else {
+			fmt.Fprintf(stdout, "updated field %s\n", def.Name)
+		}
+		return nil
+
+	case "delete":
+		fs := newFlagSet("fields delete")
+		connect := clientFlags(fs)
+
+		names, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(names) != 1 {
+			return errors.New("usage: contactctl fields delete <name>")
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := c.DeleteField(ctx, names[0]); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "deleted field %s and its values\n", names[0])
+		return nil
+	}
+
+	return fmt.Errorf("unknown fields command %q", args[0])
+}
+
+var filterFlagRe = regexp.MustCompile(`^([a-z][a-z0-9_]*)(?:\[([a-z]+)\])?=(.*)$`)
+
+// filterFlags - повторяемый флаг -field name=value или name[op]=value.
+type filterFlags []client.FieldFilter
+
+func (f *filterFlags) String() string {
+	parts := make([]string, len(*f))
+	for i, ff := range *f {
+		parts[i] = ff.Field + "[" + ff.Op + "]=" + ff.Value
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *filterFlags) Set(s string) error {
+	m := filterFlagRe.FindStringSubmatch(s)
+	if m == nil {
+		return errors.New("expected name=value or name[op]=value")
+	}
+
+	*f = append(*f, client.FieldFilter{Field: m[1], Op: m[2], Value: m[3]})
+	return nil
+}
+
+// Префиксы пользовательских полей в колонках CSV и свойствах vCard
+const (
+	csvFieldPrefix   = "field."
+	vcardFieldPrefix = "X-FIELD-"
+)
+
+// fieldString переводит значение поля в текст для CSV и vCard; сервер принимает числа и строкой.
+func fieldString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func vcardFieldProp(name string) string {
+	return vcardFieldPrefix + strings.ToUpper(strings.ReplaceAll(name, "_", "-"))
+}
+
+func vcardFieldName(prop string) (string, bool) {
+	name, ok := strings.CutPrefix(prop, vcardFieldPrefix)
+	if !ok || name == "" {
+		return "", false
+	}
+	return strings.ToLower(strings.ReplaceAll(name, "-", "_")), true
+}
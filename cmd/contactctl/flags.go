@@ -0,0 +1,146 @@
+package main
+
+import (
+	"contact-api/pkg/client"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+)
+
+var outputFormats = []string{"table", "json", "yaml"}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// usageError - ошибка разбора флагов; сообщение и справку уже вывел flag.FlagSet.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+
+func (e *usageError) Unwrap() error { return e.err }
+
+// parseArgs разбирает флаги вперемешку с позиционными аргументами,
+// чтобы работали и "delete -dry-run ID", и "delete ID -dry-run".
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{err: err}
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// clientFlags регистрирует флаги подключения. Явно заданные флаги и переменные окружения
+// перекрывают значения профиля.
+func clientFlags(fs *flag.FlagSet) func() (*client.Client, error) {
+	profile := fs.String("profile", os.Getenv("CONTACTCTL_PROFILE"), "profile from the config file (env CONTACTCTL_PROFILE)")
+	server := fs.String("server", os.Getenv("CONTACTCTL_SERVER"), "server base URL (env CONTACTCTL_SERVER)")
+	apiKey := fs.String("api-key", os.Getenv("CONTACTCTL_API_KEY"), "API key sent in X-API-Key (env CONTACTCTL_API_KEY)")
+	token := fs.String("token", os.Getenv("CONTACTCTL_TOKEN"), "bearer token, required for admin commands (env CONTACTCTL_TOKEN)")
+	timeout := fs.Duration("timeout", 0, "request timeout")
+
+	return func() (*client.Client, error) {
+		path, err := configPath()
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := f.resolve(*profile)
+		if err != nil {
+			return nil, err
+		}
+
+		if *server != "" {
+			p.Server = *server
+		}
+		if *apiKey != "" {
+			p.APIKey = *apiKey
+		}
+		if *token != "" {
+			p.Token = *token
+		}
+		if *timeout > 0 {
+			p.Timeout = *timeout
+		}
+
+		if p.Server == "" {
+			return nil, ErrNoProfile
+		}
+
+		return newClient(p)
+	}
+}
+
+func newClient(p Profile) (*client.Client, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	opts := []client.Option{
+		client.WithHTTPClient(&http.Client{Timeout: timeout}),
+		client.WithUserAgent("contactctl"),
+	}
+
+	var auth []client.Authenticator
+	if p.APIKey != "" {
+		auth = append(auth, client.APIKey(p.APIKey))
+	}
+	if p.Token != "" {
+		auth = append(auth, client.BearerToken(p.Token))
+	}
+	if len(auth) > 0 {
+		opts = append(opts, client.WithAuth(chainAuth(auth)))
+	}
+
+	return client.New(p.Server, opts...)
+}
+
+func chainAuth(auth []client.Authenticator) client.Authenticator {
+	return client.AuthFunc(func(req *http.Request) error {
+		for _, a := range auth {
+			if err := a.Authenticate(req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// outputFlag регистрирует -o и проверяет значение.
+func outputFlag(fs *flag.FlagSet) func() (string, error) {
+	output := fs.String("o", "table", "output format: table, json, yaml")
+
+	return func() (string, error) {
+		if !slices.Contains(outputFormats, *output) {
+			return "", fmt.Errorf("unknown output format %q", *output)
+		}
+		return *output, nil
+	}
+}
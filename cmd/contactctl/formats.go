@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bufio"
+	"contact-api/pkg/client"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var fileFormats = []string{"json", "csv", "vcard"}
+
+// Списки в CSV представлены основными значениями; полный контакт сохраняют json и vcard.
+// Метки записываются в одну колонку через запятую. За ними идут колонки
+// пользовательских полей с префиксом csvFieldPrefix.
+var csvHeader = []string{"id", "username", "name", "email", "mobile", "home", "organization", "title", "birthday", "tags"}
+
+// detectFormat определяет формат по расширению файла, если он не задан явно.
+func detectFormat(format, path string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = "csv"
+		case ".vcf", ".vcard":
+			format = "vcard"
+		default:
+			format = "json"
+		}
+	}
+
+	if !slices.Contains(fileFormats, format) {
+		return "", fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(fileFormats, ", "))
+	}
+
+	return format, nil
+}
+
+// exporter пишет контакты по одному, чтобы выгрузка не держала всю коллекцию в памяти.
+type exporter interface {
+	write(c client.Contact) error
+	close() error
+}
+
+// newExporter создаёт выгрузку; fields - имена пользовательских полей для колонок CSV.
+func newExporter(format string, w io.Writer, fields []string) (exporter, error) {
+	switch format {
+	case "csv":
+		header := slices.Clone(csvHeader)
+		for _, name := range fields {
+			header = append(header, csvFieldPrefix+name)
+		}
+
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvExporter{w: cw, fields: fields}, nil
+	case "vcard":
+		return &vcardExporter{w: w}, nil
+	}
+
+	return &jsonExporter{w: w}, nil
+}
+
+type jsonExporter struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonExporter) write(c client.Contact) error {
+	data, err := json.MarshalIndent(c, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if e.count == 0 {
+		sep = "[\n  "
+	}
+	e.count++
+
+	_, err = fmt.Fprintf(e.w, "%s%s", sep, data)
+	return err
+}
+
+func (e *jsonExporter) close() error {
+	if e.count == 0 {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+type csvExporter struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func (e *csvExporter) write(c client.Contact) error {
+	record := []string{
+		c.ID, c.UserName, c.Name.Display,
+		primaryEmail(c), phoneNumber(c, client.LabelMobile), phoneNumber(c, client.LabelHome),
+		c.Organization, c.Title, c.Birthday, strings.Join(c.Tags, ","),
+	}
+	for _, name := range e.fields {
+		record = append(record, fieldString(c.Fields[name]))
+	}
+
+	return e.w.Write(record)
+}
+
+func (e *csvExporter) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type vcardExporter struct {
+	w io.Writer
+}
+
+func (e *vcardExporter) write(c client.Contact) error {
+	fn := c.Name.Display
+	if fn == "" {
+		fn = c.UserName
+	}
+
+	lines := []string{"BEGIN:VCARD", "VERSION:3.0"}
+	if c.ID != "" {
+		lines = append(lines, "UID:"+vcardEscape(c.ID))
+	}
+	lines = append(lines,
+		"FN:"+vcardEscape(fn),
+		"N:"+vcardJoin(c.Name.Family, c.Name.Given, "", "", ""),
+		"NICKNAME:"+vcardEscape(c.UserName),
+	)
+
+	emails := c.Emails
+	if len(emails) == 0 && c.Email != "" {
+		emails = []client.Email{{Label: client.LabelOther, Address: c.Email}}
+	}
+	for _, em := range emails {
+		lines = append(lines, "EMAIL;TYPE="+vcardTypes("INTERNET", em.Label)+":"+vcardEscape(em.Address))
+	}
+
+	phones := c.Phones
+	if len(phones) == 0 {
+		if c.Telephone.Mobile != "" {
+			phones = append(phones, client.Phone{Label: client.LabelMobile, Number: c.Telephone.Mobile})
+		}
+		if c.Telephone.Home != "" {
+			phones = append(phones, client.Phone{Label: client.LabelHome, Number: c.Telephone.Home})
+		}
+	}
+	for _, p := range phones {
+		lines = append(lines, "TEL;TYPE="+vcardTypes("VOICE", p.Label)+":"+vcardEscape(p.Number))
+	}
+
+	for _, a := range c.Addresses {
+		lines = append(lines, "ADR;TYPE="+vcardTypes("POSTAL", a.Label)+":"+vcardJoin("", "", a.Street, a.City, a.Region, a.PostalCode, a.Country))
+	}
+	for _, u := range c.URLs {
+		prop := "URL"
+		if t := vcardTypes("", u.Label); t != "" {
+			prop += ";TYPE=" + t
+		}
+		lines = append(lines, prop+":"+u.URL)
+	}
+
+	for _, prop := range []struct{ name, value string }{
+		{"ORG", c.Organization},
+		{"TITLE", c.Title},
+		{"BDAY", c.Birthday},
+		{"NOTE", c.Notes},
+	} {
+		if prop.value != "" {
+			lines = append(lines, prop.name+":"+vcardEscape(prop.value))
+		}
+	}
+
+	if len(c.Tags) > 0 {
+		lines = append(lines, "CATEGORIES:"+vcardJoinList(c.Tags))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Fields)) {
+		if v := fieldString(c.Fields[name]); v != "" {
+			lines = append(lines, vcardFieldProp(name)+":"+vcardEscape(v))
+		}
+	}
+
+	lines = append(lines, "END:VCARD")
+
+	_, err := io.WriteString(e.w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
+
+// Соответствие меток модели типам vCard; other передаётся только базовым типом
+var vcardLabelTypes = map[string]string{
+	client.LabelHome:   "HOME",
+	client.LabelWork:   "WORK",
+	client.LabelMobile: "CELL",
+	client.LabelFax:    "FAX",
+}
+
+func vcardTypes(base, label string) string {
+	t, ok := vcardLabelTypes[label]
+	switch {
+	case !ok:
+		return base
+	case base == "" || label == client.LabelMobile || label == client.LabelFax:
+		return t
+	}
+	return base + "," + t
+}
+
+// vcardLabel выбирает метку по параметрам свойства: TYPE=WORK, TYPE=cell,voice и v2.1-вариант без TYPE=.
+func vcardLabel(params string, allowed ...string) string {
+	types := strings.ToUpper(params)
+	for _, label := range allowed {
+		if strings.Contains(types, vcardLabelTypes[label]) {
+			return label
+		}
+	}
+	return client.LabelOther
+}
+
+func (e *vcardExporter) close() error {
+	return nil
+}
+
+// readContacts разбирает файл импорта. Идентификаторы из файла сохраняются,
+// но при создании сервер назначает новые.
+func readContacts(format string, r io.Reader) ([]client.Contact, error) {
+	switch format {
+	case "csv":
+		return readCSV(r)
+	case "vcard":
+		return readVCard(r)
+	}
+
+	return readJSON(r)
+}
+
+func readJSON(r io.Reader) ([]client.Contact, error) {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+
+	first, err := br.Peek(1)
+	for err == nil && strings.TrimSpace(string(first)) == "" {
+		_, _ = br.ReadByte()
+		first, err = br.Peek(1)
+	}
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	// Массив или поток объектов (NDJSON)
+	if err == nil && first[0] == '[' {
+		var contacts []client.Contact
+		if err := dec.Decode(&contacts); err != nil {
+			return nil, fmt.Errorf("invalid json: %w", err)
+		}
+		return contacts, nil
+	}
+
+	var contacts []client.Contact
+	for {
+		var c client.Contact
+		if err := dec.Decode(&c); err != nil {
+			if errors.Is(err, io.EOF) {
+				return contacts, nil
+			}
+			return nil, fmt.Errorf("invalid json in record %d: %w", len(contacts)+1, err)
+		}
+		contacts = append(contacts, c)
+	}
+}
+
+func readCSV(r io.Reader) ([]client.Contact, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	// Колонки ищутся по имени, поэтому их порядок и набор могут отличаться от выгрузки
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, errors.New("invalid csv header: username column is required")
+	}
+
+	var contacts []client.Contact
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return contacts, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv on line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		var fields map[string]any
+		for name := range columns {
+			key, ok := strings.CutPrefix(name, csvFieldPrefix)
+			if !ok {
+				continue
+			}
+			if v := field(name); v != "" {
+				if fields == nil {
+					fields = map[string]any{}
+				}
+				fields[key] = v
+			}
+		}
+
+		contacts = append(contacts, client.Contact{
+			ID:       field("id"),
+			UserName: field("username"),
+			Name:     client.Name{Display: field("name")},
+			Email:    field("email"),
+			Telephone: client.Telephone{
+				Mobile: field("mobile"),
+				Home:   field("home"),
+			},
+			Organization: field("organization"),
+			Title:        field("title"),
+			Birthday:     field("birthday"),
+			Fields:       fields,
+			Tags:         splitList(field("tags")),
+		})
+	}
+}
+
+// readVCard понимает vCard 3.0 и 4.0 в объёме полей модели: FN, N, NICKNAME, EMAIL, TEL, ADR, URL,
+// ORG, TITLE, BDAY, NOTE, UID, CATEGORIES для меток и X-FIELD-* для пользовательских полей. Метки берутся из TYPE;
+// неизвестные типы становятся other.
+func readVCard(r io.Reader) ([]client.Contact, error) {
+	lines, err := unfoldVCard(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		contacts []client.Contact
+		current  *client.Contact
+	)
+
+	for i, line := range lines {
+		name, params, raw, ok := parseVCardLine(line)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "BEGIN":
+			current = &client.Contact{}
+			continue
+		case "END":
+			if current == nil {
+				return nil, fmt.Errorf("invalid vcard on line %d: END without BEGIN", i+1)
+			}
+			// Без NICKNAME именем пользователя служит FN
+			if current.UserName == "" {
+				current.UserName = current.Name.Display
+			}
+			contacts = append(contacts, *current)
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		value := vcardUnescape(raw)
+
+		switch name {
+		case "FN":
+			current.Name.Display = value
+		case "N":
+			parts := vcardSplit(raw)
+			current.Name.Family, current.Name.Given = parts[0], parts[1]
+		case "NICKNAME":
+			current.UserName = vcardSplit(raw)[0]
+		case "UID":
+			current.ID = value
+		case "EMAIL":
+			current.Emails = append(current.Emails, client.Email{
+				Label:   vcardLabel(params, client.LabelHome, client.LabelWork),
+				Address: value,
+			})
+		case "TEL":
+			current.Phones = append(current.Phones, client.Phone{
+				Label:  vcardLabel(params, client.LabelMobile, client.LabelFax, client.LabelHome, client.LabelWork),
+				Number: value,
+			})
+		case "ADR":
+			parts := vcardSplit(raw)
+			current.Addresses = append(current.Addresses, client.Address{
+				Label:      vcardLabel(params, client.LabelHome, client.LabelWork),
+				Street:     parts[2],
+				City:       parts[3],
+				Region:     parts[4],
+				PostalCode: parts[5],
+				Country:    parts[6],
+			})
+		case "URL":
+			current.URLs = append(current.URLs, client.URL{
+				Label: vcardLabel(params, client.LabelHome, client.LabelWork),
+				URL:   value,
+			})
+		case "ORG":
+			// Подразделения после первого компонента не сохраняются
+			current.Organization = vcardSplit(raw)[0]
+		case "TITLE":
+			current.Title = value
+		case "BDAY":
+			current.Birthday = value
+		case "NOTE":
+			current.Notes = value
+		case "CATEGORIES":
+			for _, tag := range vcardSplitBy(raw, ',') {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					current.Tags = append(current.Tags, tag)
+				}
+			}
+		default:
+			if field, ok := vcardFieldName(name); ok {
+				if current.Fields == nil {
+					current.Fields = map[string]any{}
+				}
+				current.Fields[field] = value
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, errors.New("invalid vcard: missing END:VCARD")
+	}
+
+	return contacts, nil
+}
+
+// unfoldVCard склеивает строки, перенесённые по RFC 6350 (продолжение начинается с пробела или табуляции).
+func unfoldVCard(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, sc.Err()
+}
+
+// parseVCardLine возвращает значение без снятия экранирования: составные значения сначала делятся по ';'.
+func parseVCardLine(line string) (name, params, value string, ok bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", "", false
+	}
+
+	name, params, _ = strings.Cut(head, ";")
+	// Группы вида item1.TEL
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return strings.ToUpper(name), params, value, true
+}
+
+// vcardSplit разбивает составное значение (N, ADR, ORG) по неэкранированным ';'.
+// Результат дополняется пустыми компонентами до семи, чтобы обращаться к ним по индексу.
+func vcardSplit(raw string) []string {
+	parts := vcardSplitBy(raw, ';')
+
+	for len(parts) < 7 {
+		parts = append(parts, "")
+	}
+
+	return parts
+}
+
+// vcardSplitBy разбивает значение по неэкранированным sep и снимает экранирование с частей.
+func vcardSplitBy(raw string, sep byte) []string {
+	var (
+		parts []string
+		cur   strings.Builder
+	)
+
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw):
+			cur.WriteByte(raw[i])
+			i++
+			cur.WriteByte(raw[i])
+		case raw[i] == sep:
+			parts = append(parts, vcardUnescape(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(raw[i])
+		}
+	}
+
+	return append(parts, vcardUnescape(cur.String()))
+}
+
+// vcardJoin собирает составное значение, экранируя компоненты.
+func vcardJoin(parts ...string) string {
+	for i, p := range parts {
+		parts[i] = vcardEscape(p)
+	}
+	return strings.Join(parts, ";")
+}
+
+// vcardJoinList собирает значение-список (CATEGORIES), экранируя элементы.
+func vcardJoinList(items []string) string {
+	escaped := make([]string, len(items))
+	for i, item := range items {
+		escaped[i] = vcardEscape(item)
+	}
+	return strings.Join(escaped, ",")
+}
+
+var (
+	vcardEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`)
+	vcardUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";")
+)
+
+func vcardEscape(s string) string {
+	return vcardEscaper.Replace(s)
+}
+
+func vcardUnescape(s string) string {
+	return vcardUnescaper.Replace(s)
+}
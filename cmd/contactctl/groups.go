@@ -0,0 +1,274 @@
+package main
+
+import (
+	"contact-api/pkg/client"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+)
+
+// runGroups управляет группами: list, create <name>, update <id>, delete <id>,
+// members <id>, add <id> <contact>..., remove <id> <contact>...
+func runGroups(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		fs := newFlagSet("groups list")
+		connect := clientFlags(fs)
+		output := outputFlag(fs)
+		if _, err := parseArgs(fs, args[1:]); err != nil {
+			return err
+		}
+
+		format, err := output()
+		if err != nil {
+			return err
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		groups, err := c.Groups(ctx)
+		if err != nil {
+			return err
+		}
+
+		return printGroups(stdout, format, groups)
+
+	case "create":
+		fs := newFlagSet("groups create")
+		connect := clientFlags(fs)
+		gf := newGroupFlags(fs)
+
+		names, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(names) != 1 {
+			return errors.New("usage: contactctl groups create <name> [-description TEXT] [-color #rrggbb]")
+		}
+
+		group := client.Group{Name: names[0]}
+		gf.apply(&group)
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		id, err := c.CreateGroup(ctx, group)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(stdout, id)
+		return nil
+
+	case "update":
+		fs := newFlagSet("groups update")
+		connect := clientFlags(fs)
+		gf := newGroupFlags(fs)
+
+		ids, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(ids) != 1 {
+			return errors.New("usage: contactctl groups update <id> [-name NAME] [-description TEXT] [-color #rrggbb]")
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		group, err := c.Group(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		if !gf.apply(group) {
+			return errors.New("nothing to update: pass at least one of -name, -description, -color")
+		}
+
+		if err := c.UpdateGroup(ctx, *group); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "updated group %s\n", ids[0])
+		return nil
+
+	case "delete":
+		fs := newFlagSet("groups delete")
+		connect := clientFlags(fs)
+
+		ids, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(ids) != 1 {
+			return errors.New("usage: contactctl groups delete <id>")
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		detached, err := c.DeleteGroup(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "deleted group %s, removed it from %d contacts\n", ids[0], detached)
+		return nil
+
+	case "members":
+		fs := newFlagSet("groups members")
+		connect := clientFlags(fs)
+		output := outputFlag(fs)
+		limit := fs.Int("limit", 0, "page size, 0 - all members")
+		cursor := fs.String("cursor", "", "cursor of the page to fetch")
+
+		ids, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(ids) != 1 {
+			return errors.New("usage: contactctl groups members <id> [-limit N] [-cursor C]")
+		}
+
+		format, err := output()
+		if err != nil {
+			return err
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		page, err := c.Members(ctx, ids[0], client.ListOptions{Limit: *limit, Cursor: *cursor})
+		if err != nil {
+			return err
+		}
+
+		if err := printContacts(stdout, format, page.Contacts); err != nil {
+			return err
+		}
+
+		if page.NextCursor != "" {
+			fmt.Fprintf(stderr, "next page: -cursor %s\n", page.NextCursor)
+		}
+
+		return nil
+
+	case "add", "remove":
+		fs := newFlagSet("groups " + args[0])
+		connect := clientFlags(fs)
+
+		rest, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(rest) < 2 {
+			return fmt.Errorf("usage: contactctl groups %s <id> <contact>...", args[0])
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		var (
+			total   client.MembershipChange
+			members int64
+		)
+		// Сервер принимает не больше MaxBatchSize контактов за запрос
+		for contacts := range slices.Chunk(rest[1:], client.MaxBatchSize) {
+			var add, remove []string
+			if args[0] == "add" {
+				add = contacts
+			} else {
+				remove = contacts
+			}
+
+			change, count, err := c.UpdateMembers(ctx, rest[0], add, remove)
+			if err != nil {
+				return err
+			}
+			total.Added += change.Added
+			total.Removed += change.Removed
+			members = count
+		}
+
+		if args[0] == "add" {
+			fmt.Fprintf(stdout, "added %d of %d contacts, group has %d members\n", total.Added, len(rest)-1, members)
+		} else {
+			fmt.Fprintf(stdout, "removed %d of %d contacts, group has %d members\n", total.Removed, len(rest)-1, members)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown groups command %q", args[0])
+}
+
+// groupFlags регистрирует флаги группы; apply переносит только явно заданные,
+// чтобы update не затирал остальные.
+type groupFlags struct {
+	name, description, color string
+
+	fs *flag.FlagSet
+}
+
+func newGroupFlags(fs *flag.FlagSet) *groupFlags {
+	gf := &groupFlags{fs: fs}
+	fs.StringVar(&gf.name, "name", "", "group name")
+	fs.StringVar(&gf.description, "description", "", "group description")
+	fs.StringVar(&gf.color, "color", "", "group color, #rrggbb")
+	return gf
+}
+
+func (gf *groupFlags) apply(g *client.Group) bool {
+	changed := false
+
+	gf.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			g.Name = gf.name
+		case "description":
+			g.Description = gf.description
+		case "color":
+			g.Color = gf.color
+		default:
+			return
+		}
+		changed = true
+	})
+
+	return changed
+}
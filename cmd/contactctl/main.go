@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage:
+  contactctl <command> [flags] [args]
+
+Commands:
+  list                         вывести контакты
+  get <id>...                  вывести контакты по идентификаторам
+  search <text>                найти контакты по имени, email или телефону
+  create                       создать контакт
+  update <id>                  изменить поля контакта
+  delete <id>...               удалить контакты
+  delete-all                   удалить все контакты
+  import <file|->              загрузить контакты из JSON, CSV или vCard
+  export                       выгрузить контакты в JSON, CSV или vCard
+  fields list|set|delete       управлять пользовательскими полями
+  groups list|create|update|delete|members|add|remove
+                               управлять группами и их составом
+  tags list|add|remove         управлять метками контактов
+  profile list|use|set|delete  управлять профилями серверов
+  completion bash|zsh|fish     вывести скрипт автодополнения
+
+Run "contactctl <command> -h" for command flags.
+
+Exit status: 0 - успех, 1 - ошибка команды или API, 2 - неверные аргументы.
+`
+
+// commands - команды и их обработчики; используется и для автодополнения.
+var commands = map[string]func(args []string) error{
+	"list":       runList,
+	"get":        runGet,
+	"search":     runSearch,
+	"create":     runCreate,
+	"update":     runUpdate,
+	"delete":     runDelete,
+	"delete-all": runDeleteAll,
+	"import":     runImport,
+	"export":     runExport,
+	"fields":     runFields,
+	"groups":     runGroups,
+	"tags":       runTags,
+	"profile":    runProfile,
+}
+
+func init() {
+	// completion перечисляет команды из commands, поэтому регистрируется отдельно от литерала
+	commands["completion"] = runCompletion
+}
+
+// Потоки ввода-вывода команд; тесты подменяют их буферами.
+var (
+	stdin  io.Reader = os.Stdin
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run выполняет команду и возвращает код завершения процесса.
+func run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err := cmd(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		// Ошибку разбора флагов вместе со справкой уже вывел flag.FlagSet
+		var usageErr *usageError
+		if errors.As(err, &usageErr) {
+			return 2
+		}
+
+		fmt.Fprintf(stderr, "contactctl: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
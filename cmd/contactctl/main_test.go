@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"contact-api/internal/app/apitest"
+	"contact-api/internal/app/domain/models"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	annID     = "000000000000000000000001"
+	bobID     = "000000000000000000000002"
+	missingID = "0000000000000000000000ff"
+)
+
+// setup запускает API с двумя контактами и направляет contactctl на него через окружение.
+// Файл профилей лежит во временном каталоге и изначально отсутствует.
+func setup(t *testing.T) (*httptest.Server, *apitest.Storage) {
+	t.Helper()
+
+	srv, store := apitest.NewServer(t)
+
+	for _, c := range []models.Contact{
+		{
+			UserName: "ann",
+			Name:     models.Name{Display: "Ann Lee"},
+			Emails:   []models.Email{{Label: models.LabelWork, Address: "ann@example.com"}},
+			Phones:   []models.Phone{{Label: models.LabelMobile, Number: "+100"}},
+			Tags:     []string{"vip"},
+		},
+		{
+			UserName: "bob",
+			Phones:   []models.Phone{{Label: models.LabelHome, Number: "+200"}},
+		},
+	} {
+		c.Normalize()
+		if _, err := store.Save(context.Background(), c); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	t.Setenv("CONTACTCTL_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("CONTACTCTL_PROFILE", "")
+	t.Setenv("CONTACTCTL_SERVER", srv.URL)
+	t.Setenv("CONTACTCTL_API_KEY", "")
+	t.Setenv("CONTACTCTL_TOKEN", "")
+
+	return srv, store
+}
+
+// execute запускает contactctl с подменёнными потоками и возвращает код завершения и вывод.
+func execute(t *testing.T, input string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var out, errOut bytes.Buffer
+	stdin, stdout, stderr = strings.NewReader(input), &out, &errOut
+	t.Cleanup(func() {
+		stdin, stdout, stderr = os.Stdin, os.Stdout, os.Stderr
+	})
+
+	code := run(args)
+
+	return code, out.String(), errOut.String()
+}
+
+type testCase struct {
+	name  string
+	args  []string
+	stdin string
+	code  int
+	// Вывод в stdout сравнивается целиком
+	stdout string
+	// Фрагменты, которые должны быть в stderr; без них stderr должен быть пуст
+	stderr []string
+	// На сколько изменилось число контактов на сервере
+	changed int
+}
+
+func (tt testCase) check(t *testing.T, code int, stdout, stderr string) {
+	t.Helper()
+
+	if code != tt.code {
+		t.Errorf("exit code %d, want %d; stderr: %s", code, tt.code, stderr)
+	}
+	if stdout != tt.stdout {
+		t.Errorf("stdout:\n%s\nwant:\n%s", stdout, tt.stdout)
+	}
+	if len(tt.stderr) == 0 && stderr != "" {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+	for _, s := range tt.stderr {
+		if !strings.Contains(stderr, s) {
+			t.Errorf("stderr %q does not contain %q", stderr, s)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := []testCase{
+		{name: "no command", code: 0, stdout: usage},
+		{name: "help", args: []string{"help"}, stdout: usage},
+		{name: "unknown command", args: []string{"nope"}, code: 2, stderr: []string{`unknown command "nope"`, "Usage:"}},
+
+		{
+			name: "list table",
+			args: []string{"list"},
+			stdout: "ID                        USERNAME  NAME     EMAIL            MOBILE  HOME\n" +
+				annID + "  ann       Ann Lee  ann@example.com  +100    \n" +
+				bobID + "  bob                                         +200\n",
+		},
+		{
+			name: "list json",
+			args: []string{"list", "-o", "json", "-tag", "vip"},
+			stdout: `[
+  {
+    "_id": "` + annID + `",
+    "username": "ann",
+    "name": {
+      "display": "Ann Lee"
+    },
+    "emails": [
+      {
+        "label": "work",
+        "address": "ann@example.com"
+      }
+    ],
+    "phones": [
+      {
+        "label": "mobile",
+        "number": "+100"
+      }
+    ],
+    "tags": [
+      "vip"
+    ],
+    "email": "ann@example.com",
+    "telephone": {
+      "mobile": "+100"
+    }
+  }
+]
+`,
+		},
+		{
+			name: "list yaml",
+			args: []string{"list", "-o", "yaml", "-limit", "1"},
+			stdout: "- _id: \"" + annID + "\"\n  email: ann@example.com\n  emails:\n    - address: ann@example.com\n      label: work\n" +
+				"  name:\n    display: Ann Lee\n  phones:\n    - label: mobile\n      number: \"+100\"\n" +
+				"  tags:\n    - vip\n  telephone:\n    mobile: \"+100\"\n  username: ann\n",
+			stderr: []string{"next page: -cursor "},
+		},
+		{name: "list empty result", args: []string{"list", "-o", "json", "-tag", "none"}, stdout: "[]\n"},
+		{name: "unknown output format", args: []string{"list", "-o", "xml"}, code: 1, stderr: []string{`contactctl: unknown output format "xml"`}},
+		{name: "unknown flag", args: []string{"list", "-bogus"}, code: 2, stderr: []string{"flag provided but not defined: -bogus", "Usage of list:"}},
+		{name: "bad flag value", args: []string{"list", "-limit", "many"}, code: 2, stderr: []string{`invalid value "many" for flag -limit`}},
+		{name: "command help", args: []string{"list", "-h"}, code: 0, stderr: []string{"Usage of list:", "-server"}},
+
+		{
+			name:   "get with flags after ids",
+			args:   []string{"get", bobID, "-o", "json"},
+			stdout: "[\n  {\n    \"_id\": \"" + bobID + "\",\n    \"username\": \"bob\",\n    \"name\": {},\n    \"phones\": [\n      {\n        \"label\": \"home\",\n        \"number\": \"+200\"\n      }\n    ],\n    \"telephone\": {\n      \"home\": \"+200\"\n    }\n  }\n]\n",
+		},
+		{name: "get without ids", args: []string{"get"}, code: 1, stderr: []string{"contactctl: usage: contactctl get <id>..."}},
+		{
+			name:   "search",
+			args:   []string{"search", "+200"},
+			stdout: "ID                        USERNAME  NAME  EMAIL  MOBILE  HOME\n" + bobID + "  bob                            +200\n",
+		},
+
+		{name: "create", args: []string{"create", "-username", "carol", "-email", "carol@example.com"}, stdout: "000000000000000000000003\n", changed: 1},
+		{name: "create from stdin", args: []string{"create", "-f", "-"}, stdin: `{"username":"carol"}`, stdout: "000000000000000000000003\n", changed: 1},
+		{name: "create without fields", args: []string{"create"}, code: 1, stderr: []string{"contactctl: nothing to create"}},
+		{
+			name:   "update",
+			args:   []string{"update", bobID, "-name", "Bob", "-mobile", "+300"},
+			stdout: "ID                        USERNAME  NAME  EMAIL  MOBILE  HOME\n" + bobID + "  bob       Bob          +300    +200\n",
+		},
+		{name: "update without fields", args: []string{"update", bobID}, code: 1, stderr: []string{"contactctl: nothing to update"}},
+
+		{name: "delete dry run", args: []string{"delete", "-dry-run", annID}, stdout: "would delete " + annID + " (ann)\n"},
+		{name: "delete", args: []string{"delete", annID, bobID}, stdout: "deleted " + annID + "\ndeleted " + bobID + "\n", changed: -2},
+		{name: "delete-all dry run", args: []string{"delete-all", "-dry-run"}, stdout: "would delete 2 contacts\n"},
+		{name: "delete-all without confirmation", args: []string{"delete-all"}, code: 1, stderr: []string{"refusing to delete all contacts without -yes"}},
+		{name: "delete-all", args: []string{"delete-all", "-yes"}, stdout: "deleted 2 contacts\n", changed: -2},
+
+		{
+			name: "export csv",
+			args: []string{"export", "-format", "csv"},
+			stdout: "id,username,name,email,mobile,home,organization,title,birthday,tags\n" +
+				annID + ",ann,Ann Lee,ann@example.com,+100,,,,,vip\n" +
+				bobID + ",bob,,,,+200,,,,\n",
+		},
+		{
+			name: "export vcard",
+			args: []string{"export", "-format", "vcard"},
+			stdout: "BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + annID + "\r\nFN:Ann Lee\r\nN:;;;;\r\nNICKNAME:ann\r\n" +
+				"EMAIL;TYPE=INTERNET,WORK:ann@example.com\r\nTEL;TYPE=CELL:+100\r\nCATEGORIES:vip\r\nEND:VCARD\r\n" +
+				"BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + bobID + "\r\nFN:bob\r\nN:;;;;\r\nNICKNAME:bob\r\n" +
+				"TEL;TYPE=VOICE,HOME:+200\r\nEND:VCARD\r\n",
+		},
+		{name: "export unknown format", args: []string{"export", "-format", "xml"}, code: 1, stderr: []string{"contactctl: "}},
+		{
+			name:    "import csv",
+			args:    []string{"import", "-format", "csv", "-"},
+			stdin:   "username,name,email\ncarol,Carol,carol@example.com\ndave,,\n",
+			stdout:  "imported 2 of 2 contacts\n",
+			changed: 2,
+		},
+		{
+			name:   "import dry run",
+			args:   []string{"import", "-dry-run", "-"},
+			stdin:  `[{"username":"carol"},{"username":"dave"}]`,
+			stdout: "would import 2 contacts\n",
+		},
+		{
+			name:    "import with rejected records",
+			args:    []string{"import", "-"},
+			stdin:   `[{"username":"carol"},{"username":"dave","birthday":"1990-13-01"}]`,
+			code:    1,
+			stdout:  "imported 1 of 2 contacts\n",
+			stderr:  []string{"record 2 (dave): contact api: 400 invalid contact", "contactctl: 1 contacts failed"},
+			changed: 1,
+		},
+		{name: "import malformed file", args: []string{"import", "-"}, stdin: `[{"username":`, code: 1, stderr: []string{"contactctl: "}},
+
+		{name: "not found", args: []string{"get", missingID}, code: 1, stderr: []string{"contactctl: " + missingID + ": contact api: 400 contact not found"}},
+		{
+			name:    "not found among several",
+			args:    []string{"delete", annID, missingID},
+			code:    1,
+			stdout:  "deleted " + annID + "\n",
+			stderr:  []string{missingID + ": contact api: 400 contact not found", "contactctl: 1 of 2 contacts not deleted"},
+			changed: -1,
+		},
+		{name: "invalid contact", args: []string{"create", "-username", "carol", "-birthday", "1990-13-01"}, code: 1, stderr: []string{"contactctl: contact api: 400 invalid contact"}},
+		{name: "admin command without token", args: []string{"fields", "set", "score", "-type", "number"}, code: 1, stderr: []string{"contactctl: contact api: 401"}},
+		{name: "admin command with token", args: []string{"fields", "set", "score", "-type", "number", "-token", apitest.AdminToken}, stdout: "created field score\n"},
+		{name: "server unreachable", args: []string{"list", "-server", "http://127.0.0.1:1", "-timeout", "1s"}, code: 1, stderr: []string{"contactctl: ", "127.0.0.1:1"}},
+
+		{name: "completion without shell", args: []string{"completion"}, code: 1, stderr: []string{"contactctl: usage: contactctl completion bash|zsh|fish"}},
+		{name: "completion unsupported shell", args: []string{"completion", "tcsh"}, code: 1, stderr: []string{`contactctl: unsupported shell "tcsh"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, store := setup(t)
+
+			code, stdout, stderr := execute(t, tt.stdin, tt.args...)
+			tt.check(t, code, stdout, stderr)
+
+			if n := store.Len(); n != 2+tt.changed {
+				t.Errorf("%d contacts stored, want %d", n, 2+tt.changed)
+			}
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	names := "completion create delete delete-all export fields get groups import list profile search tags update"
+
+	tests := []struct {
+		shell string
+		// Фрагменты скрипта: список команд и подкоманды profile
+		want []string
+	}{
+		{shell: "bash", want: []string{`compgen -W "` + names + `"`, `compgen -W "list use set delete"`, "complete -o default -F _contactctl contactctl"}},
+		{shell: "zsh", want: []string{names, "list use set delete", "#compdef contactctl"}},
+		{shell: "fish", want: []string{`-a "` + names + `"`, `-a "list use set delete"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			code, stdout, stderr := execute(t, "", "completion", tt.shell)
+			if code != 0 || stderr != "" {
+				t.Fatalf("exit code %d, stderr: %s", code, stderr)
+			}
+
+			for _, s := range tt.want {
+				if !strings.Contains(stdout, s) {
+					t.Errorf("script does not contain %q:\n%s", s, stdout)
+				}
+			}
+		})
+	}
+}
+
+// Шаги выполняются по очереди над одним файлом профилей.
+func TestProfiles(t *testing.T) {
+	srv, _ := setup(t)
+	t.Setenv("CONTACTCTL_SERVER", "")
+
+	// Сервер, на который не указывает ни один профиль, кроме "down"
+	const down = "http://127.0.0.1:1"
+
+	steps := []testCase{
+		{name: "no server configured", args: []string{"list"}, code: 1, stderr: []string{"contactctl: " + ErrNoProfile.Error()}},
+		{name: "empty list", args: []string{"profile", "list"}, stdout: "CURRENT  NAME  SERVER  AUTH\n"},
+		{name: "set without server", args: []string{"profile", "set", "local"}, code: 1, stderr: []string{"contactctl: -server is required"}},
+		{name: "set first becomes current", args: []string{"profile", "set", "local", "-server", srv.URL, "-api-key", "key"}},
+		{name: "set second", args: []string{"profile", "set", "down", "-server", down, "-timeout", "1s"}},
+		{
+			name:   "list",
+			args:   []string{"profile", "list"},
+			stdout: "CURRENT  NAME   SERVER                  AUTH\n         down   " + down + "      none\n*        local  " + srv.URL + "  api key\n",
+		},
+		{name: "current profile", args: []string{"list", "-o", "json", "-tag", "none"}, stdout: "[]\n"},
+		{name: "profile flag", args: []string{"list", "-profile", "down"}, code: 1, stderr: []string{"contactctl: ", "127.0.0.1:1"}},
+		{name: "server flag overrides profile", args: []string{"list", "-profile", "down", "-server", srv.URL, "-o", "json", "-tag", "none"}, stdout: "[]\n"},
+		{name: "unknown profile flag", args: []string{"list", "-profile", "prod"}, code: 1, stderr: []string{`contactctl: profile "prod" not found`}},
+		{name: "use unknown", args: []string{"profile", "use", "prod"}, code: 1, stderr: []string{`contactctl: profile "prod" not found`}},
+		{name: "use", args: []string{"profile", "use", "down"}},
+		{name: "switched", args: []string{"list"}, code: 1, stderr: []string{"contactctl: ", "127.0.0.1:1"}},
+		{name: "delete current", args: []string{"profile", "delete", "down"}},
+		{name: "no current profile", args: []string{"list"}, code: 1, stderr: []string{"contactctl: " + ErrNoProfile.Error()}},
+		{name: "unknown subcommand", args: []string{"profile", "rename"}, code: 1, stderr: []string{`contactctl: unknown profile command "rename"`}},
+	}
+
+	for _, tt := range steps {
+		code, stdout, stderr := execute(t, "", tt.args...)
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, code, stdout, stderr)
+		})
+	}
+}
+
+// Профиль из CONTACTCTL_PROFILE выбирается вместо текущего, а флаг -profile - вместо него.
+func TestProfileFromEnv(t *testing.T) {
+	srv, _ := setup(t)
+	t.Setenv("CONTACTCTL_SERVER", "")
+
+	for _, args := range [][]string{
+		{"profile", "set", "down", "-server", "http://127.0.0.1:1"},
+		{"profile", "set", "local", "-server", srv.URL},
+	} {
+		if code, _, stderr := execute(t, "", args...); code != 0 {
+			t.Fatalf("%v: %s", args, stderr)
+		}
+	}
+
+	t.Setenv("CONTACTCTL_PROFILE", "local")
+
+	tests := []testCase{
+		{name: "env profile", args: []string{"list", "-o", "json", "-tag", "none"}, stdout: "[]\n"},
+		{name: "flag over env", args: []string{"list", "-profile", "down", "-timeout", "1s"}, code: 1, stderr: []string{"127.0.0.1:1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := execute(t, "", tt.args...)
+			tt.check(t, code, stdout, stderr)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"contact-api/pkg/client"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printContacts выводит контакты в выбранном формате.
+func printContacts(w io.Writer, format string, contacts []client.Contact) error {
+	if contacts == nil {
+		contacts = []client.Contact{}
+	}
+
+	if format != "table" {
+		return printData(w, format, contacts)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUSERNAME\tNAME\tEMAIL\tMOBILE\tHOME")
+	for _, c := range contacts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.UserName, c.Name.Display,
+			primaryEmail(c), phoneNumber(c, client.LabelMobile), phoneNumber(c, client.LabelHome))
+	}
+
+	return tw.Flush()
+}
+
+// printFields выводит описания пользовательских полей в выбранном формате.
+func printFields(w io.Writer, format string, defs []client.FieldDefinition) error {
+	if defs == nil {
+		defs = []client.FieldDefinition{}
+	}
+
+	if format != "table" {
+		return printData(w, format, defs)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tREQUIRED\tVALUES\tDESCRIPTION")
+	for _, d := range defs {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\n", d.Name, d.Type, d.Required, strings.Join(d.Values, ","), d.Description)
+	}
+
+	return tw.Flush()
+}
+
+// printGroups выводит группы в выбранном формате.
+func printGroups(w io.Writer, format string, groups []client.Group) error {
+	if groups == nil {
+		groups = []client.Group{}
+	}
+
+	if format != "table" {
+		return printData(w, format, groups)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tMEMBERS\tCOLOR\tDESCRIPTION")
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", g.ID, g.Name, g.MemberCount, g.Color, g.Description)
+	}
+
+	return tw.Flush()
+}
+
+// printTags выводит метки с числом контактов в выбранном формате.
+func printTags(w io.Writer, format string, tags []client.TagCount) error {
+	if tags == nil {
+		tags = []client.TagCount{}
+	}
+
+	if format != "table" {
+		return printData(w, format, tags)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tCONTACTS")
+	for _, t := range tags {
+		fmt.Fprintf(tw, "%s\t%d\n", t.Tag, t.Count)
+	}
+
+	return tw.Flush()
+}
+
+// printData выводит v в json или yaml.
+func printData(w io.Writer, format string, v any) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	// Через JSON, чтобы ключи совпадали с API
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	return yaml.NewEncoder(w).Encode(generic)
+}
+
+// primaryEmail возвращает первый email контакта, в том числе из устаревшего поля.
+func primaryEmail(c client.Contact) string {
+	if len(c.Emails) > 0 {
+		return c.Emails[0].Address
+	}
+	return c.Email
+}
+
+// phoneNumber возвращает первый телефон с меткой label, в том числе из устаревших полей.
+func phoneNumber(c client.Contact, label string) string {
+	for _, p := range c.Phones {
+		if p.Label == label {
+			return p.Number
+		}
+	}
+
+	if len(c.Phones) > 0 {
+		return ""
+	}
+
+	switch label {
+	case client.LabelMobile:
+		return c.Telephone.Mobile
+	case client.LabelHome:
+		return c.Telephone.Home
+	}
+	return ""
+}
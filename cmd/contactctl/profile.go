@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+var ErrNoProfile = errors.New("no server configured: run \"contactctl profile set <name> -server URL\" or pass -server")
+
+// Profile - адрес сервера и учётные данные для него.
+type Profile struct {
+	Server string `yaml:"server"`
+	APIKey string `yaml:"api_key,omitempty"`
+	// Bearer-токен, если сервер стоит за прокси с аутентификацией
+	Token   string        `yaml:"token,omitempty"`
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// File - файл профилей, по умолчанию ~/.config/contactctl/config.yaml.
+// Содержит учётные данные, поэтому записывается с правами 0600.
+type File struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	path string
+}
+
+// configPath возвращает путь к файлу профилей: CONTACTCTL_CONFIG или каталог конфигурации пользователя.
+func configPath() (string, error) {
+	if path := os.Getenv("CONTACTCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config dir: %w", err)
+	}
+
+	return filepath.Join(dir, "contactctl", "config.yaml"), nil
+}
+
+// loadFile читает файл профилей; отсутствующий файл считается пустым.
+func loadFile(path string) (*File, error) {
+	f := &File{Profiles: map[string]Profile{}, path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]Profile{}
+	}
+
+	return f, nil
+}
+
+func (f *File) save() error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+
+	return os.WriteFile(f.path, data, 0o600)
+}
+
+// resolve выбирает профиль по имени или текущий.
+func (f *File) resolve(name string) (Profile, error) {
+	if name == "" {
+		name = f.Current
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	p, ok := f.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %q not found in %s", name, f.path)
+	}
+
+	return p, nil
+}
+
+func (f *File) names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// runProfile управляет файлом профилей: list, use <name>, set <name> [flags], delete <name>.
+func runProfile(args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	f, err := loadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tAUTH")
+		for _, name := range f.names() {
+			p := f.Profiles[name]
+
+			current := ""
+			if name == f.Current {
+				current = "*"
+			}
+
+			auth := "none"
+			switch {
+			case p.APIKey != "" && p.Token != "":
+				auth = "api key, token"
+			case p.APIKey != "":
+				auth = "api key"
+			case p.Token != "":
+				auth = "token"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, p.Server, auth)
+		}
+		return w.Flush()
+
+	case "use":
+		if len(args) != 2 {
+			return errors.New("usage: contactctl profile use <name>")
+		}
+		if _, ok := f.Profiles[args[1]]; !ok {
+			return fmt.Errorf("profile %q not found", args[1])
+		}
+		f.Current = args[1]
+		return f.save()
+
+	case "set":
+		if len(args) < 2 {
+			return errors.New("usage: contactctl profile set <name> [-server URL] [-api-key KEY] [-token TOKEN] [-timeout 30s]")
+		}
+		name := args[1]
+		p := f.Profiles[name]
+
+		fs := newFlagSet("profile set")
+		fs.StringVar(&p.Server, "server", p.Server, "server base URL")
+		fs.StringVar(&p.APIKey, "api-key", p.APIKey, "API key sent in X-API-Key")
+		fs.StringVar(&p.Token, "token", p.Token, "bearer token")
+		fs.DurationVar(&p.Timeout, "timeout", p.Timeout, "request timeout")
+		if _, err := parseArgs(fs, args[2:]); err != nil {
+			return err
+		}
+
+		if p.Server == "" {
+			return errors.New("-server is required")
+		}
+
+		f.Profiles[name] = p
+		if f.Current == "" {
+			f.Current = name
+		}
+		return f.save()
+
+	case "delete":
+		if len(args) != 2 {
+			return errors.New("usage: contactctl profile delete <name>")
+		}
+		delete(f.Profiles, args[1])
+		if f.Current == args[1] {
+			f.Current = ""
+		}
+		return f.save()
+	}
+
+	return fmt.Errorf("unknown profile command %q", args[0])
+}
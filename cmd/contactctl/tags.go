@@ -0,0 +1,122 @@
+package main
+
+import (
+	"contact-api/pkg/client"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// runTags управляет метками: list, add <tags> <id>..., remove <tags> <id>...
+// Метки передаются одним аргументом через запятую.
+func runTags(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		fs := newFlagSet("tags list")
+		connect := clientFlags(fs)
+		output := outputFlag(fs)
+		if _, err := parseArgs(fs, args[1:]); err != nil {
+			return err
+		}
+
+		format, err := output()
+		if err != nil {
+			return err
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		tags, err := c.Tags(ctx)
+		if err != nil {
+			return err
+		}
+
+		return printTags(stdout, format, tags)
+
+	case "add", "remove":
+		fs := newFlagSet("tags " + args[0])
+		connect := clientFlags(fs)
+
+		rest, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(rest) < 2 {
+			return fmt.Errorf("usage: contactctl tags %s <tag[,tag...]> <id>...", args[0])
+		}
+
+		tags := splitList(rest[0])
+		if len(tags) == 0 {
+			return errors.New("no tags given")
+		}
+
+		var add, remove []string
+		if args[0] == "add" {
+			add = tags
+		} else {
+			remove = tags
+		}
+
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		var total client.MembershipChange
+		// Сервер принимает не больше MaxBatchSize контактов за запрос
+		for ids := range slices.Chunk(rest[1:], client.MaxBatchSize) {
+			change, err := c.UpdateTags(ctx, ids, add, remove)
+			if err != nil {
+				return err
+			}
+			total.Added += change.Added
+			total.Removed += change.Removed
+		}
+
+		if args[0] == "add" {
+			fmt.Fprintf(stdout, "tagged %d of %d contacts\n", total.Added, len(rest)-1)
+		} else {
+			fmt.Fprintf(stdout, "untagged %d of %d contacts\n", total.Removed, len(rest)-1)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown tags command %q", args[0])
+}
+
+// splitList разбивает значение через запятую, пропуская пустые элементы.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// stringsFlag - повторяемый строковый флаг.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
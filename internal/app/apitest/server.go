@@ -0,0 +1,49 @@
+package apitest
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/http-server/middleware/cors"
+	"contact-api/internal/app/http-server/middleware/ratelimit"
+	"contact-api/internal/app/http-server/middleware/validator"
+	"contact-api/internal/app/http-server/router"
+	"contact-api/internal/app/openapi"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+)
+
+// AdminToken - токен администратора тестового сервера.
+const AdminToken = "test-admin-token"
+
+// NewServer запускает настоящий публичный API поверх хранилища в памяти.
+// Ответы проверяются по спецификации: расхождение превращается в 500 и роняет тест.
+func NewServer(t testing.TB) (*httptest.Server, *Storage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	spec, err := openapi.Load(context.Background())
+	if err != nil {
+		t.Fatalf("openapi.Load: %v", err)
+	}
+
+	validate, err := validator.New(log, spec, config.OpenAPIValidation{Requests: true, Responses: "fail"})
+	if err != nil {
+		t.Fatalf("validator.New: %v", err)
+	}
+
+	store := NewStorage()
+	srv := httptest.NewServer(router.New(log, router.Deps{
+		Storage:    store,
+		Limiter:    ratelimit.New(log, config.RateLimit{}, nil),
+		Validator:  validate,
+		CORS:       cors.New(log, config.CORS{}),
+		AdminToken: AdminToken,
+		Spec:       spec,
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, store
+}
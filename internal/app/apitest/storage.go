@@ -0,0 +1,347 @@
+// Package apitest запускает настоящий публичный API поверх хранилища в памяти
+// для тестов клиента и утилит, которые с ним работают.
+package apitest
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/router"
+	"contact-api/internal/app/storage"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Storage - хранилище в памяти с поведением, которое обработчики ждут от MongoDB.
+type Storage struct {
+	mu       sync.Mutex
+	seq      int
+	contacts map[string]models.Contact
+	fields   map[string]models.FieldDefinition
+	groups   map[string]models.Group
+}
+
+var _ router.Storage = (*Storage)(nil)
+
+// NewStorage создаёт пустое хранилище.
+func NewStorage() *Storage {
+	return &Storage{
+		contacts: make(map[string]models.Contact),
+		fields:   make(map[string]models.FieldDefinition),
+		groups:   make(map[string]models.Group),
+	}
+}
+
+// newID возвращает идентификатор в формате ObjectID. Вызывается под s.mu.
+func (s *Storage) newID() string {
+	s.seq++
+	return fmt.Sprintf("%024x", s.seq)
+}
+
+func (s *Storage) GetAll(_ context.Context, opts storage.ListOptions) ([]models.Contact, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []models.Contact
+	for _, id := range slices.Sorted(maps.Keys(s.contacts)) {
+		c := s.contacts[id]
+		if opts.After != "" && id <= opts.After {
+			continue
+		}
+		if opts.Group != "" && !slices.Contains(c.Groups, opts.Group) {
+			continue
+		}
+		if slices.ContainsFunc(opts.Tags, func(tag string) bool { return !slices.Contains(c.Tags, tag) }) {
+			continue
+		}
+		c.Normalize()
+		list = append(list, c)
+	}
+
+	if opts.Limit > 0 && int64(len(list)) > opts.Limit {
+		list = list[:opts.Limit]
+		return list, list[len(list)-1].ID, nil
+	}
+
+	return list, "", nil
+}
+
+func (s *Storage) FieldDefinitions(context.Context) ([]models.FieldDefinition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	defs := make([]models.FieldDefinition, 0, len(s.fields))
+	for _, name := range slices.Sorted(maps.Keys(s.fields)) {
+		defs = append(defs, s.fields[name])
+	}
+	return defs, nil
+}
+
+func (s *Storage) PutField(_ context.Context, def models.FieldDefinition) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, exists := s.fields[def.Name]
+	if exists && prev.Type != def.Type {
+		return false, storage.ErrFieldTypeChanged
+	}
+	s.fields[def.Name] = def
+	return !exists, nil
+}
+
+func (s *Storage) DeleteField(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.fields[name]; !ok {
+		return storage.ErrFieldNotFound
+	}
+	delete(s.fields, name)
+	return nil
+}
+
+func (s *Storage) Save(_ context.Context, contact models.Contact) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact.ID = s.newID()
+	contact.Groups = nil
+	s.contacts[contact.ID] = contact
+	return contact.ID, nil
+}
+
+func (s *Storage) SaveMany(_ context.Context, contacts []models.Contact) ([]storage.SaveResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]storage.SaveResult, len(contacts))
+	for i, contact := range contacts {
+		contact.ID = s.newID()
+		contact.Groups = nil
+		s.contacts[contact.ID] = contact
+		results[i].ID = contact.ID
+	}
+	return results, nil
+}
+
+func (s *Storage) ContactById(_ context.Context, id string) (models.Contact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.contacts[id]
+	if !ok {
+		return models.Contact{}, storage.ErrContactNotFound
+	}
+	// Как и MongoDB, отдаёт пустые списки и устаревшие поля, заполненные из списков
+	c.Normalize()
+	return c, nil
+}
+
+func (s *Storage) Update(_ context.Context, contact models.Contact) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.contacts[contact.ID]
+	if !ok {
+		return false, storage.ErrContactNotFound
+	}
+	contact.Groups = prev.Groups
+	s.contacts[contact.ID] = contact
+	return true, nil
+}
+
+func (s *Storage) Patch(_ context.Context, id string, apply func(*models.Contact) error) (models.Contact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.contacts[id]
+	if !ok {
+		return models.Contact{}, storage.ErrContactNotFound
+	}
+	c.Normalize()
+
+	if err := apply(&c); err != nil {
+		return models.Contact{}, err
+	}
+	c.ID, c.Groups = id, s.contacts[id].Groups
+	s.contacts[id] = c
+
+	c.Normalize()
+	return c, nil
+}
+
+func (s *Storage) Delete(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.contacts[id]; !ok {
+		return false, storage.ErrContactNotFound
+	}
+	delete(s.contacts, id)
+	return true, nil
+}
+
+func (s *Storage) DeleteAll(context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := int64(len(s.contacts))
+	clear(s.contacts)
+	return n, nil
+}
+
+func (s *Storage) Groups(context.Context) ([]models.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]models.Group, 0, len(s.groups))
+	for _, id := range slices.Sorted(maps.Keys(s.groups)) {
+		groups = append(groups, s.groups[id])
+	}
+	return groups, nil
+}
+
+func (s *Storage) Group(_ context.Context, id string) (models.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return models.Group{}, storage.ErrGroupNotFound
+	}
+	return g, nil
+}
+
+func (s *Storage) CreateGroup(_ context.Context, group models.Group) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range s.groups {
+		if g.Name == group.Name {
+			return "", storage.ErrGroupExists
+		}
+	}
+	group.ID = s.newID()
+	group.MemberCount = 0
+	s.groups[group.ID] = group
+	return group.ID, nil
+}
+
+func (s *Storage) UpdateGroup(_ context.Context, group models.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.groups[group.ID]
+	if !ok {
+		return storage.ErrGroupNotFound
+	}
+	group.MemberCount = prev.MemberCount
+	s.groups[group.ID] = group
+	return nil
+}
+
+func (s *Storage) DeleteGroup(_ context.Context, id string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return 0, storage.ErrGroupNotFound
+	}
+	for cid, c := range s.contacts {
+		c.Groups = slices.DeleteFunc(c.Groups, func(gid string) bool { return gid == id })
+		s.contacts[cid] = c
+	}
+	delete(s.groups, id)
+	return g.MemberCount, nil
+}
+
+func (s *Storage) UpdateMembers(_ context.Context, id string, add, remove []string) (models.Group, storage.MembershipChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return models.Group{}, storage.MembershipChange{}, storage.ErrGroupNotFound
+	}
+
+	var change storage.MembershipChange
+	for _, cid := range add {
+		if c, ok := s.contacts[cid]; ok && !slices.Contains(c.Groups, id) {
+			c.Groups = append(c.Groups, id)
+			s.contacts[cid] = c
+			change.Added++
+		}
+	}
+	for _, cid := range remove {
+		if c, ok := s.contacts[cid]; ok && slices.Contains(c.Groups, id) {
+			c.Groups = slices.DeleteFunc(c.Groups, func(gid string) bool { return gid == id })
+			s.contacts[cid] = c
+			change.Removed++
+		}
+	}
+
+	g.MemberCount += change.Added - change.Removed
+	s.groups[id] = g
+	return g, change, nil
+}
+
+func (s *Storage) Tags(context.Context) ([]storage.TagCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, c := range s.contacts {
+		for _, tag := range c.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]storage.TagCount, 0, len(counts))
+	for _, tag := range slices.Sorted(maps.Keys(counts)) {
+		tags = append(tags, storage.TagCount{Tag: tag, Count: counts[tag]})
+	}
+	return tags, nil
+}
+
+func (s *Storage) UpdateTags(_ context.Context, ids, add, remove []string) (storage.MembershipChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var change storage.MembershipChange
+	for _, id := range ids {
+		c, ok := s.contacts[id]
+		if !ok {
+			continue
+		}
+
+		before := len(c.Tags)
+		c.Tags = slices.DeleteFunc(c.Tags, func(tag string) bool { return slices.Contains(remove, tag) })
+		if len(c.Tags) < before {
+			change.Removed++
+		}
+
+		added := false
+		for _, tag := range add {
+			if !slices.Contains(c.Tags, tag) {
+				c.Tags = append(c.Tags, tag)
+				added = true
+			}
+		}
+		if added {
+			change.Added++
+		}
+
+		s.contacts[id] = c
+	}
+	return change, nil
+}
+
+// Len возвращает число сохранённых контактов.
+func (s *Storage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.contacts)
+}
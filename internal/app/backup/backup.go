@@ -0,0 +1,686 @@
+// Package backup читает и пишет архивы контактов, не зависящие от конкретного хранилища.
+//
+// Архив - gzip-поток строк JSON: заголовок, записи коллекций и завершающая строка
+// с числом записей и SHA-256 всех предыдущих строк. Без завершающей строки архив
+// считается обрезанным. Содержимое можно посмотреть через zcat.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+const (
+	Format = "contact-api-backup"
+	// Version увеличивается при несовместимом изменении записей.
+	// Архивы предыдущих версий по-прежнему читаются, см. loader.
+	Version = 5
+
+	CollectionContacts = "contacts"
+	CollectionQuotas   = "quotas"
+	// Описания пользовательских полей, с версии 3
+	CollectionFields = "fields"
+	// Группы контактов, с версии 4
+	CollectionGroups = "groups"
+	// Отметки об удалении контактов, групп и полей, с версии 5; есть только в инкрементальных копиях
+	CollectionDeletions = "deletions"
+
+	// Размер пачки записей, передаваемой хранилищу при восстановлении
+	restoreBatch = 500
+	// Наибольшая длина строки архива
+	maxLine = 1 << 20
+)
+
+var (
+	ErrInvalidArchive = errors.New("invalid backup archive")
+	ErrChecksum       = errors.New("backup checksum mismatch")
+	ErrTruncated      = errors.New("backup archive is truncated")
+	ErrBrokenChain    = errors.New("incremental backups do not form a chain")
+)
+
+// Source - хранилище, из которого делается копия.
+type Source interface {
+	ExportContacts(ctx context.Context, since time.Time, fn func(storage.ContactRecord) error) error
+	ExportQuotas(ctx context.Context, fn func(storage.QuotaRecord) error) error
+	ExportFields(ctx context.Context, fn func(models.FieldDefinition) error) error
+	ExportGroups(ctx context.Context, fn func(models.Group) error) error
+	ExportDeletions(ctx context.Context, since, until time.Time, fn func(storage.DeletionRecord) error) error
+}
+
+// Target - хранилище, в которое восстанавливается копия.
+type Target interface {
+	ImportContacts(ctx context.Context, records []storage.ContactRecord) error
+	ImportQuotas(ctx context.Context, records []storage.QuotaRecord) error
+	ImportFields(ctx context.Context, defs []models.FieldDefinition) error
+	ImportGroups(ctx context.Context, groups []models.Group) error
+	// Удаляет документы, отмеченные в архиве как удалённые
+	ApplyDeletions(ctx context.Context, records []storage.DeletionRecord) error
+	// Пересчитывает число участников групп после загрузки контактов
+	RecountGroups(ctx context.Context) error
+	DeleteAll(ctx context.Context) (int64, error)
+}
+
+// Header - первая строка архива.
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Нижняя граница времени изменения для инкрементальной копии; пусто у полной
+	Since *time.Time `json:"since,omitempty"`
+	// Момент начала выгрузки; служит since для следующей инкрементальной копии
+	Until time.Time `json:"until"`
+}
+
+// Incremental сообщает, содержит ли архив только изменения.
+func (h Header) Incremental() bool {
+	return h.Since != nil
+}
+
+// Manifest - заголовок архива и итоги его проверки.
+type Manifest struct {
+	Header
+	Counts map[string]int64 `json:"counts"`
+	SHA256 string           `json:"sha256"`
+}
+
+type record struct {
+	Collection string          `json:"collection,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+
+	// Только в завершающей строке
+	End    bool             `json:"end,omitempty"`
+	Counts map[string]int64 `json:"counts,omitempty"`
+	SHA256 string           `json:"sha256,omitempty"`
+}
+
+// Записи архива отделены от моделей хранилища, чтобы формат менялся только вместе с Version.
+type contactRecord struct {
+	ID           string          `json:"id"`
+	UserName     string          `json:"username"`
+	Name         nameRecord      `json:"name"`
+	Emails       []emailRecord   `json:"emails,omitempty"`
+	Phones       []phoneRecord   `json:"phones,omitempty"`
+	Addresses    []addressRecord `json:"addresses,omitempty"`
+	URLs         []urlRecord     `json:"urls,omitempty"`
+	Organization string          `json:"organization,omitempty"`
+	Title        string          `json:"title,omitempty"`
+	Birthday     string          `json:"birthday,omitempty"`
+	Notes        string          `json:"notes,omitempty"`
+	// С версии 3
+	Fields map[string]any `json:"fields,omitempty"`
+	// С версии 4
+	Tags      []string  `json:"tags,omitempty"`
+	Groups    []string  `json:"groups,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type nameRecord struct {
+	Display string `json:"display,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Family  string `json:"family,omitempty"`
+}
+
+type emailRecord struct {
+	Label   string `json:"label"`
+	Address string `json:"address"`
+}
+
+type phoneRecord struct {
+	Label  string `json:"label"`
+	Number string `json:"number"`
+}
+
+type addressRecord struct {
+	Label      string `json:"label"`
+	Street     string `json:"street,omitempty"`
+	City       string `json:"city,omitempty"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+type urlRecord struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// contactRecordV1 - контакт в архивах версии 1, до появления списков
+type contactRecordV1 struct {
+	ID        string    `json:"id"`
+	UserName  string    `json:"username"`
+	Email     string    `json:"email"`
+	Mobile    string    `json:"mobile"`
+	Home      string    `json:"home"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toContactRecord(r storage.ContactRecord) contactRecord {
+	c := r.Contact
+
+	return contactRecord{
+		ID:           c.ID,
+		UserName:     c.UserName,
+		Name:         nameRecord(c.Name),
+		Emails:       convertList(c.Emails, func(e models.Email) emailRecord { return emailRecord(e) }),
+		Phones:       convertList(c.Phones, func(p models.Phone) phoneRecord { return phoneRecord(p) }),
+		Addresses:    convertList(c.Addresses, func(a models.Address) addressRecord { return addressRecord(a) }),
+		URLs:         convertList(c.URLs, func(u models.URL) urlRecord { return urlRecord(u) }),
+		Organization: c.Organization,
+		Title:        c.Title,
+		Birthday:     c.Birthday,
+		Notes:        c.Notes,
+		Fields:       c.Fields,
+		Tags:         c.Tags,
+		Groups:       c.Groups,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
+
+func (c contactRecord) toStorage() storage.ContactRecord {
+	contact := models.Contact{
+		ID:           c.ID,
+		UserName:     c.UserName,
+		Name:         models.Name(c.Name),
+		Emails:       convertList(c.Emails, func(e emailRecord) models.Email { return models.Email(e) }),
+		Phones:       convertList(c.Phones, func(p phoneRecord) models.Phone { return models.Phone(p) }),
+		Addresses:    convertList(c.Addresses, func(a addressRecord) models.Address { return models.Address(a) }),
+		URLs:         convertList(c.URLs, func(u urlRecord) models.URL { return models.URL(u) }),
+		Organization: c.Organization,
+		Title:        c.Title,
+		Birthday:     c.Birthday,
+		Notes:        c.Notes,
+		Fields:       c.Fields,
+		Tags:         c.Tags,
+		Groups:       c.Groups,
+	}
+	contact.Normalize()
+
+	return storage.ContactRecord{Contact: contact, UpdatedAt: c.UpdatedAt}
+}
+
+func (c contactRecordV1) toStorage() storage.ContactRecord {
+	contact := models.Contact{
+		ID:        c.ID,
+		UserName:  c.UserName,
+		Email:     c.Email,
+		Telephone: models.Telephone{Mobile: c.Mobile, Home: c.Home},
+	}
+	contact.Normalize()
+
+	return storage.ContactRecord{Contact: contact, UpdatedAt: c.UpdatedAt}
+}
+
+func convertList[From, To any](from []From, convert func(From) To) []To {
+	if len(from) == 0 {
+		return nil
+	}
+
+	to := make([]To, len(from))
+	for i, v := range from {
+		to[i] = convert(v)
+	}
+	return to
+}
+
+type fieldRecord struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Values      []string `json:"values,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// groupRecord не содержит числа участников: оно пересчитывается по контактам после восстановления
+type groupRecord struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Color       string `json:"color,omitempty"`
+}
+
+// deletionRecord - удаление документа из коллекции; пустой ID у контактов означает удаление всех контактов
+type deletionRecord struct {
+	Collection string    `json:"collection"`
+	ID         string    `json:"id,omitempty"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
+type quotaRecord struct {
+	Key       string    `json:"key"`
+	Day       string    `json:"day"`
+	Count     int64     `json:"count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Options - параметры создания копии.
+type Options struct {
+	// Если не нулевое, в копию попадают только контакты, изменённые начиная с этого момента,
+	// и отметки об удалениях, сделанных после него.
+	Since time.Time
+	// Время, от которого отсчитывается Until; по умолчанию time.Now
+	Now func() time.Time
+}
+
+// Write выгружает контакты и квоты из src в w и возвращает манифест записанного архива.
+func Write(ctx context.Context, w io.Writer, src Source, opts Options) (Manifest, error) {
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	// Граница фиксируется до выгрузки: изменения во время копирования попадут и в следующую копию
+	until := now().UTC().Truncate(time.Millisecond)
+
+	m := Manifest{
+		Header: Header{
+			Format:    Format,
+			Version:   Version,
+			CreatedAt: until,
+			Until:     until,
+		},
+		Counts: map[string]int64{CollectionDeletions: 0, CollectionFields: 0, CollectionGroups: 0, CollectionContacts: 0, CollectionQuotas: 0},
+	}
+	if !opts.Since.IsZero() {
+		since := opts.Since.UTC()
+		m.Since = &since
+	}
+
+	zw := gzip.NewWriter(w)
+	h := sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(zw, h))
+	enc := json.NewEncoder(bw)
+
+	if err := enc.Encode(m.Header); err != nil {
+		return m, err
+	}
+
+	writeRecord := func(collection string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		m.Counts[collection]++
+
+		return enc.Encode(record{Collection: collection, Data: data})
+	}
+
+	// Удаления идут первыми: при восстановлении они применяются раньше документов того же архива.
+	// Выгружаются только удаления до until: удалённый до него документ в выгрузку уже не попадёт,
+	// так что отметка не удалит созданный заново документ, например поле с тем же именем
+	if m.Since != nil {
+		err := src.ExportDeletions(ctx, *m.Since, until, func(r storage.DeletionRecord) error {
+			return writeRecord(CollectionDeletions, deletionRecord(r))
+		})
+		if err != nil {
+			return m, fmt.Errorf("failed to back up deletions: %w", err)
+		}
+	}
+
+	// Описания полей и группы идут раньше контактов, чтобы при восстановлении появиться раньше них.
+	// Их немного, поэтому и инкрементальная копия содержит все
+	err := src.ExportFields(ctx, func(def models.FieldDefinition) error {
+		return writeRecord(CollectionFields, fieldRecord(def))
+	})
+	if err != nil {
+		return m, fmt.Errorf("failed to back up custom fields: %w", err)
+	}
+
+	err = src.ExportGroups(ctx, func(g models.Group) error {
+		return writeRecord(CollectionGroups, groupRecord{ID: g.ID, Name: g.Name, Description: g.Description, Color: g.Color})
+	})
+	if err != nil {
+		return m, fmt.Errorf("failed to back up groups: %w", err)
+	}
+
+	err = src.ExportContacts(ctx, opts.Since, func(r storage.ContactRecord) error {
+		return writeRecord(CollectionContacts, toContactRecord(r))
+	})
+	if err != nil {
+		return m, fmt.Errorf("failed to back up contacts: %w", err)
+	}
+
+	err = src.ExportQuotas(ctx, func(r storage.QuotaRecord) error {
+		return writeRecord(CollectionQuotas, quotaRecord(r))
+	})
+	if err != nil {
+		return m, fmt.Errorf("failed to back up quotas: %w", err)
+	}
+
+	// Контрольная сумма покрывает всё до завершающей строки
+	if err := bw.Flush(); err != nil {
+		return m, err
+	}
+	m.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if err := json.NewEncoder(zw).Encode(record{End: true, Counts: m.Counts, SHA256: m.SHA256}); err != nil {
+		return m, err
+	}
+
+	return m, zw.Close()
+}
+
+// Verify читает архив целиком и проверяет формат, версию, число записей и контрольную сумму.
+func Verify(r io.Reader) (Manifest, error) {
+	return read(r, nil)
+}
+
+// RestoreOptions - параметры восстановления.
+type RestoreOptions struct {
+	// Удалить существующие контакты перед загрузкой; допустимо только для полной копии
+	Replace bool
+}
+
+// Restore проверяет архивы и загружает их в dst по порядку: полную копию и следующие за ней инкрементальные.
+// open вызывается дважды для каждого архива: для проверки и для загрузки, поэтому
+// битый архив обнаруживается до того, как хранилище будет изменено.
+func Restore(ctx context.Context, dst Target, open []func() (io.ReadCloser, error), opts RestoreOptions) ([]Manifest, error) {
+	manifests := make([]Manifest, 0, len(open))
+
+	for i, o := range open {
+		m, err := withReader(o, Verify)
+		if err != nil {
+			return nil, fmt.Errorf("archive %d: %w", i+1, err)
+		}
+		manifests = append(manifests, m)
+	}
+
+	if err := CheckChain(manifests); err != nil {
+		return nil, err
+	}
+
+	if opts.Replace {
+		if manifests[0].Incremental() {
+			return nil, fmt.Errorf("%w: replace requires a full backup first", ErrBrokenChain)
+		}
+
+		if _, err := dst.DeleteAll(ctx); err != nil {
+			return nil, fmt.Errorf("failed to clear contacts: %w", err)
+		}
+	}
+
+	for i, o := range open {
+		_, err := withReader(o, func(r io.Reader) (Manifest, error) {
+			l := &loader{ctx: ctx, dst: dst, version: manifests[i].Version}
+			m, err := read(r, l.add)
+			if err != nil {
+				return m, err
+			}
+			return m, l.flush()
+		})
+		if err != nil {
+			return manifests, fmt.Errorf("archive %d: %w", i+1, err)
+		}
+	}
+
+	if err := dst.RecountGroups(ctx); err != nil {
+		return manifests, fmt.Errorf("failed to recount group members: %w", err)
+	}
+
+	return manifests, nil
+}
+
+// CheckChain проверяет, что каждая инкрементальная копия начинается не позже конца предыдущей.
+func CheckChain(manifests []Manifest) error {
+	for i := 1; i < len(manifests); i++ {
+		prev, cur := manifests[i-1], manifests[i]
+
+		if !cur.Incremental() {
+			return fmt.Errorf("%w: archive %d is a full backup, only the first one may be", ErrBrokenChain, i+1)
+		}
+		if cur.Since.After(prev.Until) {
+			return fmt.Errorf("%w: archive %d starts at %s, after archive %d ends at %s",
+				ErrBrokenChain, i+1, cur.Since.Format(time.RFC3339), i, prev.Until.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// ReadHeader читает только заголовок архива, не проверяя остальное содержимое.
+func ReadHeader(r io.Reader) (Header, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return Header{}, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	defer zr.Close()
+
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(nil, maxLine)
+
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return Header{}, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+		}
+		return Header{}, ErrTruncated
+	}
+
+	return parseHeader(sc.Bytes())
+}
+
+func parseHeader(line []byte) (Header, error) {
+	var h Header
+	if err := json.Unmarshal(line, &h); err != nil {
+		return h, fmt.Errorf("%w: bad header: %w", ErrInvalidArchive, err)
+	}
+	if h.Format != Format {
+		return h, fmt.Errorf("%w: unknown format %q", ErrInvalidArchive, h.Format)
+	}
+	if h.Version < 1 || h.Version > Version {
+		return h, fmt.Errorf("%w: unsupported version %d, expected at most %d", ErrInvalidArchive, h.Version, Version)
+	}
+
+	return h, nil
+}
+
+// read разбирает архив и передаёт записи в fn, если она задана.
+// Ошибка контрольной суммы возвращается после того, как все записи уже переданы,
+// поэтому перед загрузкой архив нужно проверить через Verify.
+func read(r io.Reader, fn func(collection string, data json.RawMessage) error) (Manifest, error) {
+	var m Manifest
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return m, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	defer zr.Close()
+
+	h := sha256.New()
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(nil, maxLine)
+
+	if !sc.Scan() {
+		return m, scanErr(sc)
+	}
+
+	m.Header, err = parseHeader(sc.Bytes())
+	if err != nil {
+		return m, err
+	}
+	hashLine(h, sc.Bytes())
+
+	m.Counts = map[string]int64{}
+
+	for sc.Scan() {
+		var rec record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return m, fmt.Errorf("%w: bad record: %w", ErrInvalidArchive, err)
+		}
+
+		if rec.End {
+			m.SHA256 = hex.EncodeToString(h.Sum(nil))
+			if m.SHA256 != rec.SHA256 {
+				return m, ErrChecksum
+			}
+
+			for c, n := range rec.Counts {
+				if m.Counts[c] != n {
+					return m, fmt.Errorf("%w: %s has %d records, expected %d", ErrInvalidArchive, c, m.Counts[c], n)
+				}
+			}
+
+			if sc.Scan() {
+				return m, fmt.Errorf("%w: data after end of archive", ErrInvalidArchive)
+			}
+
+			return m, nil
+		}
+
+		hashLine(h, sc.Bytes())
+
+		switch rec.Collection {
+		case CollectionContacts, CollectionQuotas, CollectionFields, CollectionGroups, CollectionDeletions:
+		default:
+			return m, fmt.Errorf("%w: unknown collection %q", ErrInvalidArchive, rec.Collection)
+		}
+		m.Counts[rec.Collection]++
+
+		if fn != nil {
+			if err := fn(rec.Collection, rec.Data); err != nil {
+				return m, err
+			}
+		}
+	}
+
+	return m, scanErr(sc)
+}
+
+func hashLine(h hash.Hash, line []byte) {
+	h.Write(line)
+	h.Write([]byte{'\n'})
+}
+
+func scanErr(sc *bufio.Scanner) error {
+	if err := sc.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	return ErrTruncated
+}
+
+func withReader(open func() (io.ReadCloser, error), fn func(io.Reader) (Manifest, error)) (Manifest, error) {
+	rc, err := open()
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer rc.Close()
+
+	return fn(rc)
+}
+
+// loader копит записи и передаёт их хранилищу пачками.
+type loader struct {
+	ctx context.Context
+	dst Target
+	// Версия загружаемого архива
+	version   int
+	deletions []storage.DeletionRecord
+	contacts  []storage.ContactRecord
+	quotas    []storage.QuotaRecord
+	fields    []models.FieldDefinition
+	groups    []models.Group
+}
+
+func (l *loader) add(collection string, data json.RawMessage) error {
+	switch collection {
+	case CollectionContacts:
+		var (
+			r   storage.ContactRecord
+			err error
+		)
+
+		if l.version == 1 {
+			var c contactRecordV1
+			err = json.Unmarshal(data, &c)
+			r = c.toStorage()
+		} else {
+			var c contactRecord
+			err = json.Unmarshal(data, &c)
+			r = c.toStorage()
+		}
+		if err != nil {
+			return fmt.Errorf("%w: bad contact: %w", ErrInvalidArchive, err)
+		}
+
+		l.contacts = append(l.contacts, r)
+
+	case CollectionFields:
+		var f fieldRecord
+		if err := json.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("%w: bad custom field: %w", ErrInvalidArchive, err)
+		}
+
+		l.fields = append(l.fields, models.FieldDefinition(f))
+
+	case CollectionGroups:
+		var g groupRecord
+		if err := json.Unmarshal(data, &g); err != nil {
+			return fmt.Errorf("%w: bad group: %w", ErrInvalidArchive, err)
+		}
+
+		l.groups = append(l.groups, models.Group{ID: g.ID, Name: g.Name, Description: g.Description, Color: g.Color})
+
+	case CollectionDeletions:
+		var d deletionRecord
+		if err := json.Unmarshal(data, &d); err != nil {
+			return fmt.Errorf("%w: bad deletion: %w", ErrInvalidArchive, err)
+		}
+
+		l.deletions = append(l.deletions, storage.DeletionRecord(d))
+
+	case CollectionQuotas:
+		var q quotaRecord
+		if err := json.Unmarshal(data, &q); err != nil {
+			return fmt.Errorf("%w: bad quota: %w", ErrInvalidArchive, err)
+		}
+
+		l.quotas = append(l.quotas, storage.QuotaRecord(q))
+	}
+
+	if len(l.deletions)+len(l.contacts)+len(l.quotas)+len(l.fields)+len(l.groups) >= restoreBatch {
+		return l.flush()
+	}
+
+	return nil
+}
+
+func (l *loader) flush() error {
+	// Удаления в архиве идут раньше документов, поэтому и из пачки применяются первыми
+	if err := l.dst.ApplyDeletions(l.ctx, l.deletions); err != nil {
+		return err
+	}
+	l.deletions = l.deletions[:0]
+
+	// Описания полей и группы записываются раньше контактов из той же пачки
+	if err := l.dst.ImportFields(l.ctx, l.fields); err != nil {
+		return err
+	}
+	l.fields = l.fields[:0]
+
+	if err := l.dst.ImportGroups(l.ctx, l.groups); err != nil {
+		return err
+	}
+	l.groups = l.groups[:0]
+
+	if err := l.dst.ImportContacts(l.ctx, l.contacts); err != nil {
+		return err
+	}
+	l.contacts = l.contacts[:0]
+
+	if err := l.dst.ImportQuotas(l.ctx, l.quotas); err != nil {
+		return err
+	}
+	l.quotas = l.quotas[:0]
+
+	return nil
+}
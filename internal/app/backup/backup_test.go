@@ -0,0 +1,459 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memStore - хранилище в памяти, которое ведёт отметки об удалениях так же, как storage/mongo.
+type memStore struct {
+	now       time.Time
+	contacts  map[string]storage.ContactRecord
+	quotas    map[string]storage.QuotaRecord
+	fields    map[string]models.FieldDefinition
+	groups    map[string]models.Group
+	deletions map[string]storage.DeletionRecord
+}
+
+func newMemStore(now time.Time) *memStore {
+	return &memStore{
+		now:       now,
+		contacts:  map[string]storage.ContactRecord{},
+		quotas:    map[string]storage.QuotaRecord{},
+		fields:    map[string]models.FieldDefinition{},
+		groups:    map[string]models.Group{},
+		deletions: map[string]storage.DeletionRecord{},
+	}
+}
+
+// tick сдвигает часы хранилища, чтобы изменения попадали в разные копии.
+func (s *memStore) tick() time.Time {
+	s.now = s.now.Add(time.Second)
+	return s.now
+}
+
+func (s *memStore) put(c models.Contact) {
+	c.Normalize()
+	s.contacts[c.ID] = storage.ContactRecord{Contact: c, UpdatedAt: s.tick()}
+}
+
+func (s *memStore) deleteContact(id string) {
+	delete(s.contacts, id)
+	s.deletions[storage.DeletedContacts+"|"+id] = storage.DeletionRecord{Collection: storage.DeletedContacts, ID: id, DeletedAt: s.tick()}
+}
+
+func (s *memStore) deleteField(name string) {
+	delete(s.fields, name)
+	s.deletions[storage.DeletedFields+"|"+name] = storage.DeletionRecord{Collection: storage.DeletedFields, ID: name, DeletedAt: s.tick()}
+}
+
+func (s *memStore) deleteGroup(id string) {
+	delete(s.groups, id)
+	s.deletions[storage.DeletedGroups+"|"+id] = storage.DeletionRecord{Collection: storage.DeletedGroups, ID: id, DeletedAt: s.tick()}
+}
+
+func (s *memStore) ExportContacts(_ context.Context, since time.Time, fn func(storage.ContactRecord) error) error {
+	for _, id := range slices.Sorted(maps.Keys(s.contacts)) {
+		if r := s.contacts[id]; !r.UpdatedAt.Before(since) {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memStore) ExportQuotas(_ context.Context, fn func(storage.QuotaRecord) error) error {
+	for _, key := range slices.Sorted(maps.Keys(s.quotas)) {
+		if err := fn(s.quotas[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memStore) ExportFields(_ context.Context, fn func(models.FieldDefinition) error) error {
+	for _, name := range slices.Sorted(maps.Keys(s.fields)) {
+		if err := fn(s.fields[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memStore) ExportGroups(_ context.Context, fn func(models.Group) error) error {
+	for _, id := range slices.Sorted(maps.Keys(s.groups)) {
+		if err := fn(s.groups[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memStore) ExportDeletions(_ context.Context, since, until time.Time, fn func(storage.DeletionRecord) error) error {
+	for _, key := range slices.Sorted(maps.Keys(s.deletions)) {
+		if d := s.deletions[key]; !d.DeletedAt.Before(since) && d.DeletedAt.Before(until) {
+			if err := fn(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memStore) ImportContacts(_ context.Context, records []storage.ContactRecord) error {
+	for _, r := range records {
+		s.contacts[r.Contact.ID] = r
+	}
+	return nil
+}
+
+func (s *memStore) ImportQuotas(_ context.Context, records []storage.QuotaRecord) error {
+	for _, r := range records {
+		s.quotas[r.Key+"|"+r.Day] = r
+	}
+	return nil
+}
+
+func (s *memStore) ImportFields(_ context.Context, defs []models.FieldDefinition) error {
+	for _, def := range defs {
+		s.fields[def.Name] = def
+	}
+	return nil
+}
+
+func (s *memStore) ImportGroups(_ context.Context, groups []models.Group) error {
+	for _, g := range groups {
+		g.MemberCount = s.groups[g.ID].MemberCount
+		s.groups[g.ID] = g
+	}
+	return nil
+}
+
+func (s *memStore) ApplyDeletions(_ context.Context, records []storage.DeletionRecord) error {
+	for _, r := range records {
+		switch {
+		case r.Collection == storage.DeletedContacts && r.ID == "":
+			clear(s.contacts)
+		case r.Collection == storage.DeletedContacts:
+			delete(s.contacts, r.ID)
+		case r.Collection == storage.DeletedGroups:
+			delete(s.groups, r.ID)
+		case r.Collection == storage.DeletedFields:
+			delete(s.fields, r.ID)
+		}
+	}
+	return nil
+}
+
+func (s *memStore) RecountGroups(context.Context) error {
+	for id, g := range s.groups {
+		g.MemberCount = 0
+		for _, r := range s.contacts {
+			if slices.Contains(r.Contact.Groups, id) {
+				g.MemberCount++
+			}
+		}
+		s.groups[id] = g
+	}
+	return nil
+}
+
+func (s *memStore) DeleteAll(context.Context) (int64, error) {
+	n := int64(len(s.contacts))
+	clear(s.contacts)
+	s.deletions[storage.DeletedContacts+"|"] = storage.DeletionRecord{Collection: storage.DeletedContacts, DeletedAt: s.tick()}
+	return n, nil
+}
+
+func newTestStore() *memStore {
+	s := newMemStore(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	s.fields["level"] = models.FieldDefinition{Name: "level", Type: "enum", Values: []string{"gold", "silver"}}
+	s.fields["score"] = models.FieldDefinition{Name: "score", Type: "number", Required: true}
+	s.groups["g1"] = models.Group{ID: "g1", Name: "Friends", Color: "#00ff00", MemberCount: 1}
+	s.quotas["k|2024-05-01"] = storage.QuotaRecord{Key: "k", Day: "2024-05-01", Count: 3, CreatedAt: s.now}
+
+	s.put(models.Contact{
+		ID:       "c1",
+		UserName: "ann",
+		Name:     models.Name{Display: "Ann Lee", Given: "Ann", Family: "Lee"},
+		Emails:   []models.Email{{Label: models.LabelWork, Address: "ann@example.com"}},
+		Phones:   []models.Phone{{Label: models.LabelMobile, Number: "+15550001"}},
+		Fields:   map[string]any{"level": "gold", "score": 4.5},
+		Tags:     []string{"vip"},
+		Groups:   []string{"g1"},
+	})
+	s.put(models.Contact{ID: "c2", UserName: "bob", Notes: "line 1\nline 2"})
+	s.put(models.Contact{ID: "c3", UserName: "cid"})
+
+	return s
+}
+
+// archive - архив в памяти; open подходит для Restore, который читает каждый архив дважды.
+type archive []byte
+
+func (a archive) open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(a)), nil
+}
+
+func writeArchive(t *testing.T, src Source, since time.Time, now time.Time) (archive, Manifest) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	m, err := Write(context.Background(), &buf, src, Options{Since: since, Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return buf.Bytes(), m
+}
+
+func restore(t *testing.T, dst Target, opts RestoreOptions, archives ...archive) ([]Manifest, error) {
+	t.Helper()
+
+	open := make([]func() (io.ReadCloser, error), len(archives))
+	for i, a := range archives {
+		open[i] = a.open
+	}
+	return Restore(context.Background(), dst, open, opts)
+}
+
+func assertSameStore(t *testing.T, got, want *memStore) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got.contacts, want.contacts) {
+		t.Errorf("contacts = %+v\nwant %+v", got.contacts, want.contacts)
+	}
+	if !reflect.DeepEqual(got.fields, want.fields) {
+		t.Errorf("fields = %+v\nwant %+v", got.fields, want.fields)
+	}
+	if !reflect.DeepEqual(got.groups, want.groups) {
+		t.Errorf("groups = %+v\nwant %+v", got.groups, want.groups)
+	}
+	if !reflect.DeepEqual(got.quotas, want.quotas) {
+		t.Errorf("quotas = %+v\nwant %+v", got.quotas, want.quotas)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := newTestStore()
+	data, written := writeArchive(t, src, time.Time{}, src.tick())
+
+	want := map[string]int64{CollectionContacts: 3, CollectionQuotas: 1, CollectionFields: 2, CollectionGroups: 1, CollectionDeletions: 0}
+	if !reflect.DeepEqual(written.Counts, want) {
+		t.Errorf("written counts = %v, want %v", written.Counts, want)
+	}
+	if written.Incremental() || written.Version != Version || len(written.SHA256) != 64 {
+		t.Errorf("manifest = %+v", written)
+	}
+
+	verified, err := Verify(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if verified.SHA256 != written.SHA256 || !verified.Until.Equal(written.Until) {
+		t.Errorf("Verify = %+v, written %+v", verified, written)
+	}
+	for c, n := range verified.Counts {
+		if written.Counts[c] != n {
+			t.Errorf("verified %d %s, written %d", n, c, written.Counts[c])
+		}
+	}
+
+	dst := newMemStore(time.Time{})
+	if _, err := restore(t, dst, RestoreOptions{}, data); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	assertSameStore(t, dst, src)
+}
+
+// rewrite распаковывает архив, меняет его строки через edit и упаковывает обратно.
+func rewrite(t *testing.T, a archive, edit func(lines []string) []string) archive {
+	t.Helper()
+
+	zr, err := gzip.NewReader(bytes.NewReader(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(nil, maxLine)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, line := range edit(lines) {
+		zw.Write([]byte(line + "\n"))
+	}
+	zw.Close()
+
+	return buf.Bytes()
+}
+
+func TestVerifyDetectsDamage(t *testing.T) {
+	src := newTestStore()
+	data, _ := writeArchive(t, src, time.Time{}, src.tick())
+
+	tests := []struct {
+		name string
+		edit func(lines []string) []string
+		want error
+	}{
+		{
+			name: "changed record",
+			edit: func(lines []string) []string {
+				lines[len(lines)-2] = strings.Replace(lines[len(lines)-2], `"count":3`, `"count":4`, 1)
+				return lines
+			},
+			want: ErrChecksum,
+		},
+		{
+			name: "dropped record",
+			edit: func(lines []string) []string {
+				return slices.Delete(lines, 1, 2)
+			},
+			want: ErrChecksum,
+		},
+		{
+			name: "missing end",
+			edit: func(lines []string) []string {
+				return lines[:len(lines)-1]
+			},
+			want: ErrTruncated,
+		},
+		{
+			name: "data after end",
+			edit: func(lines []string) []string {
+				return append(lines, lines[1])
+			},
+			want: ErrInvalidArchive,
+		},
+		{
+			name: "unknown format",
+			edit: func(lines []string) []string {
+				lines[0] = strings.Replace(lines[0], Format, "other", 1)
+				return lines
+			},
+			want: ErrInvalidArchive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			damaged := rewrite(t, data, tt.edit)
+
+			if _, err := Verify(bytes.NewReader(damaged)); !errors.Is(err, tt.want) {
+				t.Errorf("Verify: err = %v, want %v", err, tt.want)
+			}
+
+			// Повреждённый архив обнаруживается до того, как хранилище будет изменено
+			dst := newMemStore(time.Time{})
+			if _, err := restore(t, dst, RestoreOptions{}, damaged); !errors.Is(err, tt.want) {
+				t.Errorf("Restore: err = %v, want %v", err, tt.want)
+			}
+			if len(dst.contacts)+len(dst.quotas)+len(dst.fields)+len(dst.groups) != 0 {
+				t.Error("damaged archive was partially restored")
+			}
+		})
+	}
+
+	if _, err := Verify(bytes.NewReader(data[:len(data)/2])); !errors.Is(err, ErrTruncated) && !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("Verify of a cut archive: err = %v", err)
+	}
+}
+
+func TestIncrementalRestoresDeletions(t *testing.T) {
+	src := newTestStore()
+	full, fullManifest := writeArchive(t, src, time.Time{}, src.tick())
+
+	// Изменения после полной копии: удаление контакта, группы и поля, которое затем создаётся заново
+	src.put(models.Contact{ID: "c4", UserName: "dan"})
+	src.deleteContact("c2")
+	src.deleteGroup("g1")
+	c1 := src.contacts["c1"].Contact
+	c1.Groups = nil
+	src.put(c1)
+	src.deleteField("level")
+	src.fields["level"] = models.FieldDefinition{Name: "level", Type: "string"}
+
+	inc1, m1 := writeArchive(t, src, fullManifest.Until, src.tick())
+	if !m1.Incremental() || m1.Counts[CollectionDeletions] != 3 || m1.Counts[CollectionContacts] != 2 {
+		t.Errorf("first incremental counts = %v", m1.Counts)
+	}
+
+	// Удаление всех контактов перекрывает контакты из предыдущих копий, но не созданные после него
+	src.DeleteAll(context.Background())
+	src.put(models.Contact{ID: "c5", UserName: "eve"})
+
+	inc2, m2 := writeArchive(t, src, m1.Until, src.tick())
+	if m2.Counts[CollectionDeletions] != 1 || m2.Counts[CollectionContacts] != 1 {
+		t.Errorf("second incremental counts = %v", m2.Counts)
+	}
+
+	t.Run("first incremental", func(t *testing.T) {
+		dst := newMemStore(time.Time{})
+		if _, err := restore(t, dst, RestoreOptions{Replace: true}, full, inc1); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+
+		if got := slices.Sorted(maps.Keys(dst.contacts)); !slices.Equal(got, []string{"c1", "c3", "c4"}) {
+			t.Errorf("contacts = %v, want deleted c2 to stay deleted", got)
+		}
+		if _, ok := dst.groups["g1"]; ok {
+			t.Error("deleted group was restored")
+		}
+		if dst.fields["level"].Type != "string" {
+			t.Errorf("recreated field = %+v", dst.fields["level"])
+		}
+	})
+
+	t.Run("whole chain", func(t *testing.T) {
+		dst := newMemStore(time.Time{})
+		if _, err := restore(t, dst, RestoreOptions{Replace: true}, full, inc1, inc2); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		assertSameStore(t, dst, src)
+	})
+}
+
+func TestRestoreChecksChain(t *testing.T) {
+	src := newTestStore()
+	full, fullManifest := writeArchive(t, src, time.Time{}, src.tick())
+	gap, _ := writeArchive(t, src, fullManifest.Until.Add(time.Hour), src.now.Add(2*time.Hour))
+
+	tests := []struct {
+		name     string
+		opts     RestoreOptions
+		archives []archive
+	}{
+		{name: "gap between archives", archives: []archive{full, gap}},
+		{name: "two full archives", archives: []archive{full, full}},
+		{name: "replace from incremental", opts: RestoreOptions{Replace: true}, archives: []archive{gap}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := restore(t, newMemStore(time.Time{}), tt.opts, tt.archives...); !errors.Is(err, ErrBrokenChain) {
+				t.Errorf("err = %v, want ErrBrokenChain", err)
+			}
+		})
+	}
+}
@@ -1,56 +1,344 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
-	"log"
+	"gopkg.in/yaml.v3"
+	"net"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
 )
 
+const (
+	EnvLocal   = "local"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+)
+
+// Config собирается по слоям, каждый следующий перекрывает предыдущий:
+// значения по умолчанию, файл профиля с цепочкой extends, переменные окружения, флаги командной строки.
+// Поля Config применяются только при старте, поля Reloadable можно перечитать без перезапуска.
 type Config struct {
-	Env          string `yaml:"env" default:"prod"`
-	Port         string `yaml:"port" default:"8080"`
-	DBConnection string `yaml:"db_conn"`
+	Env          string       `yaml:"env" env:"APP_ENV" env-default:"prod"`
+	Port         string       `yaml:"port" env:"HTTP_PORT" env-default:":8080"`
+	Mongo        Mongo        `yaml:"mongo" env-prefix:"MONGO_"`
+	Tracing      Tracing      `yaml:"tracing" env-prefix:"TRACING_"`
+	Health       Health       `yaml:"health" env-prefix:"HEALTH_"`
+	HTTPServer   HTTPServer   `yaml:"http_server" env-prefix:"HTTP_"`
+	LogRedaction LogRedaction `yaml:"log_redaction" env-prefix:"LOG_REDACTION_"`
+	LogPretty    LogPretty    `yaml:"log_pretty" env-prefix:"LOG_PRETTY_"`
+	Admin        Admin        `yaml:"admin" env-prefix:"ADMIN_"`
+	// Проверка запросов и ответов /v1 по спецификации OpenAPI
+	OpenAPIValidation OpenAPIValidation `yaml:"openapi_validation" env-prefix:"OPENAPI_VALIDATION_"`
+	// Период опроса файлов конфигурации на изменения, отрицательное значение - перечитывать только по SIGHUP
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"CONFIG_RELOAD_INTERVAL" env-default:"10s"`
+
+	Reloadable `yaml:",inline"`
+
+	// Файлы, из которых собрана конфигурация, в порядке применения
+	sources []string
 }
 
-func MustLoad(pathToConfig string) *Config {
-	err := godotenv.Load("./.env")
-	if err != nil {
-		log.Printf("Error loading .env file")
+// Reloadable - настройки, которые перечитываются по SIGHUP или при изменении файла.
+type Reloadable struct {
+	// debug, info, warn, error
+	LogLevel  string          `yaml:"log_level" env:"LOG_LEVEL" env-default:"info"`
+	CORS      CORS            `yaml:"cors" env-prefix:"CORS_"`
+	RateLimit RateLimit       `yaml:"rate_limit" env-prefix:"RATE_LIMIT_"`
+	Features  map[string]bool `yaml:"features" env:"FEATURES"`
+	Timeouts  StorageTimeouts `yaml:"storage_timeouts" env-prefix:"STORAGE_TIMEOUT_"`
+}
+
+type CORS struct {
+	// Точные origin'ы или шаблоны с одним *, например https://*.example.com.
+	// Пустой список запрещает кросс-доменные запросы
+	AllowedOrigins []string `yaml:"allowed_origins" env:"ALLOWED_ORIGINS"`
+	// Если не задан, используются методы всех зарегистрированных маршрутов
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"ALLOWED_HEADERS" env-default:"Accept,Authorization,Content-Type,X-CSRF-Token,X-API-Key,X-Request-ID"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"EXPOSED_HEADERS" env-default:"Link,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After,X-Request-ID"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"MAX_AGE" env-default:"5m"`
+}
+
+// Addr возвращает адрес admin-listener'а с учётом LoopbackOnly.
+func (a Admin) Addr() string {
+	host, port, err := net.SplitHostPort(a.Port)
+	if err != nil || host != "" || !a.LoopbackOnly {
+		return a.Port
 	}
 
-	if pathToConfig == "" {
-		log.Fatalf("Config file not specified")
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// Sources возвращает файлы, из которых собрана конфигурация.
+func (c *Config) Sources() []string {
+	return c.sources
+}
+
+// LogRedaction - правила скрытия учётных и персональных данных в логах.
+type LogRedaction struct {
+	Enabled        bool `yaml:"enabled" env:"ENABLED"`
+	URICredentials bool `yaml:"uri_credentials" env:"URI_CREDENTIALS"`
+	// mask, hash, drop или none
+	Email     string   `yaml:"email" env:"EMAIL" env-default:"mask"`
+	Phone     string   `yaml:"phone" env:"PHONE" env-default:"mask"`
+	EmailKeys []string `yaml:"email_keys" env:"EMAIL_KEYS" env-default:"email"`
+	PhoneKeys []string `yaml:"phone_keys" env:"PHONE_KEYS" env-default:"phone,telephone,mobile,home"`
+	HashSalt  string   `yaml:"hash_salt" env:"HASH_SALT" secret:"true"`
+}
+
+// LogPretty - вид логов в окружении local. Цвета также отключаются при NO_COLOR и выводе не в терминал.
+type LogPretty struct {
+	// Печатать каждую запись одной строкой
+	Compact bool `yaml:"compact" env:"COMPACT"`
+	// Добавлять файл и строку, откуда вызван логгер
+	Source  bool `yaml:"source" env:"SOURCE"`
+	NoColor bool `yaml:"no_color" env:"NO_COLOR"`
+}
+
+// Admin - отдельный listener для служебных эндпоинтов: health, метрики, pprof, конфигурация и управление логами.
+type Admin struct {
+	Port string `yaml:"port" env:"PORT" env-default:":9090"`
+	// Слушать только loopback-интерфейс; адрес без хоста превращается в 127.0.0.1
+	LoopbackOnly bool `yaml:"loopback_only" env:"LOOPBACK_ONLY"`
+	// Bearer-токен администратора для /config, /debug/* и /log/*; если не задан, они отвечают 401
+	Token string `yaml:"token" env:"TOKEN" secret:"true"`
+	// Наибольший срок временного понижения уровня логирования для маршрута или клиента
+	MaxOverrideTTL time.Duration `yaml:"max_override_ttl" env:"MAX_OVERRIDE_TTL" env-default:"1h"`
+}
+
+// Режимы проверки ответов по спецификации
+const (
+	ResponseValidationOff  = "off"
+	ResponseValidationLog  = "log"
+	ResponseValidationFail = "fail"
+)
+
+type OpenAPIValidation struct {
+	// Отклонять с 400 запросы, не соответствующие спецификации
+	Requests bool `yaml:"requests" env:"REQUESTS"`
+	// off, log - писать нарушения в лог, fail - заменять ответ на 500; в prod допускается только off
+	Responses string `yaml:"responses" env:"RESPONSES" env-default:"off"`
+}
+
+type HTTPServer struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT" env-default:"10s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"READ_HEADER_TIMEOUT" env-default:"5s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT" env-default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT" env-default:"60s"`
+	// Пауза между переводом /readyz в ошибку и остановкой приёма соединений
+	DrainDelay time.Duration `yaml:"drain_delay" env:"DRAIN_DELAY" env-default:"5s"`
+	// Время на завершение активных запросов и фоновых задач при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"30s"`
+}
+
+type Health struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"CHECK_TIMEOUT" env-default:"2s"` // общий таймаут проверок /readyz
+}
+
+// StorageTimeouts - предельное время выполнения каждой операции хранилища.
+// Таймаут отсчитывается от контекста HTTP-запроса, поэтому отключение клиента прерывает запрос раньше.
+type StorageTimeouts struct {
+	GetAll         time.Duration `yaml:"get_all" env:"GET_ALL" env-default:"15s"`
+	ContactById    time.Duration `yaml:"contact_by_id" env:"CONTACT_BY_ID" env-default:"5s"`
+	Save           time.Duration `yaml:"save" env:"SAVE" env-default:"5s"`
+	Update         time.Duration `yaml:"update" env:"UPDATE" env-default:"5s"`
+	Delete         time.Duration `yaml:"delete" env:"DELETE" env-default:"5s"`
+	DeleteAll      time.Duration `yaml:"delete_all" env:"DELETE_ALL" env-default:"30s"`
+	IncrementQuota time.Duration `yaml:"increment_quota" env:"INCREMENT_QUOTA" env-default:"2s"`
+	// Чтение описаний пользовательских полей выполняется при каждой записи контакта
+	FieldDefinitions time.Duration `yaml:"field_definitions" env:"FIELD_DEFINITIONS" env-default:"2s"`
+	// Включает построение индекса по новому полю
+	PutField time.Duration `yaml:"put_field" env:"PUT_FIELD" env-default:"30s"`
+	// Удаление поля стирает его значения во всех контактах
+	DeleteField time.Duration `yaml:"delete_field" env:"DELETE_FIELD" env-default:"30s"`
+	// Чтение групп и одной группы
+	Groups    time.Duration `yaml:"groups" env:"GROUPS" env-default:"5s"`
+	SaveGroup time.Duration `yaml:"save_group" env:"SAVE_GROUP" env-default:"5s"`
+	// Удаление группы убирает её из всех контактов
+	DeleteGroup time.Duration `yaml:"delete_group" env:"DELETE_GROUP" env-default:"30s"`
+	// Изменение членства в группах и меток пачкой контактов
+	UpdateMembers time.Duration `yaml:"update_members" env:"UPDATE_MEMBERS" env-default:"30s"`
+	// Запись пачки импортируемых контактов
+	Import time.Duration `yaml:"import" env:"IMPORT" env-default:"30s"`
+	// Подсчёт меток проходит по всем контактам
+	Tags time.Duration `yaml:"tags" env:"TAGS" env-default:"15s"`
+}
+
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// Порядок источников ключа клиента: api_key, principal, ip.
+	// api_key учитывается, только если ключ из заголовка есть в api_keys
+	KeyBy        []string `yaml:"key_by" env:"KEY_BY" env-default:"principal,ip"`
+	APIKeyHeader string   `yaml:"api_key_header" env:"API_KEY_HEADER" env-default:"X-API-Key"`
+	// Выданные клиентам ключи; с неизвестным ключом клиент определяется следующим источником из key_by
+	APIKeys         []string `yaml:"api_keys" env:"API_KEYS" secret:"true"`
+	Read            Bucket   `yaml:"read" env-prefix:"READ_"`
+	Write           Bucket   `yaml:"write" env-prefix:"WRITE_"`
+	Destructive     Bucket   `yaml:"destructive" env-prefix:"DESTRUCTIVE_"`
+	DailyWriteQuota int64    `yaml:"daily_write_quota" env:"DAILY_WRITE_QUOTA"` // 0 - без ограничения
+}
+
+type Tracing struct {
+	// Экспортёр спанов: otlp, stdout, file или none
+	Exporter    string  `yaml:"exporter" env:"EXPORTER" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"ENDPOINT" env-default:"localhost:4318"` // адрес OTLP/HTTP коллектора
+	Insecure    bool    `yaml:"insecure" env:"INSECURE"`
+	FilePath    string  `yaml:"file_path" env:"FILE_PATH" env-default:"./traces.json"`
+	ServiceName string  `yaml:"service_name" env:"SERVICE_NAME" env-default:"contact-api"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"SAMPLE_RATIO" env-default:"1"`
+}
+
+type Bucket struct {
+	Rate  float64 `yaml:"rate" env:"RATE"`   // токенов в секунду, 0 - без ограничения
+	Burst int     `yaml:"burst" env:"BURST"` // максимальный размер bucket'а
+}
+
+// LoadOptions описывает, откуда брать конфигурацию.
+type LoadOptions struct {
+	// Путь к файлу конфигурации; если пуст, используется <ConfigDir>/<Profile>.yaml
+	Path      string
+	Profile   string
+	ConfigDir string
+	// Файл с переменными окружения, отсутствие файла не считается ошибкой
+	DotEnv string
+	// Значения флагов командной строки, применяются последними
+	Overrides []func(*Config)
+}
+
+// Load читает конфигурацию по слоям и проверяет результат, возвращая все найденные ошибки сразу.
+func Load(opts LoadOptions) (*Config, error) {
+	if opts.DotEnv != "" {
+		if err := godotenv.Load(opts.DotEnv); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("error loading %s: %w", opts.DotEnv, err)
+		}
 	}
 
-	if _, err := os.Stat(pathToConfig); os.IsNotExist(err) {
-		log.Printf("Config file not found at path: %s", pathToConfig)
-		log.Fatal("Config file does not exist")
+	path := opts.Path
+	if path == "" {
+		if opts.Profile == "" {
+			return nil, errors.New("config file not specified")
+		}
+		path = filepath.Join(opts.ConfigDir, opts.Profile+".yaml")
 	}
 
 	var cfg Config
 
-	if err := cleanenv.ReadConfig(pathToConfig, &cfg); err != nil {
-		log.Fatalf("Error reading config: %s", err.Error())
+	// Значения по умолчанию выставляются до чтения файлов, чтобы явный ноль или false в профиле их перекрывал
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, fmt.Errorf("error reading environment: %w", err)
+	}
+
+	if err := readFileChain(path, &cfg, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	if err := readEnv(&cfg); err != nil {
+		return nil, fmt.Errorf("error reading environment: %w", err)
 	}
-	cfg.DBConnection = getDBConnection("DB_USER", "DB_PASSWORD", cfg.DBConnection)
 
-	return &cfg
+	for _, override := range opts.Overrides {
+		override(&cfg)
+	}
+
+	if err := cfg.Mongo.loadSecrets(); err != nil {
+		return nil, fmt.Errorf("error loading database credentials: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
 }
 
-func getDBConnection(userEnv string, passwdEnv string, defaultConn string) string {
-	user := os.Getenv(userEnv)
-	passwd := os.Getenv(passwdEnv)
+// readFileChain применяет к cfg сначала родительские файлы из extends, затем сам файл.
+func readFileChain(path string, cfg *Config, seen map[string]bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if seen[abs] {
+		return fmt.Errorf("config inheritance cycle at %s", path)
+	}
+	seen[abs] = true
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config %s: %w", path, err)
+	}
+
+	var header struct {
+		Extends string `yaml:"extends"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+
+	if header.Extends != "" {
+		parent := header.Extends
+		if !filepath.IsAbs(parent) {
+			parent = filepath.Join(filepath.Dir(path), parent)
+		}
 
-	if user == "" || passwd == "" {
-		if defaultConn != "" {
-			return defaultConn
+		if err := readFileChain(parent, cfg, seen); err != nil {
+			return err
 		}
-		log.Fatal("Database credentials are not set and no default connection string provided.")
 	}
 
-	res := fmt.Sprintf("mongodb://%s:%s@mongo:27017/contact?authSource=admin", user, passwd)
+	cfg.sources = append(cfg.sources, path)
+
+	// yaml не трогает поля, отсутствующие в файле, поэтому дочерний профиль перекрывает только то, что задал сам
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readEnv накладывает на cfg заданные переменные окружения. cleanenv подставляет env-default
+// в любое нулевое поле, в том числе заданное нулём в файле, поэтому из его результата
+// берутся только поля, для которых переменная действительно задана.
+func readEnv(cfg *Config) error {
+	withEnv := *cfg
+	if err := cleanenv.ReadEnv(&withEnv); err != nil {
+		return err
+	}
+
+	copyEnvFields(reflect.ValueOf(cfg).Elem(), reflect.ValueOf(&withEnv).Elem(), "")
 
-	return res
+	return nil
+}
+
+// copyEnvFields переносит из src в dst поля, для которых задана переменная окружения.
+// Имена переменных составляются так же, как в cleanenv: env-prefix вложенных структур и список имён в env.
+func copyEnvFields(dst, src reflect.Value, prefix string) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			copyEnvFields(dst.Field(i), src.Field(i), prefix+field.Tag.Get("env-prefix"))
+			continue
+		}
+
+		names, ok := field.Tag.Lookup("env")
+		if !ok || names == "" {
+			continue
+		}
+		for _, name := range strings.Split(names, ",") {
+			if _, set := os.LookupEnv(prefix + name); set {
+				dst.Field(i).Set(src.Field(i))
+				break
+			}
+		}
+	}
 }
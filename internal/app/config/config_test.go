@@ -0,0 +1,232 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig создаёт файл конфигурации в dir и возвращает путь к нему.
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testBase - минимальный корректный профиль, от которого наследуются профили тестов.
+const testBase = `
+env: "prod"
+port: ":8080"
+log_level: "info"
+mongo:
+  hosts: ["localhost:27017"]
+  database: "contacts"
+rate_limit:
+  read: {rate: 10, burst: 20}
+`
+
+func TestLoadExtendsChain(t *testing.T) {
+	dir := t.TempDir()
+
+	writeConfig(t, dir, "base.yaml", testBase)
+	writeConfig(t, dir, "profiles/staging.yaml", `
+extends: "../base.yaml"
+env: "staging"
+health:
+  check_timeout: 3s
+rate_limit:
+  read: {rate: 5, burst: 10}
+`)
+	child := writeConfig(t, dir, "profiles/team.yaml", `
+extends: "staging.yaml"
+health:
+  check_timeout: 1s
+mongo:
+  database: "team"
+`)
+
+	cfg, err := Load(LoadOptions{Path: child})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Каждый файл перекрывает только заданные им поля
+	if cfg.Env != EnvStaging || cfg.Health.CheckTimeout != time.Second || cfg.Port != ":8080" {
+		t.Errorf("env %q, health.check_timeout %s, port %q", cfg.Env, cfg.Health.CheckTimeout, cfg.Port)
+	}
+	if cfg.Mongo.Database != "team" || !slices.Equal(cfg.Mongo.Hosts, []string{"localhost:27017"}) {
+		t.Errorf("mongo database %q, hosts %v", cfg.Mongo.Database, cfg.Mongo.Hosts)
+	}
+	if cfg.RateLimit.Read != (Bucket{Rate: 5, Burst: 10}) {
+		t.Errorf("rate_limit.read = %+v", cfg.RateLimit.Read)
+	}
+
+	// Поля, не заданные ни одним файлом, получают значения по умолчанию
+	if cfg.Admin.Port != ":9090" || cfg.Timeouts.Save != 5*time.Second || cfg.Mongo.DeletionCollection != "deletions" {
+		t.Errorf("defaults not applied: admin port %q, save timeout %s, deletion collection %q",
+			cfg.Admin.Port, cfg.Timeouts.Save, cfg.Mongo.DeletionCollection)
+	}
+
+	want := []string{
+		filepath.Join(dir, "base.yaml"),
+		filepath.Join(dir, "profiles", "staging.yaml"),
+		child,
+	}
+	if !slices.Equal(cfg.Sources(), want) {
+		t.Errorf("sources = %v, want %v", cfg.Sources(), want)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "base.yaml", testBase)
+	writeConfig(t, dir, "test.yaml", "extends: base.yaml\nhealth:\n  check_timeout: 3s\n")
+	writeConfig(t, dir, ".env", "MONGO_DATABASE=from-dotenv\n")
+
+	t.Setenv("HEALTH_CHECK_TIMEOUT", "4s")
+	t.Setenv("HTTP_PORT", ":8081")
+	// godotenv пишет в окружение процесса
+	t.Cleanup(func() { os.Unsetenv("MONGO_DATABASE") })
+
+	cfg, err := Load(LoadOptions{
+		Profile:   "test",
+		ConfigDir: dir,
+		DotEnv:    filepath.Join(dir, ".env"),
+		Overrides: []func(*Config){func(c *Config) { c.Port = ":8082" }},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Health.CheckTimeout != 4*time.Second {
+		t.Errorf("health.check_timeout = %s, environment must override files", cfg.Health.CheckTimeout)
+	}
+	if cfg.Port != ":8082" {
+		t.Errorf("port = %q, flags must override environment", cfg.Port)
+	}
+	if cfg.Mongo.Database != "from-dotenv" {
+		t.Errorf("mongo.database = %q, want value from .env", cfg.Mongo.Database)
+	}
+}
+
+// Явно заданные в профиле ноль, false и пустой список не заменяются значениями по умолчанию.
+func TestLoadExplicitZeroValues(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "base.yaml", testBase+`
+http_server:
+  drain_delay: 3s
+`)
+	writeConfig(t, dir, "zero.yaml", `
+extends: base.yaml
+reload_interval: 0s
+http_server:
+  drain_delay: 0s
+rate_limit:
+  enabled: false
+  key_by: ["ip"]
+tracing:
+  sample_ratio: 0
+log_redaction:
+  email_keys: []
+`)
+
+	t.Setenv("STORAGE_TIMEOUT_SAVE", "7s")
+
+	cfg, err := Load(LoadOptions{Profile: "zero", ConfigDir: dir})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.ReloadInterval != 0 || cfg.HTTPServer.DrainDelay != 0 || cfg.Tracing.SampleRatio != 0 {
+		t.Errorf("reload_interval %s, drain_delay %s, sample_ratio %v; want zeros from the profile",
+			cfg.ReloadInterval, cfg.HTTPServer.DrainDelay, cfg.Tracing.SampleRatio)
+	}
+	if cfg.RateLimit.Enabled || !slices.Equal(cfg.RateLimit.KeyBy, []string{"ip"}) || len(cfg.LogRedaction.EmailKeys) != 0 {
+		t.Errorf("rate_limit %+v, email_keys %v", cfg.RateLimit, cfg.LogRedaction.EmailKeys)
+	}
+
+	// Незаданные поля по-прежнему получают значения по умолчанию, а окружение перекрывает файлы
+	if cfg.HTTPServer.ShutdownTimeout != 30*time.Second || cfg.Timeouts.Save != 7*time.Second {
+		t.Errorf("shutdown_timeout %s, save timeout %s", cfg.HTTPServer.ShutdownTimeout, cfg.Timeouts.Save)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "a.yaml", "extends: b.yaml\n")
+	writeConfig(t, dir, "b.yaml", "extends: a.yaml\n")
+	writeConfig(t, dir, "orphan.yaml", "extends: missing.yaml\n")
+	writeConfig(t, dir, "broken.yaml", "log_level: [\n")
+	writeConfig(t, dir, "base.yaml", testBase)
+	writeConfig(t, dir, "invalid.yaml", `
+extends: base.yaml
+port: "8080"
+health:
+  check_timeout: -1s
+rate_limit:
+  key_by: ["api_key"]
+`)
+
+	tests := []struct {
+		name string
+		opts LoadOptions
+		want []string
+	}{
+		{"no file", LoadOptions{}, []string{"config file not specified"}},
+		{"cycle", LoadOptions{Path: filepath.Join(dir, "a.yaml")}, []string{"inheritance cycle"}},
+		{"missing parent", LoadOptions{Path: filepath.Join(dir, "orphan.yaml")}, []string{"missing.yaml"}},
+		{"bad yaml", LoadOptions{Path: filepath.Join(dir, "broken.yaml")}, []string{"error parsing config"}},
+		// Ошибки проверки возвращаются все сразу
+		{"invalid values", LoadOptions{Profile: "invalid", ConfigDir: dir}, []string{
+			`port: "8080" is not a valid listen address`,
+			"health.check_timeout: must be positive",
+			"rate_limit.api_keys: required when key_by includes api_key",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.opts)
+			if err == nil {
+				t.Fatal("Load succeeded")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+// Профили из репозитория должны загружаться без дополнительных настроек, кроме адреса MongoDB.
+func TestRepoProfiles(t *testing.T) {
+	dir := filepath.Join("..", "..", "..", "config")
+	t.Setenv("MONGO_HOSTS", "localhost:27017")
+
+	for _, profile := range []string{"base", "prod", "staging", "local.example"} {
+		t.Run(profile, func(t *testing.T) {
+			cfg, err := Load(LoadOptions{Profile: profile, ConfigDir: dir})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if profile != "base" && len(cfg.Sources()) != 2 {
+				t.Errorf("sources = %v, want base and the profile", cfg.Sources())
+			}
+			// Пробы и сборщик метрик обращаются к admin-listener'у не с loopback
+			if deployed := profile == "prod" || profile == "staging"; deployed && cfg.Admin.Addr() != ":9090" {
+				t.Errorf("admin listens on %q, want all interfaces", cfg.Admin.Addr())
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type Mongo struct {
+	// Полная строка подключения; если задана, Hosts и AuthSource берутся из неё
+	URI        string   `yaml:"uri" env:"URI" secret:"uri"`
+	Hosts      []string `yaml:"hosts" env:"HOSTS" env-default:"mongo:27017"`
+	AuthSource string   `yaml:"auth_source" env:"AUTH_SOURCE" env-default:"admin"`
+
+	User     string `yaml:"user" env:"USER"`
+	Password string `yaml:"password" env:"PASSWORD" secret:"true"`
+	// Файлы Docker secrets, имеют приоритет над User и Password
+	UserFile     string `yaml:"user_file" env:"USER_FILE"`
+	PasswordFile string `yaml:"password_file" env:"PASSWORD_FILE"`
+
+	Database        string `yaml:"database" env:"DATABASE" env-default:"contacts"`
+	Collection      string `yaml:"collection" env:"COLLECTION" env-default:"contact-list"`
+	QuotaCollection string `yaml:"quota_collection" env:"QUOTA_COLLECTION" env-default:"quotas"`
+	// Описания пользовательских полей контактов
+	FieldCollection string `yaml:"field_collection" env:"FIELD_COLLECTION" env-default:"fields"`
+	// Группы контактов; членство хранится в документах контактов
+	GroupCollection string `yaml:"group_collection" env:"GROUP_COLLECTION" env-default:"groups"`
+	// Отметки об удалении контактов, групп и полей для инкрементальных резервных копий
+	DeletionCollection string `yaml:"deletion_collection" env:"DELETION_COLLECTION" env-default:"deletions"`
+
+	MinPoolSize            uint64        `yaml:"min_pool_size" env:"MIN_POOL_SIZE"`
+	MaxPoolSize            uint64        `yaml:"max_pool_size" env:"MAX_POOL_SIZE" env-default:"100"`
+	ConnectTimeout         time.Duration `yaml:"connect_timeout" env:"CONNECT_TIMEOUT" env-default:"10s"`
+	ServerSelectionTimeout time.Duration `yaml:"server_selection_timeout" env:"SERVER_SELECTION_TIMEOUT" env-default:"30s"`
+
+	// primary, primaryPreferred, secondary, secondaryPreferred, nearest
+	ReadPreference string       `yaml:"read_preference" env:"READ_PREFERENCE" env-default:"primary"`
+	WriteConcern   WriteConcern `yaml:"write_concern" env-prefix:"WRITE_CONCERN_"`
+	Retry          Retry        `yaml:"retry" env-prefix:"RETRY_"`
+	TLS            TLS          `yaml:"tls" env-prefix:"TLS_"`
+	Migrations     Migrations   `yaml:"migrations" env-prefix:"MIGRATIONS_"`
+	Schema         Schema       `yaml:"schema" env-prefix:"SCHEMA_"`
+}
+
+type WriteConcern struct {
+	W        string        `yaml:"w" env:"W" env-default:"majority"` // majority или число узлов
+	Journal  bool          `yaml:"journal" env:"JOURNAL"`
+	WTimeout time.Duration `yaml:"wtimeout" env:"WTIMEOUT" env-default:"5s"`
+}
+
+// Retry - политика повторных попыток подключения при старте с экспоненциальной задержкой.
+type Retry struct {
+	Attempts       int           `yaml:"attempts" env:"ATTEMPTS" env-default:"10"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"INITIAL_BACKOFF" env-default:"1s"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"MAX_BACKOFF" env-default:"30s"`
+	Multiplier     float64       `yaml:"multiplier" env:"MULTIPLIER" env-default:"2"`
+}
+
+// Migrations - применение миграций схемы документов.
+type Migrations struct {
+	// Коллекция с применёнными версиями и блокировкой
+	Collection string `yaml:"collection" env:"COLLECTION" env-default:"migrations"`
+	// Применять ожидающие миграции при старте сервера; остальные реплики ждут блокировку
+	Auto bool `yaml:"auto" env:"AUTO"`
+	// Не переписывать коллекцию для миграций, умеющих преобразовывать документ при чтении
+	Lazy bool `yaml:"lazy" env:"LAZY"`
+	// Срок блокировки без продления; после него блокировку упавшей реплики может взять другая
+	LockTTL time.Duration `yaml:"lock_ttl" env:"LOCK_TTL" env-default:"1m"`
+	// Сколько ждать блокировку, занятую другой репликой
+	LockWait time.Duration `yaml:"lock_wait" env:"LOCK_WAIT" env-default:"10m"`
+}
+
+// Режимы сверки валидатора и индексов коллекции контактов при старте
+const (
+	SchemaApply = "apply"
+	SchemaWarn  = "warn"
+	SchemaOff   = "off"
+)
+
+// Schema - $jsonSchema-валидатор и индексы коллекции контактов.
+type Schema struct {
+	// apply - создать коллекцию и привести валидатор и индексы к ожидаемым, warn - только сообщить о расхождениях
+	Mode string `yaml:"mode" env:"MODE" env-default:"apply"`
+	// error - отклонять документы неверной формы, warn - только писать их в лог MongoDB
+	ValidationAction string `yaml:"validation_action" env:"VALIDATION_ACTION" env-default:"error"`
+}
+
+type TLS struct {
+	Enabled            bool   `yaml:"enabled" env:"ENABLED"`
+	CAFile             string `yaml:"ca_file" env:"CA_FILE"`
+	CertFile           string `yaml:"cert_file" env:"CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"KEY_FILE"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"INSECURE_SKIP_VERIFY"`
+}
+
+// Backoff возвращает задержку перед попыткой с номером attempt (начиная с 1).
+func (r Retry) Backoff(attempt int) time.Duration {
+	d := float64(r.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= r.Multiplier
+		if time.Duration(d) >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+
+	return time.Duration(d)
+}
+
+// loadSecrets подставляет учётные данные из файлов Docker secrets, если они указаны.
+func (m *Mongo) loadSecrets() error {
+	if m.UserFile != "" {
+		user, err := readSecret(m.UserFile)
+		if err != nil {
+			return err
+		}
+		m.User = user
+	}
+
+	if m.PasswordFile != "" {
+		passwd, err := readSecret(m.PasswordFile)
+		if err != nil {
+			return err
+		}
+		m.Password = passwd
+	}
+
+	return nil
+}
+
+func readSecret(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", path, err)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
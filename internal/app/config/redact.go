@@ -0,0 +1,72 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+)
+
+const redacted = "******"
+
+// Redacted возвращает копию конфигурации, в которой значения полей с тегом secret скрыты.
+// Для secret:"uri" скрывается только пароль внутри строки подключения.
+func (c Config) Redacted() Config {
+	redactValue(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+func redactValue(v reflect.Value) {
+	t := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		switch t.Field(i).Tag.Get("secret") {
+		case "true":
+			redactSecret(field)
+			continue
+		case "uri":
+			if field.Kind() == reflect.String {
+				field.SetString(RedactURI(field.String()))
+			}
+			continue
+		}
+
+		if field.Kind() == reflect.Struct {
+			redactValue(field)
+		}
+	}
+}
+
+// redactSecret скрывает строку или каждый непустой элемент списка строк.
+func redactSecret(field reflect.Value) {
+	switch {
+	case field.Kind() == reflect.String && field.String() != "":
+		field.SetString(redacted)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String && field.Len() > 0:
+		// Копия, чтобы не затереть ключи в исходной конфигурации, с которой разделяется массив
+		values := reflect.MakeSlice(field.Type(), field.Len(), field.Len())
+		for i := 0; i < field.Len(); i++ {
+			if field.Index(i).String() != "" {
+				values.Index(i).SetString(redacted)
+			}
+		}
+		field.Set(values)
+	}
+}
+
+// RedactURI скрывает пароль в строке подключения, оставляя хост и параметры видимыми.
+func RedactURI(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	}
+
+	return u.String()
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Reloader перечитывает конфигурацию по SIGHUP или при изменении файлов
+// и передаёт новые Reloadable-настройки подписчикам.
+type Reloader struct {
+	log  *slog.Logger
+	opts LoadOptions
+
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []func(Reloadable)
+	modTimes    map[string]time.Time
+}
+
+func NewReloader(log *slog.Logger, opts LoadOptions, cfg *Config) *Reloader {
+	const op = "config.Reloader"
+
+	r := &Reloader{
+		log:  log.With(slog.String("op", op)),
+		opts: opts,
+	}
+	r.current.Store(cfg)
+	r.modTimes = modTimes(cfg.Sources())
+
+	return r
+}
+
+// Current возвращает последнюю успешно применённую конфигурацию.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Subscribe регистрирует функцию, применяющую новые настройки к работающему компоненту.
+func (r *Reloader) Subscribe(fn func(Reloadable)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload читает и проверяет конфигурацию заново. При ошибке продолжает действовать прежняя.
+func (r *Reloader) Reload(trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log := r.log.With(slog.String("trigger", trigger))
+	log.Info("config reload requested")
+
+	// Запоминаем текущее состояние файлов, чтобы ошибочный файл не перечитывался на каждом опросе
+	paths := make([]string, 0, len(r.modTimes))
+	for path := range r.modTimes {
+		paths = append(paths, path)
+	}
+	r.modTimes = modTimes(paths)
+
+	next, err := Load(r.opts)
+	if err != nil {
+		log.Error("config reload rejected, keeping previous config", sl.Err(err))
+		return err
+	}
+
+	prev := r.current.Load()
+	r.modTimes = modTimes(next.Sources())
+
+	if !staticEqual(prev, next) {
+		log.Warn("static settings changed, they will be applied after restart")
+	}
+
+	if reflect.DeepEqual(prev.Reloadable, next.Reloadable) {
+		log.Info("config reloaded, no runtime changes")
+		return nil
+	}
+
+	// Статические поля остаются прежними, пока процесс не перезапущен
+	applied := *prev
+	applied.Reloadable = next.Reloadable
+	r.current.Store(&applied)
+
+	for _, fn := range r.subscribers {
+		fn(applied.Reloadable)
+	}
+
+	log.Info("config reloaded", slog.String("log_level", applied.LogLevel))
+
+	return nil
+}
+
+// Run обрабатывает SIGHUP и опрашивает файлы конфигурации, пока не отменён ctx.
+// При pollInterval <= 0 изменения файлов отслеживаются только по SIGHUP.
+func (r *Reloader) Run(ctx context.Context, pollInterval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if pollInterval > 0 {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			_ = r.Reload("sighup")
+		case <-tick:
+			if r.filesChanged() {
+				_ = r.Reload("file change")
+			}
+		}
+	}
+}
+
+func (r *Reloader) filesChanged() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for path, prev := range r.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(prev) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func modTimes(paths []string) map[string]time.Time {
+	res := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			res[path] = info.ModTime()
+		}
+	}
+	return res
+}
+
+func staticEqual(a, b *Config) bool {
+	x, y := *a, *b
+	x.Reloadable, y.Reloadable = Reloadable{}, Reloadable{}
+	x.sources, y.sources = nil, nil
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// touch переписывает файл и сдвигает время изменения, чтобы опрос заметил его независимо от точности часов ФС.
+func touch(t *testing.T, path, content string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestReloader(t *testing.T) (r *Reloader, base, child string, got *[]Reloadable) {
+	t.Helper()
+
+	dir := t.TempDir()
+	base = writeConfig(t, dir, "base.yaml", testBase)
+	child = writeConfig(t, dir, "child.yaml", "extends: base.yaml\nlog_level: info\n")
+
+	opts := LoadOptions{Path: child}
+	cfg, err := Load(opts)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	r = NewReloader(slog.New(slog.NewTextHandler(io.Discard, nil)), opts, cfg)
+
+	got = new([]Reloadable)
+	r.Subscribe(func(rl Reloadable) { *got = append(*got, rl) })
+
+	return r, base, child, got
+}
+
+func TestReloadAppliesRuntimeSettings(t *testing.T) {
+	r, base, child, got := newTestReloader(t)
+
+	if r.filesChanged() {
+		t.Fatal("files reported as changed right after load")
+	}
+
+	// Изменение родительского файла замечается так же, как изменение самого профиля
+	touch(t, base, strings.Replace(testBase, `port: ":8080"`, `port: ":8081"`, 1))
+	touch(t, child, "extends: base.yaml\nlog_level: debug\n")
+
+	if !r.filesChanged() {
+		t.Fatal("change is not detected")
+	}
+
+	if err := r.Reload("test"); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if len(*got) != 1 || (*got)[0].LogLevel != "debug" {
+		t.Fatalf("subscribers got %+v, want one update with log_level debug", *got)
+	}
+
+	cur := r.Current()
+	if cur.LogLevel != "debug" {
+		t.Errorf("current log_level = %q", cur.LogLevel)
+	}
+	// Статические настройки применяются только после перезапуска
+	if cur.Port != ":8080" {
+		t.Errorf("current port = %q, static setting changed without restart", cur.Port)
+	}
+	if r.filesChanged() {
+		t.Error("files reported as changed after reload")
+	}
+
+	// Повторное чтение без изменений не беспокоит подписчиков
+	if err := r.Reload("test"); err != nil || len(*got) != 1 {
+		t.Errorf("Reload without changes = %v, %d updates", err, len(*got))
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	r, _, child, got := newTestReloader(t)
+	prev := r.Current()
+
+	touch(t, child, "extends: base.yaml\nlog_level: loud\n")
+
+	if err := r.Reload("test"); err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	if r.Current() != prev || len(*got) != 0 {
+		t.Error("invalid config replaced the previous one")
+	}
+
+	// Ошибочный файл не перечитывается на каждом опросе, пока его снова не изменят
+	if r.filesChanged() {
+		t.Error("rejected file reported as changed again")
+	}
+
+	touch(t, child, "extends: base.yaml\nlog_level: warn\n")
+	if !r.filesChanged() {
+		t.Fatal("fix is not detected")
+	}
+	if err := r.Reload("test"); err != nil || r.Current().LogLevel != "warn" {
+		t.Errorf("Reload after fix = %v, log_level %q", err, r.Current().LogLevel)
+	}
+}
+
+func TestReloadFollowsNewParent(t *testing.T) {
+	r, _, child, _ := newTestReloader(t)
+
+	// Профиль начал наследоваться от другого файла: теперь отслеживается и он
+	other := writeConfig(t, filepath.Dir(child), "other.yaml", strings.Replace(testBase, `log_level: "info"`, `log_level: "warn"`, 1))
+	touch(t, child, "extends: other.yaml\n")
+
+	if err := r.Reload("test"); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if r.Current().LogLevel != "warn" {
+		t.Errorf("log_level = %q, want value from the new parent", r.Current().LogLevel)
+	}
+
+	touch(t, other, strings.Replace(testBase, `log_level: "info"`, `log_level: "error"`, 1))
+	if !r.filesChanged() {
+		t.Error("change of the new parent is not detected")
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validate проверяет конфигурацию целиком и возвращает все ошибки одним значением.
+func (c *Config) Validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(slices.Contains([]string{EnvLocal, EnvStaging, EnvProd}, c.Env),
+		"env: unknown environment %q", c.Env)
+
+	_, _, err := net.SplitHostPort(c.Port)
+	check(err == nil, "port: %q is not a valid listen address", c.Port)
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.LogLevel)) == nil, "log_level: unknown level %q", c.LogLevel)
+
+	check(c.HTTPServer.ReadTimeout >= 0, "http_server.read_timeout: must not be negative")
+	check(c.HTTPServer.WriteTimeout >= 0, "http_server.write_timeout: must not be negative")
+	check(c.HTTPServer.IdleTimeout >= 0, "http_server.idle_timeout: must not be negative")
+	check(c.HTTPServer.DrainDelay >= 0, "http_server.drain_delay: must not be negative")
+	check(c.HTTPServer.ShutdownTimeout > 0, "http_server.shutdown_timeout: must be positive")
+	check(c.Health.CheckTimeout > 0, "health.check_timeout: must be positive")
+
+	for name, d := range map[string]time.Duration{
+		"get_all":           c.Timeouts.GetAll,
+		"contact_by_id":     c.Timeouts.ContactById,
+		"save":              c.Timeouts.Save,
+		"update":            c.Timeouts.Update,
+		"delete":            c.Timeouts.Delete,
+		"delete_all":        c.Timeouts.DeleteAll,
+		"increment_quota":   c.Timeouts.IncrementQuota,
+		"field_definitions": c.Timeouts.FieldDefinitions,
+		"put_field":         c.Timeouts.PutField,
+		"delete_field":      c.Timeouts.DeleteField,
+		"groups":            c.Timeouts.Groups,
+		"save_group":        c.Timeouts.SaveGroup,
+		"delete_group":      c.Timeouts.DeleteGroup,
+		"update_members":    c.Timeouts.UpdateMembers,
+		"import":            c.Timeouts.Import,
+		"tags":              c.Timeouts.Tags,
+	} {
+		check(d > 0, "storage_timeouts.%s: must be positive", name)
+	}
+
+	for _, source := range c.RateLimit.KeyBy {
+		check(slices.Contains([]string{"api_key", "principal", "ip"}, source),
+			"rate_limit.key_by: unknown key source %q", source)
+	}
+	check(!slices.Contains(c.RateLimit.KeyBy, "api_key") || len(c.RateLimit.APIKeys) > 0,
+		"rate_limit.api_keys: required when key_by includes api_key")
+	for name, b := range map[string]Bucket{
+		"read":        c.RateLimit.Read,
+		"write":       c.RateLimit.Write,
+		"destructive": c.RateLimit.Destructive,
+	} {
+		check(b.Rate >= 0, "rate_limit.%s.rate: must not be negative", name)
+		check(b.Rate == 0 || b.Burst > 0, "rate_limit.%s.burst: must be positive when rate is set", name)
+	}
+	check(c.RateLimit.DailyWriteQuota >= 0, "rate_limit.daily_write_quota: must not be negative")
+
+	check(slices.Contains([]string{"", "none", "otlp", "stdout", "file"}, c.Tracing.Exporter),
+		"tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio: must be between 0 and 1")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(strings.Count(origin, "*") <= 1, "cors.allowed_origins: %q may contain only one wildcard", origin)
+		// Браузеры отклоняют ответы с Access-Control-Allow-Origin: * на запросы с учётными данными
+		check(origin != "*" || !c.CORS.AllowCredentials,
+			"cors: allow_credentials cannot be combined with the \"*\" origin")
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age: must not be negative")
+
+	for name, mode := range map[string]string{"email": c.LogRedaction.Email, "phone": c.LogRedaction.Phone} {
+		check(slices.Contains([]string{"none", "mask", "hash", "drop"}, mode),
+			"log_redaction.%s: unknown mode %q", name, mode)
+	}
+
+	adminHost, _, err := net.SplitHostPort(c.Admin.Port)
+	check(err == nil, "admin.port: %q is not a valid listen address", c.Admin.Port)
+	check(err != nil || !c.Admin.LoopbackOnly || adminHost == "" || isLoopback(adminHost),
+		"admin.port: %q is not a loopback address while loopback_only is set", c.Admin.Port)
+	check(c.Admin.Port != c.Port, "admin.port: must differ from port")
+	check(c.Admin.MaxOverrideTTL > 0, "admin.max_override_ttl: must be positive")
+
+	check(slices.Contains([]string{ResponseValidationOff, ResponseValidationLog, ResponseValidationFail}, c.OpenAPIValidation.Responses),
+		"openapi_validation.responses: unknown mode %q", c.OpenAPIValidation.Responses)
+	// Проверка ответов буферизует их целиком, поэтому предназначена только для тестовых окружений
+	check(c.Env != EnvProd || c.OpenAPIValidation.Responses == ResponseValidationOff,
+		"openapi_validation.responses: must be off in prod")
+
+	errs = append(errs, c.Mongo.validate()...)
+
+	return errors.Join(errs...)
+}
+
+func (m *Mongo) validate() []error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(m.URI != "" || len(m.Hosts) > 0, "mongo: either uri or hosts must be set")
+	check(m.Database != "", "mongo.database: must not be empty")
+	check(m.Collection != "", "mongo.collection: must not be empty")
+	check(m.QuotaCollection != "", "mongo.quota_collection: must not be empty")
+	check(m.FieldCollection != "", "mongo.field_collection: must not be empty")
+	check(m.FieldCollection != m.Collection && m.FieldCollection != m.QuotaCollection,
+		"mongo.field_collection: must differ from collection and quota_collection")
+	check(m.GroupCollection != "", "mongo.group_collection: must not be empty")
+	check(m.GroupCollection != m.Collection && m.GroupCollection != m.QuotaCollection && m.GroupCollection != m.FieldCollection,
+		"mongo.group_collection: must differ from collection, quota_collection and field_collection")
+	check(m.DeletionCollection != "", "mongo.deletion_collection: must not be empty")
+	check(!slices.Contains([]string{m.Collection, m.QuotaCollection, m.FieldCollection, m.GroupCollection}, m.DeletionCollection),
+		"mongo.deletion_collection: must differ from collection, quota_collection, field_collection and group_collection")
+	check(m.User == "" || m.Password != "", "mongo.password: must be set together with user")
+	check(m.MaxPoolSize == 0 || m.MinPoolSize <= m.MaxPoolSize,
+		"mongo.min_pool_size: must not exceed max_pool_size")
+
+	_, err := readpref.ModeFromString(m.ReadPreference)
+	check(err == nil, "mongo.read_preference: unknown mode %q", m.ReadPreference)
+
+	check(m.Retry.Attempts >= 1, "mongo.retry.attempts: must be at least 1")
+	check(m.Retry.InitialBackoff > 0, "mongo.retry.initial_backoff: must be positive")
+	check(m.Retry.MaxBackoff >= m.Retry.InitialBackoff,
+		"mongo.retry.max_backoff: must not be less than initial_backoff")
+	check(m.Retry.Multiplier >= 1, "mongo.retry.multiplier: must be at least 1")
+
+	check((m.TLS.CertFile == "") == (m.TLS.KeyFile == ""),
+		"mongo.tls: cert_file and key_file must be set together")
+
+	check(m.Migrations.Collection != "", "mongo.migrations.collection: must not be empty")
+	check(!slices.Contains([]string{m.Collection, m.QuotaCollection, m.FieldCollection, m.GroupCollection, m.DeletionCollection}, m.Migrations.Collection),
+		"mongo.migrations.collection: must differ from the other collections")
+	check(m.Migrations.LockTTL >= time.Second, "mongo.migrations.lock_ttl: must be at least 1s")
+	check(m.Migrations.LockWait > 0, "mongo.migrations.lock_wait: must be positive")
+
+	check(slices.Contains([]string{SchemaApply, SchemaWarn, SchemaOff}, m.Schema.Mode),
+		"mongo.schema.mode: unknown mode %q, expected apply, warn or off", m.Schema.Mode)
+	check(slices.Contains([]string{"error", "warn"}, m.Schema.ValidationAction),
+		"mongo.schema.validation_action: unknown action %q, expected error or warn", m.Schema.ValidationAction)
+
+	return errs
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
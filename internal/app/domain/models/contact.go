@@ -1,13 +1,227 @@
 package models
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Метки элементов списков контакта
+const (
+	LabelHome   = "home"
+	LabelWork   = "work"
+	LabelMobile = "mobile"
+	LabelFax    = "fax"
+	LabelOther  = "other"
+)
+
+// Допустимые метки каждого списка; совпадают с enum в openapi.yaml
+var (
+	EmailLabels   = []string{LabelHome, LabelWork, LabelOther}
+	PhoneLabels   = []string{LabelMobile, LabelHome, LabelWork, LabelFax, LabelOther}
+	AddressLabels = []string{LabelHome, LabelWork, LabelOther}
+	URLLabels     = []string{LabelHome, LabelWork, LabelOther}
+)
+
 type Contact struct {
-	ID        string `json:"_id"`
-	UserName  string `json:"username"`
-	Email     string `json:"email"`
-	Telephone Phone  `json:"telephone"`
+	ID       string `json:"_id"`
+	UserName string `json:"username"`
+	Name     Name   `json:"name"`
+
+	Emails    []Email   `json:"emails"`
+	Phones    []Phone   `json:"phones"`
+	Addresses []Address `json:"addresses"`
+	URLs      []URL     `json:"urls"`
+
+	Organization string `json:"organization,omitempty"`
+	Title        string `json:"title,omitempty"`
+	// YYYY-MM-DD или --MM-DD, если год неизвестен
+	Birthday string `json:"birthday,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+
+	// Значения пользовательских полей по имени, см. FieldDefinition
+	Fields map[string]any `json:"fields"`
+
+	// Произвольные метки, см. ValidateTags
+	Tags []string `json:"tags"`
+	// Идентификаторы групп контакта; меняются только через членство в группах, при записи контакта игнорируются
+	Groups []string `json:"groups"`
+
+	// Устаревшие поля для клиентов, написанных до появления списков: при чтении заполняются
+	// первым email и первыми телефонами с метками mobile и home, при записи используются,
+	// только если соответствующий список пуст
+	Email     string    `json:"email"`
+	Telephone Telephone `json:"telephone"`
+}
+
+type Name struct {
+	// Как показывать контакт; given и family - для сортировки и экспорта
+	Display string `json:"display,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Family  string `json:"family,omitempty"`
+}
+
+type Email struct {
+	// home, work, other
+	Label   string `json:"label"`
+	Address string `json:"address"`
 }
 
 type Phone struct {
+	// mobile, home, work, fax, other
+	Label  string `json:"label"`
+	Number string `json:"number"`
+}
+
+type Address struct {
+	// home, work, other
+	Label      string `json:"label"`
+	Street     string `json:"street,omitempty"`
+	City       string `json:"city,omitempty"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+type URL struct {
+	// home, work, other
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Telephone - устаревшая пара телефонов, см. Contact.Telephone
+type Telephone struct {
 	Mobile string `json:"mobile"`
 	Home   string `json:"home"`
 }
+
+// Normalize согласует устаревшие поля со списками: переносит email и telephone в пустые списки
+// и заполняет устаревшие поля из списков. Вызывается для входящих контактов перед сохранением
+// и для прочитанных из хранилища.
+func (c *Contact) Normalize() {
+	if len(c.Emails) == 0 && c.Email != "" {
+		c.Emails = []Email{{Label: LabelOther, Address: c.Email}}
+	}
+
+	if len(c.Phones) == 0 {
+		if c.Telephone.Mobile != "" {
+			c.Phones = append(c.Phones, Phone{Label: LabelMobile, Number: c.Telephone.Mobile})
+		}
+		if c.Telephone.Home != "" {
+			c.Phones = append(c.Phones, Phone{Label: LabelHome, Number: c.Telephone.Home})
+		}
+	}
+
+	c.Email = ""
+	if len(c.Emails) > 0 {
+		c.Email = c.Emails[0].Address
+	}
+
+	c.Telephone = Telephone{
+		Mobile: c.firstPhone(LabelMobile),
+		Home:   c.firstPhone(LabelHome),
+	}
+
+	// Пустые списки отдаются как [], а не null
+	if c.Emails == nil {
+		c.Emails = []Email{}
+	}
+	if c.Phones == nil {
+		c.Phones = []Phone{}
+	}
+	if c.Addresses == nil {
+		c.Addresses = []Address{}
+	}
+	if c.URLs == nil {
+		c.URLs = []URL{}
+	}
+	if c.Fields == nil {
+		c.Fields = map[string]any{}
+	}
+	if c.Tags == nil {
+		c.Tags = []string{}
+	}
+	if c.Groups == nil {
+		c.Groups = []string{}
+	}
+}
+
+func (c *Contact) firstPhone(label string) string {
+	for _, p := range c.Phones {
+		if p.Label == label {
+			return p.Number
+		}
+	}
+	return ""
+}
+
+// ContactError - ошибка одного поля контакта; Field - путь в JSON, например emails.0.label.
+type ContactError struct {
+	Field   string
+	Message string
+}
+
+// ContactErrors - все ошибки полей контакта.
+type ContactErrors []ContactError
+
+func (e ContactErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, ce := range e {
+		parts[i] = ce.Field + ": " + ce.Message
+	}
+	return "invalid contact: " + strings.Join(parts, "; ")
+}
+
+// Validate проверяет метки и обязательные значения элементов списков и формат дня рождения.
+// Спецификация описывает то же самое, но проверка запросов по ней может быть выключена,
+// поэтому записывать такие контакты не даёт сама модель. Вызывается после Normalize.
+func (c *Contact) Validate() error {
+	var errs ContactErrors
+
+	check := func(ok bool, field, format string, args ...any) {
+		if !ok {
+			errs = append(errs, ContactError{Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	label := func(list string, i int, label string, allowed []string) {
+		check(slices.Contains(allowed, label), fmt.Sprintf("%s.%d.label", list, i),
+			"unknown label %q, want one of %s", label, strings.Join(allowed, ", "))
+	}
+
+	for i, e := range c.Emails {
+		label("emails", i, e.Label, EmailLabels)
+		check(e.Address != "", fmt.Sprintf("emails.%d.address", i), "required")
+	}
+	for i, p := range c.Phones {
+		label("phones", i, p.Label, PhoneLabels)
+		check(p.Number != "", fmt.Sprintf("phones.%d.number", i), "required")
+	}
+	for i, a := range c.Addresses {
+		label("addresses", i, a.Label, AddressLabels)
+	}
+	for i, u := range c.URLs {
+		label("urls", i, u.Label, URLLabels)
+		check(u.URL != "", fmt.Sprintf("urls.%d.url", i), "required")
+	}
+
+	check(c.Birthday == "" || validBirthday(c.Birthday), "birthday",
+		"%q is not a date in YYYY-MM-DD or --MM-DD form", c.Birthday)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validBirthday проверяет дату YYYY-MM-DD или --MM-DD без года.
+func validBirthday(s string) bool {
+	// Без года 29 февраля допустимо, поэтому день проверяется в високосном году
+	if rest, ok := strings.CutPrefix(s, "--"); ok {
+		s = "2000-" + rest
+	}
+
+	_, err := time.Parse(time.DateOnly, s)
+	return err == nil
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		contact Contact
+		fields  []string
+	}{
+		{name: "empty", contact: Contact{UserName: "ann"}},
+		{name: "valid lists", contact: Contact{
+			Emails:    []Email{{Label: LabelWork, Address: "ann@example.com"}},
+			Phones:    []Phone{{Label: LabelFax, Number: "+100"}},
+			Addresses: []Address{{Label: LabelHome}},
+			URLs:      []URL{{Label: LabelOther, URL: "https://example.com"}},
+			Birthday:  "1990-04-12",
+		}},
+		{name: "birthday without year", contact: Contact{Birthday: "--02-29"}},
+		{name: "wrong labels", contact: Contact{
+			Emails:    []Email{{Label: LabelHome, Address: "a@example.com"}, {Label: LabelMobile, Address: "b@example.com"}},
+			Phones:    []Phone{{Label: "cell", Number: "+100"}},
+			Addresses: []Address{{Label: LabelFax}},
+			URLs:      []URL{{Label: "", URL: "https://example.com"}},
+		}, fields: []string{"emails.1.label", "phones.0.label", "addresses.0.label", "urls.0.label"}},
+		{name: "missing values", contact: Contact{
+			Emails: []Email{{Label: LabelHome}},
+			Phones: []Phone{{Label: LabelHome}},
+			URLs:   []URL{{Label: LabelHome}},
+		}, fields: []string{"emails.0.address", "phones.0.number", "urls.0.url"}},
+		{name: "birthday format", contact: Contact{Birthday: "12.04.1990"}, fields: []string{"birthday"}},
+		{name: "birthday date", contact: Contact{Birthday: "1990-02-30"}, fields: []string{"birthday"}},
+		{name: "birthday without year date", contact: Contact{Birthday: "--13-01"}, fields: []string{"birthday"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.contact.Validate()
+
+			var errs ContactErrors
+			if err != nil && !errors.As(err, &errs) {
+				t.Fatalf("Validate = %v, want ContactErrors", err)
+			}
+
+			var fields []string
+			for _, ce := range errs {
+				fields = append(fields, ce.Field)
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("invalid fields %v, want %v: %v", fields, tt.fields, err)
+			}
+		})
+	}
+}
+
+// Устаревшие поля переносятся в списки с допустимыми метками.
+func TestNormalizedLegacyContactIsValid(t *testing.T) {
+	c := Contact{Email: "ann@example.com", Telephone: Telephone{Mobile: "+100", Home: "+200"}}
+	c.Normalize()
+
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
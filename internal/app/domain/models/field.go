@@ -0,0 +1,185 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы пользовательских полей
+const (
+	FieldString = "string"
+	FieldNumber = "number"
+	FieldDate   = "date"
+	FieldEnum   = "enum"
+	FieldURL    = "url"
+)
+
+// FieldTypes - все допустимые типы пользовательских полей
+var FieldTypes = []string{FieldString, FieldNumber, FieldDate, FieldEnum, FieldURL}
+
+// Формат значений полей типа date; такие значения сортируются как строки
+const DateLayout = "2006-01-02"
+
+var ErrInvalidField = errors.New("invalid field definition")
+
+// Имя поля - ключ в Contact.Fields и часть пути в MongoDB, поэтому без точек и $
+var fieldNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// FieldDefinition - пользовательское поле контактов, заданное администратором.
+type FieldDefinition struct {
+	Name string `json:"name"`
+	// string, number, date, enum, url
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Допустимые значения, только для enum
+	Values      []string `json:"values,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Validate проверяет само описание поля.
+func (d FieldDefinition) Validate() error {
+	if !fieldNameRe.MatchString(d.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidField, fieldNameRe)
+	}
+
+	if !slices.Contains(FieldTypes, d.Type) {
+		return fmt.Errorf("%w: unknown type %q, expected one of %s", ErrInvalidField, d.Type, strings.Join(FieldTypes, ", "))
+	}
+
+	if d.Type != FieldEnum {
+		if len(d.Values) > 0 {
+			return fmt.Errorf("%w: values are allowed only for enum", ErrInvalidField)
+		}
+		return nil
+	}
+
+	if len(d.Values) == 0 {
+		return fmt.Errorf("%w: enum requires values", ErrInvalidField)
+	}
+	for i, v := range d.Values {
+		if v == "" {
+			return fmt.Errorf("%w: enum values must not be empty", ErrInvalidField)
+		}
+		if slices.Contains(d.Values[:i], v) {
+			return fmt.Errorf("%w: duplicate enum value %q", ErrInvalidField, v)
+		}
+	}
+
+	return nil
+}
+
+// ParseValue приводит значение поля из запроса к хранимому виду: числа - float64,
+// остальные типы - строки; дата приводится к DateLayout.
+func (d FieldDefinition) ParseValue(v any) (any, error) {
+	if d.Type == FieldNumber {
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case string:
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, errors.New("must be a number")
+			}
+			return f, nil
+		}
+		return nil, errors.New("must be a number")
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("must be a string")
+	}
+
+	switch d.Type {
+	case FieldDate:
+		t, err := time.Parse(DateLayout, s)
+		if err != nil {
+			return nil, errors.New("must be a date in YYYY-MM-DD format")
+		}
+		return t.Format(DateLayout), nil
+
+	case FieldEnum:
+		if !slices.Contains(d.Values, s) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(d.Values, ", "))
+		}
+
+	case FieldURL:
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("must be an absolute http or https url")
+		}
+	}
+
+	return s, nil
+}
+
+// FieldError - ошибка значения одного пользовательского поля.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// FieldErrors - все ошибки значений пользовательских полей контакта.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "invalid custom fields: " + strings.Join(parts, "; ")
+}
+
+// ValidateFields проверяет Fields по описаниям полей и приводит значения к хранимому виду.
+// Возвращает FieldErrors со всеми найденными ошибками. Пустая строка или null
+// означает отсутствие значения.
+func (c *Contact) ValidateFields(defs []FieldDefinition) error {
+	var errs FieldErrors
+
+	values := make(map[string]any, len(c.Fields))
+
+	for name, v := range c.Fields {
+		if v == nil || v == "" {
+			continue
+		}
+
+		i := slices.IndexFunc(defs, func(d FieldDefinition) bool { return d.Name == name })
+		if i < 0 {
+			errs = append(errs, FieldError{Field: name, Message: "unknown field"})
+			continue
+		}
+
+		parsed, err := defs[i].ParseValue(v)
+		if err != nil {
+			errs = append(errs, FieldError{Field: name, Message: err.Error()})
+			continue
+		}
+		values[name] = parsed
+	}
+
+	for _, d := range defs {
+		if v := c.Fields[d.Name]; d.Required && (v == nil || v == "") {
+			errs = append(errs, FieldError{Field: d.Name, Message: "required"})
+		}
+	}
+
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b FieldError) int { return strings.Compare(a.Field, b.Field) })
+		return errs
+	}
+
+	c.Fields = values
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrInvalidGroup = errors.New("invalid group")
+
+// Цвет группы в виде #rrggbb
+var groupColorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Group - именованный набор контактов. Членство хранится в Contact.Groups.
+type Group struct {
+	ID          string `json:"_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Color       string `json:"color,omitempty"`
+	// Число контактов в группе; поддерживается хранилищем при изменении членства
+	MemberCount int64 `json:"member_count"`
+}
+
+// Validate проверяет и нормализует имя и цвет группы.
+func (g *Group) Validate() error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" || utf8.RuneCountInString(g.Name) > 128 {
+		return fmt.Errorf("%w: name must be 1 to 128 characters", ErrInvalidGroup)
+	}
+
+	if utf8.RuneCountInString(g.Description) > 1024 {
+		return fmt.Errorf("%w: description must be at most 1024 characters", ErrInvalidGroup)
+	}
+
+	if g.Color != "" {
+		if !groupColorRe.MatchString(g.Color) {
+			return fmt.Errorf("%w: color must be in #rrggbb format", ErrInvalidGroup)
+		}
+		g.Color = strings.ToLower(g.Color)
+	}
+
+	return nil
+}
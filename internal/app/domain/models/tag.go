@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ограничения меток контакта
+const (
+	MaxTags      = 50
+	MaxTagLength = 64
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+// NormalizeTag обрезает пробелы по краям и проверяет метку. Запятая запрещена,
+// потому что разделяет метки в CSV и в CATEGORIES vCard.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", fmt.Errorf("%w: %q must be 1 to %d characters", ErrInvalidTag, tag, MaxTagLength)
+	}
+
+	if strings.ContainsFunc(tag, func(r rune) bool { return r == ',' || unicode.IsControl(r) }) {
+		return "", fmt.Errorf("%w: %q must not contain commas or control characters", ErrInvalidTag, tag)
+	}
+
+	return tag, nil
+}
+
+// NormalizeTags нормализует метки и убирает повторы, сохраняя порядок.
+func NormalizeTags(tags []string) ([]string, error) {
+	result := make([]string, 0, len(tags))
+
+	for _, t := range tags {
+		tag, err := NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	if len(result) > MaxTags {
+		return nil, fmt.Errorf("%w: at most %d tags per contact", ErrInvalidTag, MaxTags)
+	}
+
+	return result, nil
+}
+
+// ValidateTags нормализует Tags контакта.
+func (c *Contact) ValidateTags() error {
+	tags, err := NormalizeTags(c.Tags)
+	if err != nil {
+		return err
+	}
+
+	c.Tags = tags
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package features
+
+import "sync/atomic"
+
+// Список известных переключателей функциональности
+const (
+	// DebugErrors добавляет текст внутренней ошибки в ответы API
+	DebugErrors = "debug_errors"
+)
+
+var current atomic.Pointer[map[string]bool]
+
+// Set атомарно заменяет набор включённых переключателей.
+func Set(toggles map[string]bool) {
+	copied := make(map[string]bool, len(toggles))
+	for name, on := range toggles {
+		copied[name] = on
+	}
+	current.Store(&copied)
+}
+
+// Enabled сообщает, включён ли переключатель; неизвестные переключатели выключены.
+func Enabled(name string) bool {
+	toggles := current.Load()
+	if toggles == nil {
+		return false
+	}
+	return (*toggles)[name]
+}
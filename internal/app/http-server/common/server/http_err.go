@@ -1,7 +1,11 @@
 package server
 
 import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/features"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 )
@@ -14,24 +18,116 @@ func BadRequest(slug string, err error, w http.ResponseWriter, r *http.Request)
 	httpRespondWithError(err, slug, w, r, "Bad request", http.StatusBadRequest)
 }
 
+// Violation - одно нарушение спецификации API во входящем запросе.
+type Violation struct {
+	In      string `json:"in"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// InvalidRequest отвечает 400 со списком нарушений спецификации.
+func InvalidRequest(slug string, violations []Violation, err error, w http.ResponseWriter, r *http.Request) {
+	resp := newErrorResponse(err, slug, http.StatusBadRequest)
+	resp.Violations = violations
+
+	writeError(resp, w)
+}
+
+// FieldViolations раскладывает ошибку проверки пользовательских полей на нарушения для InvalidRequest.
+func FieldViolations(err error) []Violation {
+	var errs models.FieldErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	violations := make([]Violation, len(errs))
+	for i, fe := range errs {
+		violations[i] = Violation{In: "body", Field: "fields." + fe.Field, Message: fe.Message}
+	}
+
+	return violations
+}
+
+// ContactViolations раскладывает ошибку проверки контакта на нарушения для InvalidRequest.
+func ContactViolations(err error) []Violation {
+	var errs models.ContactErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	violations := make([]Violation, len(errs))
+	for i, ce := range errs {
+		violations[i] = Violation{In: "body", Field: ce.Field, Message: ce.Message}
+	}
+
+	return violations
+}
+
 func NotFound(slug string, err error, w http.ResponseWriter, r *http.Request) {
 	httpRespondWithError(err, slug, w, r, "Not found", http.StatusBadRequest)
 }
 
+func Conflict(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Conflict", http.StatusConflict)
+}
+
+func Unauthorized(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Unauthorized", http.StatusUnauthorized)
+}
+
+func TooManyRequests(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Too many requests", http.StatusTooManyRequests)
+}
+
+// StatusClientClosedRequest - нестандартный код nginx для запросов, прерванных клиентом
+const StatusClientClosedRequest = 499
+
+func ClientClosed(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Client closed request", StatusClientClosedRequest)
+}
+
+func GatewayTimeout(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Gateway timeout", http.StatusGatewayTimeout)
+}
+
+// ContextError отвечает клиенту, если операция прервана отменой или таймаутом контекста запроса,
+// чтобы такие случаи не смешивались с ошибками хранилища. Возвращает false для прочих ошибок.
+func ContextError(err error, w http.ResponseWriter, r *http.Request) bool {
+	switch {
+	case errors.Is(err, context.Canceled):
+		ClientClosed("request canceled", err, w, r)
+	case errors.Is(err, context.DeadlineExceeded):
+		GatewayTimeout("request timed out", err, w, r)
+	default:
+		return false
+	}
+
+	return true
+}
+
 func httpRespondWithError(err error, slug string, w http.ResponseWriter, r *http.Request, msg string, status int) {
+	writeError(newErrorResponse(err, slug, status), w)
+}
+
+func newErrorResponse(err error, slug string, status int) ErrorResponse {
 	resp := ErrorResponse{Slug: slug, httpStatus: status}
-	if os.Getenv("DEBUG_ERRORS") != "" && err != nil {
+	if (os.Getenv("DEBUG_ERRORS") != "" || features.Enabled(features.DebugErrors)) && err != nil {
 		resp.Error = err.Error()
 	}
 
+	return resp
+}
+
+func writeError(resp ErrorResponse, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(status)
+	w.WriteHeader(resp.httpStatus)
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
 type ErrorResponse struct {
-	Slug       string `json:"slug"`
-	Error      string `json:"error,omitempty"`
+	Slug       string      `json:"slug"`
+	Error      string      `json:"error,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
 	httpStatus int
 }
 
@@ -1,12 +1,20 @@
 package server
 
 import (
+	"contact-api/internal/app/tracing"
 	"encoding/json"
 	"net/http"
 )
 
 func RespondOK(data any, w http.ResponseWriter, r *http.Request) {
+	Respond(http.StatusOK, data, w, r)
+}
+
+func Respond(status int, data any, w http.ResponseWriter, r *http.Request) {
+	_, span := tracing.Tracer().Start(r.Context(), "server.Respond")
+	defer span.End()
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(data)
 }
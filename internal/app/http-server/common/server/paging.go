@@ -0,0 +1,41 @@
+package server
+
+import (
+	"contact-api/internal/app/storage"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// PageOptions читает limit и cursor постраничных списков контактов.
+func PageOptions(r *http.Request) (storage.ListOptions, error) {
+	var opts storage.ListOptions
+
+	query := r.URL.Query()
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit < 1 || limit > storage.MaxPageSize {
+			return opts, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidLimit, storage.MaxPageSize)
+		}
+		opts.Limit = limit
+	}
+
+	opts.After = query.Get("cursor")
+
+	return opts, nil
+}
+
+// SetNextLink добавляет заголовок Link со ссылкой на следующую страницу относительно текущего запроса.
+func SetNextLink(cursor string, w http.ResponseWriter, r *http.Request) {
+	u := *r.URL
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	u.Scheme, u.Host = "", ""
+
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+}
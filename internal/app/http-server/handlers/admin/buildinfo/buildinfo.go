@@ -0,0 +1,14 @@
+package buildinfo
+
+import (
+	"contact-api/internal/app/buildinfo"
+	"contact-api/internal/app/http-server/common/server"
+	"net/http"
+)
+
+// New возвращает версию и коммит, из которых собран сервис.
+func New() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		server.RespondOK(buildinfo.Get(), w, r)
+	}
+}
@@ -0,0 +1,42 @@
+package effectiveconfig
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/http-server/common/server"
+	"gopkg.in/yaml.v3"
+	"net/http"
+)
+
+type ConfigSource interface {
+	Current() *config.Config
+}
+
+type Resp struct {
+	Sources []string       `json:"sources"`
+	Config  map[string]any `json:"config"`
+}
+
+// New возвращает действующую конфигурацию с учётом перезагрузок; секреты скрыты.
+// Конфигурация проходит через YAML, чтобы ключи совпадали с файлами профилей.
+func New(source ConfigSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := source.Current()
+
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			server.InternalError("error encoding config", err, w, r)
+			return
+		}
+
+		var fields map[string]any
+		if err := yaml.Unmarshal(out, &fields); err != nil {
+			server.InternalError("error encoding config", err, w, r)
+			return
+		}
+
+		server.RespondOK(Resp{
+			Sources: cfg.Sources(),
+			Config:  fields,
+		}, w, r)
+	}
+}
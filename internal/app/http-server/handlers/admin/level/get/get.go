@@ -0,0 +1,32 @@
+package get
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/loglevel"
+	"log/slog"
+	"net/http"
+)
+
+type OverrideLister interface {
+	List() []loglevel.Override
+}
+
+type Resp struct {
+	Level     slog.Level          `json:"level"`
+	Overrides []loglevel.Override `json:"overrides"`
+}
+
+// New возвращает текущий общий уровень логирования и действующие переопределения.
+func New(level slog.Leveler, overrides OverrideLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list := overrides.List()
+		if list == nil {
+			list = []loglevel.Override{}
+		}
+
+		server.RespondOK(Resp{
+			Level:     level.Level(),
+			Overrides: list,
+		}, w, r)
+	}
+}
@@ -0,0 +1,68 @@
+package set
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/pkg/logger/sl"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+type Req struct {
+	// Строка, а не slog.Level: нулевое значение slog.Level - INFO, и пустое тело молча включило бы его
+	Level string `json:"level"`
+}
+
+type Resp struct {
+	Level    slog.Level `json:"level"`
+	Previous slog.Level `json:"previous"`
+}
+
+// New меняет общий уровень логирования. Значение действует до следующей
+// перезагрузки конфигурации, которая снова применит log_level из файла.
+func New(log *slog.Logger, level *slog.LevelVar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.level.set.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var req Req
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		var lvl slog.Level
+		err := errors.New("level is required")
+		if req.Level != "" {
+			err = lvl.UnmarshalText([]byte(req.Level))
+		}
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid log level", sl.Err(err))
+
+			server.InvalidRequest("invalid log level", []server.Violation{
+				{In: "body", Field: "level", Message: err.Error()},
+			}, err, w, r)
+
+			return
+		}
+
+		previous := level.Level()
+		level.Set(lvl)
+
+		// Пишем на уровне Warn, чтобы смена уровня попала в лог при любом значении
+		log.WarnContext(r.Context(), "log level changed",
+			slog.String("from", previous.String()),
+			slog.String("to", lvl.String()))
+
+		server.RespondOK(Resp{
+			Level:    lvl,
+			Previous: previous,
+		}, w, r)
+	}
+}
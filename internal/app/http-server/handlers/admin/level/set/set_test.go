@@ -0,0 +1,51 @@
+package set
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		status    int
+		want      slog.Level
+		violation bool
+	}{
+		{name: "ok", body: `{"level":"debug"}`, status: http.StatusOK, want: slog.LevelDebug},
+		{name: "offset", body: `{"level":"WARN+2"}`, status: http.StatusOK, want: slog.LevelWarn + 2},
+		// Пустое тело не должно выставлять INFO, нулевое значение slog.Level
+		{name: "empty body", body: `{}`, status: http.StatusBadRequest, want: slog.LevelError, violation: true},
+		{name: "empty level", body: `{"level":""}`, status: http.StatusBadRequest, want: slog.LevelError, violation: true},
+		{name: "unknown level", body: `{"level":"loud"}`, status: http.StatusBadRequest, want: slog.LevelError, violation: true},
+		{name: "wrong type", body: `{"level":-4}`, status: http.StatusBadRequest, want: slog.LevelError},
+		{name: "malformed json", body: `{"level":`, status: http.StatusBadRequest, want: slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := new(slog.LevelVar)
+			level.Set(slog.LevelError)
+
+			h := New(slog.New(slog.NewTextHandler(io.Discard, nil)), level)
+
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if level.Level() != tt.want {
+				t.Errorf("level = %s, want %s", level.Level(), tt.want)
+			}
+			if tt.violation && !strings.Contains(w.Body.String(), `"field":"level"`) {
+				t.Errorf("response does not point at the level field: %s", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package add
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/loglevel"
+	"contact-api/internal/pkg/logger/sl"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type OverrideAdder interface {
+	Add(ov loglevel.Override, ttl time.Duration) (loglevel.Override, error)
+}
+
+type Req struct {
+	Route string `json:"route"`
+	// Совпадает только с "admin": других аутентифицированных клиентов нет
+	Principal string `json:"principal"`
+	// По умолчанию debug
+	Level *slog.Level `json:"level"`
+	// Длительность в формате Go, например 15m
+	TTL string `json:"ttl"`
+}
+
+// New временно понижает уровень логирования для маршрута или клиента.
+// Переопределение снимается автоматически по истечении ttl.
+func New(log *slog.Logger, overrides OverrideAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.override.add.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var req Req
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid ttl", sl.Err(err))
+
+			server.BadRequest("invalid ttl", err, w, r)
+
+			return
+		}
+
+		level := slog.LevelDebug
+		if req.Level != nil {
+			level = *req.Level
+		}
+
+		ov, err := overrides.Add(loglevel.Override{
+			Route:     req.Route,
+			Principal: req.Principal,
+			Level:     level,
+		}, ttl)
+		if err != nil {
+			if errors.Is(err, loglevel.ErrInvalidOverride) {
+				log.InfoContext(r.Context(), "invalid override", sl.Err(err))
+				server.BadRequest("invalid override", err, w, r)
+				return
+			}
+
+			log.ErrorContext(r.Context(), "error adding override", sl.Err(err))
+
+			server.InternalError("error adding override", err, w, r)
+
+			return
+		}
+
+		log.WarnContext(r.Context(), "log level override added",
+			slog.String("id", ov.ID),
+			slog.String("route", ov.Route),
+			slog.String("principal", ov.Principal),
+			slog.String("level", ov.Level.String()),
+			slog.Time("expires_at", ov.ExpiresAt))
+
+		server.Respond(http.StatusCreated, ov, w, r)
+	}
+}
@@ -0,0 +1,52 @@
+package delete
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/loglevel"
+	"contact-api/internal/pkg/logger/sl"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+type OverrideRemover interface {
+	Remove(id string) error
+}
+
+type Resp struct {
+	OK  bool   `json:"ok"`
+	MSG string `json:"msg"`
+}
+
+// New снимает переопределение уровня логирования до истечения срока.
+func New(log *slog.Logger, overrides OverrideRemover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.override.delete.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		id := chi.URLParam(r, "id")
+
+		if err := overrides.Remove(id); err != nil {
+			if errors.Is(err, loglevel.ErrOverrideNotFound) {
+				log.InfoContext(r.Context(), "override not found", slog.String("id", id))
+				server.NotFound("override not found", err, w, r)
+				return
+			}
+
+			log.ErrorContext(r.Context(), "error removing override", sl.Err(err))
+
+			server.InternalError("error removing override", err, w, r)
+
+			return
+		}
+
+		log.WarnContext(r.Context(), "log level override removed", slog.String("id", id))
+
+		server.RespondOK(Resp{
+			OK:  true,
+			MSG: "successful delete override with id: " + id,
+		}, w, r)
+	}
+}
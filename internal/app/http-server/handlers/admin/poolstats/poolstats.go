@@ -0,0 +1,22 @@
+package poolstats
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage/mongo"
+	"net/http"
+)
+
+type PoolStatter interface {
+	PoolStats() []mongo.PoolStats
+}
+
+type Resp struct {
+	Pools []mongo.PoolStats `json:"pools"`
+}
+
+// New возвращает состояние пулов соединений MongoDB по каждому серверу.
+func New(storage PoolStatter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		server.RespondOK(Resp{Pools: storage.PoolStats()}, w, r)
+	}
+}
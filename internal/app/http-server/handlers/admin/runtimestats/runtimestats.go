@@ -0,0 +1,69 @@
+package runtimestats
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+type Resp struct {
+	Uptime     string `json:"uptime"`
+	Goroutines int    `json:"goroutines"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	Memory     Memory `json:"memory"`
+	GC         GC     `json:"gc"`
+}
+
+type Memory struct {
+	Alloc        uint64 `json:"alloc_bytes"`
+	TotalAlloc   uint64 `json:"total_alloc_bytes"`
+	Sys          uint64 `json:"sys_bytes"`
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapInuse    uint64 `json:"heap_inuse_bytes"`
+	HeapIdle     uint64 `json:"heap_idle_bytes"`
+	HeapReleased uint64 `json:"heap_released_bytes"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse_bytes"`
+}
+
+type GC struct {
+	NumGC      uint32    `json:"num_gc"`
+	PauseTotal string    `json:"pause_total"`
+	LastGC     time.Time `json:"last_gc"`
+	NextGC     uint64    `json:"next_gc_bytes"`
+}
+
+// New возвращает число горутин и статистику памяти процесса.
+// runtime.ReadMemStats ненадолго останавливает мир, поэтому эндпоинт не предназначен для частого опроса.
+func New(started time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+
+		server.RespondOK(Resp{
+			Uptime:     time.Since(started).Round(time.Second).String(),
+			Goroutines: runtime.NumGoroutine(),
+			NumCPU:     runtime.NumCPU(),
+			GOMAXPROCS: runtime.GOMAXPROCS(0),
+			Memory: Memory{
+				Alloc:        ms.Alloc,
+				TotalAlloc:   ms.TotalAlloc,
+				Sys:          ms.Sys,
+				HeapAlloc:    ms.HeapAlloc,
+				HeapInuse:    ms.HeapInuse,
+				HeapIdle:     ms.HeapIdle,
+				HeapReleased: ms.HeapReleased,
+				HeapObjects:  ms.HeapObjects,
+				StackInuse:   ms.StackInuse,
+			},
+			GC: GC{
+				NumGC:      ms.NumGC,
+				PauseTotal: time.Duration(ms.PauseTotalNs).String(),
+				LastGC:     time.Unix(0, int64(ms.LastGC)).UTC(),
+				NextGC:     ms.NextGC,
+			},
+		}, w, r)
+	}
+}
@@ -3,34 +3,48 @@ package deleteAll
 import (
 	"contact-api/internal/app/http-server/common/server"
 	"contact-api/internal/pkg/logger/sl"
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 )
 
 type ContactsDeleter interface {
-	DeleteAll() (int64, error)
+	DeleteAll(ctx context.Context) (int64, error)
+}
+
+type Resp struct {
+	OK      bool   `json:"ok"`
+	Deleted int64  `json:"deleted"`
+	MSG     string `json:"msg"`
 }
 
 func New(log *slog.Logger, deleter ContactsDeleter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.all.delete.New"
-		log = log.With(
-			slog.String("op: ", op))
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
 
-		count, err := deleter.DeleteAll()
+		count, err := deleter.DeleteAll(r.Context())
 		if err != nil {
-			log.Info("error deleting all contacts: ", sl.Err(err))
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "deleting all contacts interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error deleting all contacts: ", sl.Err(err))
 
 			server.InternalError("error deleting records", err, w, r)
 
 			return
 		}
 
-		resp := fmt.Sprintf("deleting %d records complete successfully", count)
-
-		log.Info("deleting records complete successfully")
+		log.InfoContext(r.Context(), "deleting records complete successfully", slog.Int64("count", count))
 
-		server.RespondOK(resp, w, r)
+		server.RespondOK(Resp{
+			OK:      true,
+			Deleted: count,
+			MSG:     fmt.Sprintf("deleting %d records complete successfully", count),
+		}, w, r)
 	}
 }
@@ -3,41 +3,231 @@ package getAll
 import (
 	"contact-api/internal/app/domain/models"
 	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
 	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 )
 
+var ErrInvalidFilter = errors.New("invalid custom field filter")
+
 type ContactsAll interface {
-	GetAll() ([]models.Contact, error)
-}
-
-// New создает обработчик HTTP для получения всех контактов
-// @Summary Получить все контакты
-// @Description Возвращает список всех контактов в формате JSON
-// @Tags contacts
-// @Accept json
-// @Produce json
-// @Success 200 {array} models.Contact "Успешно получен список контактов"
-// @Failure 500 {object} server.ErrorResponse "Ошибка сервера"
-// @Router /v1/contact [get]
+	GetAll(ctx context.Context, opts storage.ListOptions) ([]models.Contact, string, error)
+	FieldDefinitions(ctx context.Context) ([]models.FieldDefinition, error)
+	Group(ctx context.Context, id string) (models.Group, error)
+}
+
+// New создает обработчик HTTP для получения всех контактов.
+// С параметром limit отдаёт одну страницу, а ссылку на следующую - в заголовке Link с rel="next".
+// Параметры field[имя] и field[имя][операция] отбирают контакты по пользовательским полям,
+// sort=field.имя или sort=-field.имя сортирует по ним. tag (можно несколько) и group
+// оставляют контакты со всеми указанными метками и из указанной группы.
 func New(log *slog.Logger, getAller ContactsAll) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.all.get.New"
-		log = log.With(
-			slog.String("op:", op))
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		opts, err := server.PageOptions(r)
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid pagination parameters", sl.Err(err))
+
+			server.BadRequest("invalid pagination parameters", err, w, r)
+
+			return
+		}
+
+		query := r.URL.Query()
+
+		opts.Tags, err = models.NormalizeTags(query["tag"])
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid tag filter", sl.Err(err))
+
+			server.BadRequest("invalid tag filter", err, w, r)
+
+			return
+		}
+
+		// Несуществующая группа - ошибка, а не пустой список, как и в списке участников группы
+		if opts.Group = query.Get("group"); opts.Group != "" {
+			if _, err := getAller.Group(r.Context(), opts.Group); err != nil {
+				if server.ContextError(err, w, r) {
+					log.InfoContext(r.Context(), "reading group interrupted", sl.Err(err))
+					return
+				}
+
+				if errors.Is(err, storage.ErrGroupNotFound) {
+					log.InfoContext(r.Context(), "group not found", slog.String("group", opts.Group))
+					server.NotFound("group not found", err, w, r)
+					return
+				}
+
+				log.InfoContext(r.Context(), "error reading group", sl.Err(err))
+
+				server.InternalError("error reading group", err, w, r)
 
-		contacts, err := getAller.GetAll()
+				return
+			}
+		}
+
+		if fq := parseFieldQuery(r); !fq.empty() {
+			defs, err := getAller.FieldDefinitions(r.Context())
+			if err != nil {
+				if server.ContextError(err, w, r) {
+					log.InfoContext(r.Context(), "reading custom fields interrupted", sl.Err(err))
+					return
+				}
+
+				log.InfoContext(r.Context(), "error reading custom fields", sl.Err(err))
+
+				server.InternalError("error reading custom fields", err, w, r)
+
+				return
+			}
+
+			if err := fq.apply(&opts, defs); err != nil {
+				log.InfoContext(r.Context(), "invalid custom field filter", sl.Err(err))
+
+				server.BadRequest("invalid custom field filter", err, w, r)
+
+				return
+			}
+		}
+
+		contacts, next, err := getAller.GetAll(r.Context(), opts)
 		if err != nil {
-			log.Info("error getting lines", sl.Err(err))
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "getting all contacts interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrInvalidCursor) {
+				log.InfoContext(r.Context(), "invalid cursor", sl.Err(err))
+				server.BadRequest("invalid pagination parameters", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error getting lines", sl.Err(err))
 
 			server.InternalError("error get any record", err, w, r)
 
 			return
 		}
 
-		log.Info("successfully getting all records")
+		if contacts == nil {
+			contacts = []models.Contact{}
+		}
+
+		if next != "" {
+			server.SetNextLink(next, w, r)
+		}
+
+		log.InfoContext(r.Context(), "successfully getting all records", slog.Int("count", len(contacts)))
 
 		server.RespondOK(contacts, w, r)
 	}
 }
+
+// Операции, допустимые в field[имя][операция]; без операции - eq
+var filterOps = []string{
+	storage.FilterEq, storage.FilterNe,
+	storage.FilterGt, storage.FilterGte,
+	storage.FilterLt, storage.FilterLte,
+}
+
+var filterKeyRe = regexp.MustCompile(`^field\[([^\]]+)\](?:\[([a-z]+)\])?$`)
+
+// fieldQuery - условия и сортировка по пользовательским полям до проверки по их описаниям
+type fieldQuery struct {
+	filters []rawFilter
+	sort    string
+}
+
+type rawFilter struct {
+	param, field, op, value string
+}
+
+func parseFieldQuery(r *http.Request) fieldQuery {
+	var fq fieldQuery
+
+	query := r.URL.Query()
+
+	for key, values := range query {
+		m := filterKeyRe.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+
+		op := m[2]
+		if op == "" {
+			op = storage.FilterEq
+		}
+
+		for _, v := range values {
+			fq.filters = append(fq.filters, rawFilter{param: key, field: m[1], op: op, value: v})
+		}
+	}
+
+	// Порядок параметров в map случаен, а от него зависит текст ошибки
+	slices.SortFunc(fq.filters, func(a, b rawFilter) int { return strings.Compare(a.param, b.param) })
+
+	fq.sort = query.Get("sort")
+
+	return fq
+}
+
+func (fq fieldQuery) empty() bool {
+	return len(fq.filters) == 0 && fq.sort == ""
+}
+
+// apply проверяет поля и значения по описаниям и переносит их в opts.
+func (fq fieldQuery) apply(opts *storage.ListOptions, defs []models.FieldDefinition) error {
+	lookup := func(name string) (models.FieldDefinition, bool) {
+		i := slices.IndexFunc(defs, func(d models.FieldDefinition) bool { return d.Name == name })
+		if i < 0 {
+			return models.FieldDefinition{}, false
+		}
+		return defs[i], true
+	}
+
+	for _, f := range fq.filters {
+		def, ok := lookup(f.field)
+		if !ok {
+			return fmt.Errorf("%w: %s: unknown field", ErrInvalidFilter, f.param)
+		}
+
+		if !slices.Contains(filterOps, f.op) {
+			return fmt.Errorf("%w: %s: unknown operation, expected one of %s", ErrInvalidFilter, f.param, strings.Join(filterOps, ", "))
+		}
+
+		value, err := def.ParseValue(f.value)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidFilter, f.param, err)
+		}
+
+		opts.Filters = append(opts.Filters, storage.FieldFilter{Field: def.Name, Op: f.op, Value: value})
+	}
+
+	if fq.sort != "" {
+		name, desc := strings.CutPrefix(fq.sort, "-")
+
+		name, ok := strings.CutPrefix(name, "field.")
+		if !ok {
+			return fmt.Errorf("%w: sort: expected field.<name> or -field.<name>", ErrInvalidFilter)
+		}
+
+		if _, ok := lookup(name); !ok {
+			return fmt.Errorf("%w: sort: unknown field %q", ErrInvalidFilter, name)
+		}
+
+		opts.SortField, opts.SortDesc = name, desc
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package getAll
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var testDefs = []models.FieldDefinition{
+	{Name: "score", Type: models.FieldNumber},
+	{Name: "since", Type: models.FieldDate},
+	{Name: "tier", Type: models.FieldEnum, Values: []string{"gold", "silver"}},
+}
+
+type fakeLister struct {
+	opts *storage.ListOptions
+}
+
+func (f *fakeLister) GetAll(_ context.Context, opts storage.ListOptions) ([]models.Contact, string, error) {
+	f.opts = &opts
+	return nil, "", nil
+}
+
+func (f *fakeLister) FieldDefinitions(context.Context) ([]models.FieldDefinition, error) {
+	return testDefs, nil
+}
+
+func (f *fakeLister) Group(_ context.Context, id string) (models.Group, error) {
+	return models.Group{ID: id}, nil
+}
+
+func TestFieldQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		status  int
+		filters []storage.FieldFilter
+		sort    string
+		desc    bool
+	}{
+		{
+			name:    "eq and range",
+			query:   url.Values{"field[tier]": {"gold"}, "field[score][gte]": {"4.5"}},
+			status:  http.StatusOK,
+			filters: []storage.FieldFilter{{Field: "score", Op: "gte", Value: 4.5}, {Field: "tier", Op: "eq", Value: "gold"}},
+		},
+		{
+			name:   "sort desc",
+			query:  url.Values{"sort": {"-field.since"}},
+			status: http.StatusOK,
+			sort:   "since",
+			desc:   true,
+		},
+		{name: "unknown filter field", query: url.Values{"field[crm_id]": {"1"}}, status: http.StatusBadRequest},
+		{name: "unknown operation", query: url.Values{"field[score][like]": {"1"}}, status: http.StatusBadRequest},
+		{name: "number mismatch", query: url.Values{"field[score]": {"high"}}, status: http.StatusBadRequest},
+		{name: "date mismatch", query: url.Values{"field[since][lt]": {"01.02.2024"}}, status: http.StatusBadRequest},
+		{name: "enum mismatch", query: url.Values{"field[tier]": {"bronze"}}, status: http.StatusBadRequest},
+		{name: "unknown sort field", query: url.Values{"sort": {"field.crm_id"}}, status: http.StatusBadRequest},
+		{name: "sort without prefix", query: url.Values{"sort": {"score"}}, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := &fakeLister{}
+
+			w := httptest.NewRecorder()
+			New(slog.New(slog.NewTextHandler(io.Discard, nil)), lister).
+				ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/contact?"+tt.query.Encode(), nil))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.status != http.StatusOK {
+				if lister.opts != nil {
+					t.Error("storage called for invalid query")
+				}
+
+				var resp struct {
+					Slug string `json:"slug"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Slug != "invalid custom field filter" {
+					t.Errorf("slug %q, %v", resp.Slug, err)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(lister.opts.Filters, tt.filters) {
+				t.Errorf("filters %+v, want %+v", lister.opts.Filters, tt.filters)
+			}
+			if lister.opts.SortField != tt.sort || lister.opts.SortDesc != tt.desc {
+				t.Errorf("sort %q desc %v", lister.opts.SortField, lister.opts.SortDesc)
+			}
+		})
+	}
+}
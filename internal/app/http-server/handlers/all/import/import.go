@@ -0,0 +1,168 @@
+package importAll
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+var ErrInvalidBatch = errors.New("invalid import batch")
+
+type ContactsImporter interface {
+	SaveMany(ctx context.Context, contacts []models.Contact) ([]storage.SaveResult, error)
+	FieldDefinitions(ctx context.Context) ([]models.FieldDefinition, error)
+}
+
+// Result - итог одного контакта из пакета: идентификатор созданного или причина отказа.
+type Result struct {
+	// Позиция контакта в теле запроса
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	// Код ответа, который получил бы контакт, созданный отдельным запросом
+	Status     int                `json:"status,omitempty"`
+	Slug       string             `json:"slug,omitempty"`
+	Violations []server.Violation `json:"violations,omitempty"`
+}
+
+type Resp struct {
+	Created int      `json:"created"`
+	Failed  int      `json:"failed"`
+	Results []Result `json:"results"`
+}
+
+// New создаёт контакты пачкой. Каждый контакт проверяется отдельно: отклонённые не мешают
+// сохранить остальные, а причина отказа возвращается в результатах под их позицией.
+func New(log *slog.Logger, importer ContactsImporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.all.import.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var batch []json.RawMessage
+
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		if len(batch) == 0 || len(batch) > storage.MaxBatchSize {
+			err := fmt.Errorf("%w: from 1 to %d contacts per request", ErrInvalidBatch, storage.MaxBatchSize)
+
+			log.InfoContext(r.Context(), "invalid import batch", sl.Err(err))
+
+			server.BadRequest("invalid import batch", err, w, r)
+
+			return
+		}
+
+		defs, err := importer.FieldDefinitions(r.Context())
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "reading custom fields interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error reading custom fields", sl.Err(err))
+
+			server.InternalError("error reading custom fields", err, w, r)
+
+			return
+		}
+
+		resp := Resp{Results: make([]Result, len(batch))}
+
+		var (
+			valid     []models.Contact
+			positions []int
+		)
+
+		for i, data := range batch {
+			contact, rejected := check(data, defs)
+			if rejected != nil {
+				rejected.Index = i
+				resp.Results[i] = *rejected
+				resp.Failed++
+				continue
+			}
+
+			resp.Results[i].Index = i
+
+			valid = append(valid, contact)
+			positions = append(positions, i)
+		}
+
+		if len(valid) > 0 {
+			saved, err := importer.SaveMany(r.Context(), valid)
+			if err != nil {
+				if server.ContextError(err, w, r) {
+					log.InfoContext(r.Context(), "importing contacts interrupted", sl.Err(err))
+					return
+				}
+
+				log.InfoContext(r.Context(), "error importing contacts", sl.Err(err))
+
+				server.InternalError("error importing contacts", err, w, r)
+
+				return
+			}
+
+			for j, res := range saved {
+				i := positions[j]
+
+				if res.Err != nil {
+					log.InfoContext(r.Context(), "error saving contact", slog.Int("index", i), sl.Err(res.Err))
+
+					resp.Results[i].Status = http.StatusInternalServerError
+					resp.Results[i].Slug = "error saving contact"
+					resp.Failed++
+					continue
+				}
+
+				resp.Results[i].ID = res.ID
+				resp.Created++
+			}
+		}
+
+		log.InfoContext(r.Context(), "imported contacts",
+			slog.Int("created", resp.Created), slog.Int("failed", resp.Failed))
+
+		server.RespondOK(resp, w, r)
+	}
+}
+
+// check разбирает и проверяет один контакт так же, как POST /v1/contact.
+// Для отклонённого контакта возвращает результат с причиной отказа.
+func check(data json.RawMessage, defs []models.FieldDefinition) (models.Contact, *Result) {
+	var contact models.Contact
+
+	if err := json.Unmarshal(data, &contact); err != nil {
+		return contact, &Result{Status: http.StatusBadRequest, Slug: "error parsing contact"}
+	}
+
+	// Устаревшие email и telephone переносятся в списки
+	contact.Normalize()
+
+	if err := contact.Validate(); err != nil {
+		return contact, &Result{Status: http.StatusBadRequest, Slug: "invalid contact", Violations: server.ContactViolations(err)}
+	}
+
+	if err := contact.ValidateTags(); err != nil {
+		return contact, &Result{Status: http.StatusBadRequest, Slug: "invalid tags"}
+	}
+
+	if err := contact.ValidateFields(defs); err != nil {
+		return contact, &Result{Status: http.StatusBadRequest, Slug: "invalid custom fields", Violations: server.FieldViolations(err)}
+	}
+
+	return contact, nil
+}
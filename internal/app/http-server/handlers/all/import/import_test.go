@@ -0,0 +1,172 @@
+package importAll
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeImporter сохраняет контакты, кроме пользователей из failNames, которые получают ошибку записи.
+type fakeImporter struct {
+	saved     []models.Contact
+	failNames []string
+	err       error
+}
+
+func (f *fakeImporter) SaveMany(_ context.Context, contacts []models.Contact) ([]storage.SaveResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	results := make([]storage.SaveResult, len(contacts))
+	for i, contact := range contacts {
+		if slices.Contains(f.failNames, contact.UserName) {
+			results[i].Err = errors.New("document failed validation")
+			continue
+		}
+		results[i].ID = fmt.Sprintf("%024x", len(f.saved)+1)
+		f.saved = append(f.saved, contact)
+	}
+	return results, nil
+}
+
+func (f *fakeImporter) FieldDefinitions(context.Context) ([]models.FieldDefinition, error) {
+	return []models.FieldDefinition{{Name: "score", Type: models.FieldNumber}}, nil
+}
+
+func serve(importer ContactsImporter, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Post("/v1/contact/import", New(slog.New(slog.NewTextHandler(io.Discard, nil)), importer))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/contact/import", strings.NewReader(body)))
+	return w
+}
+
+func TestImport(t *testing.T) {
+	tooMany := "[" + strings.TrimSuffix(strings.Repeat(`{"username":"a"},`, storage.MaxBatchSize+1), ",") + "]"
+
+	tests := []struct {
+		name      string
+		body      string
+		failNames []string
+		err       error
+		status    int
+		// Статус каждого результата, 0 - контакт сохранён
+		results []int
+		// Имена сохранённых контактов
+		saved []string
+	}{
+		{
+			name:    "all valid",
+			body:    `[{"username":"a"},{"_id":"ignored","username":"b","email":"b@example.com"}]`,
+			status:  http.StatusOK,
+			results: []int{0, 0},
+			saved:   []string{"a", "b"},
+		},
+		{
+			name: "invalid contacts are skipped",
+			body: `[{"username":"a"},{"username":42},{"username":"c","birthday":"1990-13-01"},` +
+				`{"username":"d","tags":["a,b"]},{"username":"e","fields":{"score":"high"}},{"username":"f"}]`,
+			status:  http.StatusOK,
+			results: []int{0, 400, 400, 400, 400, 0},
+			saved:   []string{"a", "f"},
+		},
+		{
+			name:    "nothing valid",
+			body:    `[{"username":"a","fields":{"crm_id":"1"}}]`,
+			status:  http.StatusOK,
+			results: []int{400},
+		},
+		{
+			name:      "write error of one contact",
+			body:      `[{"username":"a"},{"username":"b"}]`,
+			failNames: []string{"a"},
+			status:    http.StatusOK,
+			results:   []int{500, 0},
+			saved:     []string{"b"},
+		},
+		{name: "not an array", body: `{"username":"a"}`, status: http.StatusBadRequest},
+		{name: "empty", body: `[]`, status: http.StatusBadRequest},
+		{name: "too many", body: tooMany, status: http.StatusBadRequest},
+		{name: "canceled", body: `[{"username":"a"}]`, err: context.Canceled, status: server.StatusClientClosedRequest},
+		{name: "storage error", body: `[{"username":"a"}]`, err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := &fakeImporter{failNames: tt.failNames, err: tt.err}
+
+			w := serve(importer, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				if len(importer.saved) != 0 {
+					t.Errorf("%d contacts saved by a failed request", len(importer.saved))
+				}
+				return
+			}
+
+			var resp Resp
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			var statuses []int
+			for i, res := range resp.Results {
+				if res.Index != i {
+					t.Errorf("result %d has index %d", i, res.Index)
+				}
+				if (res.Status == 0) != (res.ID != "") {
+					t.Errorf("result %d: status %d with id %q", i, res.Status, res.ID)
+				}
+				statuses = append(statuses, res.Status)
+			}
+			if !slices.Equal(statuses, tt.results) {
+				t.Errorf("result statuses %v, want %v", statuses, tt.results)
+			}
+
+			var names []string
+			for _, c := range importer.saved {
+				names = append(names, c.UserName)
+			}
+			if !slices.Equal(names, tt.saved) {
+				t.Errorf("saved %v, want %v", names, tt.saved)
+			}
+			if resp.Created != len(tt.saved) || resp.Failed != len(tt.results)-len(tt.saved) {
+				t.Errorf("created %d, failed %d", resp.Created, resp.Failed)
+			}
+		})
+	}
+}
+
+// Нарушения контакта относятся к его полям, позиция берётся из index результата.
+func TestImportViolations(t *testing.T) {
+	w := serve(&fakeImporter{}, `[{"username":"a"},{"username":"b","fields":{"score":"high"}}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var resp Resp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	res := resp.Results[1]
+	if res.Slug != "invalid custom fields" || len(res.Violations) != 1 || res.Violations[0].Field != "fields.score" {
+		t.Errorf("result = %+v", res)
+	}
+}
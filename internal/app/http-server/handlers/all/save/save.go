@@ -4,13 +4,15 @@ import (
 	"contact-api/internal/app/domain/models"
 	"contact-api/internal/app/http-server/common/server"
 	"contact-api/internal/pkg/logger/sl"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 )
 
 type ContactSaver interface {
-	Save(contact models.Contact) (string, error)
+	Save(ctx context.Context, contact models.Contact) (string, error)
+	FieldDefinitions(ctx context.Context) ([]models.FieldDefinition, error)
 }
 
 type RespOK struct {
@@ -21,31 +23,76 @@ type RespOK struct {
 func New(log *slog.Logger, saver ContactSaver) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.all.save.New"
-		log = log.With(
-			slog.String("op: ", op))
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
 
 		contact := models.Contact{}
 
 		err := json.NewDecoder(r.Body).Decode(&contact)
 		if err != nil {
-			log.Info("error reading json", sl.Err(err))
+			log.InfoContext(r.Context(), "error reading json", sl.Err(err))
 
 			server.BadRequest("request error", err, w, r)
 
 			return
 		}
 
-		id, err := saver.Save(contact)
+		// Устаревшие email и telephone переносятся в списки
+		contact.Normalize()
+
+		if err := contact.Validate(); err != nil {
+			log.InfoContext(r.Context(), "invalid contact", sl.Err(err))
+
+			server.InvalidRequest("invalid contact", server.ContactViolations(err), err, w, r)
+
+			return
+		}
+
+		if err := contact.ValidateTags(); err != nil {
+			log.InfoContext(r.Context(), "invalid tags", sl.Err(err))
+
+			server.BadRequest("invalid tags", err, w, r)
+
+			return
+		}
+
+		defs, err := saver.FieldDefinitions(r.Context())
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "reading custom fields interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error reading custom fields", sl.Err(err))
+
+			server.InternalError("error reading custom fields", err, w, r)
+
+			return
+		}
+
+		if err := contact.ValidateFields(defs); err != nil {
+			log.InfoContext(r.Context(), "invalid custom fields", sl.Err(err))
+
+			server.InvalidRequest("invalid custom fields", server.FieldViolations(err), err, w, r)
+
+			return
+		}
+
+		id, err := saver.Save(r.Context(), contact)
 		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "saving contact interrupted", sl.Err(err))
+				return
+			}
 
-			log.Info("error saving contact", sl.Err(err))
+			log.InfoContext(r.Context(), "error saving contact", sl.Err(err))
 
 			server.InternalError("error saving contact", err, w, r)
 
 			return
 		}
 
-		log.Info("saved contact successfully")
+		log.InfoContext(r.Context(), "saved contact successfully")
 
 		server.RespondOK(RespOK{
 			ID:  id,
@@ -0,0 +1,58 @@
+package deleteField
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+type FieldDeleter interface {
+	DeleteField(ctx context.Context, name string) error
+}
+
+type Resp struct {
+	OK  bool   `json:"ok"`
+	MSG string `json:"msg"`
+}
+
+// New удаляет описание пользовательского поля вместе с его значениями во всех контактах.
+func New(log *slog.Logger, deleter FieldDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.fields.delete.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		name := chi.URLParam(r, "name")
+
+		if err := deleter.DeleteField(r.Context(), name); err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "deleting custom field interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrFieldNotFound) {
+				log.InfoContext(r.Context(), "custom field not found", slog.String("name", name))
+				server.NotFound("custom field not found", err, w, r)
+				return
+			}
+
+			log.ErrorContext(r.Context(), "error deleting custom field", sl.Err(err))
+
+			server.InternalError("error deleting custom field", err, w, r)
+
+			return
+		}
+
+		log.WarnContext(r.Context(), "custom field deleted", slog.String("name", name))
+
+		server.RespondOK(Resp{
+			OK:  true,
+			MSG: "deleted custom field " + name,
+		}, w, r)
+	}
+}
@@ -0,0 +1,64 @@
+package deleteField
+
+import (
+	"contact-api/internal/app/storage"
+	"context"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeDeleter помнит, у скольких контактов было значение поля, и убирает его вместе с описанием.
+type fakeDeleter struct {
+	inUse map[string]int
+	err   error
+}
+
+func (f *fakeDeleter) DeleteField(_ context.Context, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.inUse[name]; !ok {
+		return storage.ErrFieldNotFound
+	}
+	delete(f.inUse, name)
+	return nil
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  string
+		err    error
+		status int
+	}{
+		{name: "unused", field: "notes", status: http.StatusOK},
+		// Поле со значениями в контактах удаляется вместе со значениями, а не отклоняется
+		{name: "in use", field: "crm_id", status: http.StatusOK},
+		{name: "not found", field: "missing", status: http.StatusBadRequest},
+		{name: "canceled", field: "crm_id", err: context.Canceled, status: 499},
+		{name: "storage error", field: "crm_id", err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleter := &fakeDeleter{inUse: map[string]int{"notes": 0, "crm_id": 12}, err: tt.err}
+
+			router := chi.NewRouter()
+			router.Delete("/v1/field/{name}", New(slog.New(slog.NewTextHandler(io.Discard, nil)), deleter))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/field/"+tt.field, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if _, ok := deleter.inUse[tt.field]; tt.status == http.StatusOK && ok {
+				t.Errorf("field %q was not deleted", tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package listFields
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+type FieldLister interface {
+	FieldDefinitions(ctx context.Context) ([]models.FieldDefinition, error)
+}
+
+// New отдаёт описания пользовательских полей контактов. Доступно всем клиентам:
+// без описаний нельзя заполнить поля и построить фильтр.
+func New(log *slog.Logger, lister FieldLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.fields.list.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		defs, err := lister.FieldDefinitions(r.Context())
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "listing custom fields interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error listing custom fields", sl.Err(err))
+
+			server.InternalError("error listing custom fields", err, w, r)
+
+			return
+		}
+
+		if defs == nil {
+			defs = []models.FieldDefinition{}
+		}
+
+		server.RespondOK(defs, w, r)
+	}
+}
@@ -0,0 +1,80 @@
+package putField
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+type FieldPutter interface {
+	PutField(ctx context.Context, def models.FieldDefinition) (bool, error)
+}
+
+// New создаёт или заменяет описание пользовательского поля; имя берётся из пути.
+// Отвечает 201 для нового поля и 200 для изменённого.
+func New(log *slog.Logger, putter FieldPutter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.fields.put.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var def models.FieldDefinition
+
+		if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		def.Name = chi.URLParam(r, "name")
+
+		if err := def.Validate(); err != nil {
+			log.InfoContext(r.Context(), "invalid custom field", sl.Err(err))
+
+			server.BadRequest("invalid custom field", err, w, r)
+
+			return
+		}
+
+		created, err := putter.PutField(r.Context(), def)
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "saving custom field interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrFieldTypeChanged) {
+				log.InfoContext(r.Context(), "custom field type change rejected", slog.String("name", def.Name))
+				server.Conflict("custom field type cannot be changed, delete the field first", err, w, r)
+				return
+			}
+
+			log.ErrorContext(r.Context(), "error saving custom field", sl.Err(err))
+
+			server.InternalError("error saving custom field", err, w, r)
+
+			return
+		}
+
+		log.WarnContext(r.Context(), "custom field saved",
+			slog.String("name", def.Name),
+			slog.String("type", def.Type),
+			slog.Bool("created", created))
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+
+		server.Respond(status, def, w, r)
+	}
+}
@@ -0,0 +1,74 @@
+package putField
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakePutter хранит типы полей и, как и хранилище, не даёт менять тип существующего поля.
+type fakePutter struct {
+	types map[string]string
+	err   error
+}
+
+func (f *fakePutter) PutField(_ context.Context, def models.FieldDefinition) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+
+	typ, ok := f.types[def.Name]
+	if ok && typ != def.Type {
+		return false, storage.ErrFieldTypeChanged
+	}
+	f.types[def.Name] = def.Type
+	return !ok, nil
+}
+
+func TestPut(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  string
+		body   string
+		err    error
+		status int
+	}{
+		{name: "created", field: "crm_id", body: `{"type":"string"}`, status: http.StatusCreated},
+		{name: "updated", field: "tier", body: `{"type":"enum","values":["gold","silver"],"required":true}`, status: http.StatusOK},
+		{name: "type changed", field: "tier", body: `{"type":"string"}`, status: http.StatusConflict},
+		{name: "malformed json", field: "crm_id", body: `{"type":`, status: http.StatusBadRequest},
+		{name: "wrong json type", field: "crm_id", body: `{"type":"string","required":"yes"}`, status: http.StatusBadRequest},
+		{name: "unknown type", field: "crm_id", body: `{"type":"bool"}`, status: http.StatusBadRequest},
+		{name: "bad name", field: "Crm.ID", body: `{"type":"string"}`, status: http.StatusBadRequest},
+		{name: "enum without values", field: "tier", body: `{"type":"enum"}`, status: http.StatusBadRequest},
+		{name: "values for non-enum", field: "crm_id", body: `{"type":"string","values":["a"]}`, status: http.StatusBadRequest},
+		{name: "storage error", field: "crm_id", body: `{"type":"string"}`, err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			putter := &fakePutter{types: map[string]string{"tier": models.FieldEnum}, err: tt.err}
+
+			router := chi.NewRouter()
+			router.Put("/v1/field/{name}", New(slog.New(slog.NewTextHandler(io.Discard, nil)), putter))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/field/"+tt.field, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			// Имя берётся из пути и возвращается в описании
+			if w.Code < 300 && !strings.Contains(w.Body.String(), `"name":"`+tt.field+`"`) {
+				t.Errorf("body %s has no name %q", w.Body, tt.field)
+			}
+		})
+	}
+}
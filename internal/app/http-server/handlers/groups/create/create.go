@@ -0,0 +1,76 @@
+package createGroup
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+type GroupCreator interface {
+	CreateGroup(ctx context.Context, group models.Group) (string, error)
+}
+
+type RespOK struct {
+	ID  string `json:"id"`
+	MSG string `json:"msg"`
+}
+
+// New создаёт пустую группу; _id и member_count в теле игнорируются.
+func New(log *slog.Logger, creator GroupCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.groups.create.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var group models.Group
+
+		if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		if err := group.Validate(); err != nil {
+			log.InfoContext(r.Context(), "invalid group", sl.Err(err))
+
+			server.BadRequest("invalid group", err, w, r)
+
+			return
+		}
+
+		id, err := creator.CreateGroup(r.Context(), group)
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "creating group interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrGroupExists) {
+				log.InfoContext(r.Context(), "group name is taken", slog.String("name", group.Name))
+				server.Conflict("group with this name already exists", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error creating group", sl.Err(err))
+
+			server.InternalError("error creating group", err, w, r)
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "group created", slog.String("id", id))
+
+		server.RespondOK(RespOK{
+			ID:  id,
+			MSG: "successful create group",
+		}, w, r)
+	}
+}
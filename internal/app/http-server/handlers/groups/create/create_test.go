@@ -0,0 +1,71 @@
+package createGroup
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeCreator struct {
+	created *models.Group
+	err     error
+}
+
+func (f *fakeCreator) CreateGroup(_ context.Context, group models.Group) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.created = &group
+	return "0123456789abcdef01234567", nil
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{name: "ok", body: `{"name":" Family ","color":"#FFAA00"}`, status: http.StatusOK},
+		{name: "malformed json", body: `{"name":`, status: http.StatusBadRequest},
+		{name: "empty name", body: `{"name":"  "}`, status: http.StatusBadRequest},
+		{name: "bad color", body: `{"name":"Family","color":"red"}`, status: http.StatusBadRequest},
+		{name: "name taken", body: `{"name":"Family"}`, err: storage.ErrGroupExists, status: http.StatusConflict},
+		{name: "timeout", body: `{"name":"Family"}`, err: context.DeadlineExceeded, status: http.StatusGatewayTimeout},
+		{name: "storage error", body: `{"name":"Family"}`, err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creator := &fakeCreator{err: tt.err}
+
+			w := httptest.NewRecorder()
+			New(slog.New(slog.NewTextHandler(io.Discard, nil)), creator).
+				ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/group", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			// В хранилище попадает нормализованная группа
+			if creator.created.Name != "Family" || creator.created.Color != "#ffaa00" {
+				t.Errorf("created %+v", *creator.created)
+			}
+
+			var resp RespOK
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.ID != "0123456789abcdef01234567" {
+				t.Errorf("response %+v, %v", resp, err)
+			}
+		})
+	}
+}
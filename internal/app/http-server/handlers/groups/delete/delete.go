@@ -0,0 +1,62 @@
+package deleteGroup
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+type GroupDeleter interface {
+	DeleteGroup(ctx context.Context, id string) (int64, error)
+}
+
+type Resp struct {
+	OK bool `json:"ok"`
+	// Число контактов, из которых убрана группа
+	Detached int64  `json:"detached"`
+	MSG      string `json:"msg"`
+}
+
+// New удаляет группу и убирает её из всех контактов; сами контакты остаются.
+func New(log *slog.Logger, deleter GroupDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.groups.delete.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		gid := chi.URLParam(r, "gid")
+
+		detached, err := deleter.DeleteGroup(r.Context(), gid)
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "deleting group interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrGroupNotFound) {
+				log.InfoContext(r.Context(), "group not found", slog.String("id", gid))
+				server.NotFound("group not found", err, w, r)
+				return
+			}
+
+			log.ErrorContext(r.Context(), "error deleting group", sl.Err(err))
+
+			server.InternalError("error deleting group", err, w, r)
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "group deleted", slog.String("id", gid), slog.Int64("detached", detached))
+
+		server.RespondOK(Resp{
+			OK:       true,
+			Detached: detached,
+			MSG:      "deleted group " + gid,
+		}, w, r)
+	}
+}
@@ -0,0 +1,65 @@
+package deleteGroup
+
+import (
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeDeleter struct {
+	detached int64
+	err      error
+	id       string
+}
+
+func (f *fakeDeleter) DeleteGroup(_ context.Context, id string) (int64, error) {
+	f.id = id
+	return f.detached, f.err
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "not found", err: storage.ErrGroupNotFound, status: http.StatusBadRequest},
+		{name: "timeout", err: context.DeadlineExceeded, status: http.StatusGatewayTimeout},
+		{name: "storage error", err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleter := &fakeDeleter{detached: 3, err: tt.err}
+
+			router := chi.NewRouter()
+			router.Delete("/v1/group/{gid}", New(slog.New(slog.NewTextHandler(io.Discard, nil)), deleter))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/group/0123456789abcdef01234567", nil))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if deleter.id != "0123456789abcdef01234567" {
+				t.Errorf("deleted %q", deleter.id)
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var resp Resp
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || !resp.OK || resp.Detached != 3 {
+				t.Errorf("response %+v, %v", resp, err)
+			}
+		})
+	}
+}
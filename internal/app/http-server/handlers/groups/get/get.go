@@ -0,0 +1,49 @@
+package getGroup
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+type GroupGetter interface {
+	Group(ctx context.Context, id string) (models.Group, error)
+}
+
+func New(log *slog.Logger, getter GroupGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.groups.get.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		gid := chi.URLParam(r, "gid")
+
+		group, err := getter.Group(r.Context(), gid)
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "reading group interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrGroupNotFound) {
+				log.InfoContext(r.Context(), "group not found", slog.String("id", gid))
+				server.NotFound("group not found", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error reading group", sl.Err(err))
+
+			server.InternalError("error reading group", err, w, r)
+
+			return
+		}
+
+		server.RespondOK(group, w, r)
+	}
+}
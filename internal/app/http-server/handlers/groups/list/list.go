@@ -0,0 +1,43 @@
+package listGroups
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+type GroupLister interface {
+	Groups(ctx context.Context) ([]models.Group, error)
+}
+
+// New отдаёт все группы в порядке имён вместе с числом участников.
+func New(log *slog.Logger, lister GroupLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.groups.list.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		groups, err := lister.Groups(r.Context())
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "listing groups interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error listing groups", sl.Err(err))
+
+			server.InternalError("error listing groups", err, w, r)
+
+			return
+		}
+
+		if groups == nil {
+			groups = []models.Group{}
+		}
+
+		server.RespondOK(groups, w, r)
+	}
+}
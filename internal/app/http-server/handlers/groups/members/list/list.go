@@ -0,0 +1,90 @@
+package groupMembers
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+type MemberLister interface {
+	Group(ctx context.Context, id string) (models.Group, error)
+	GetAll(ctx context.Context, opts storage.ListOptions) ([]models.Contact, string, error)
+}
+
+// New отдаёт контакты группы в порядке идентификаторов. Постраничный вывод - как у списка контактов:
+// limit, cursor и ссылка на следующую страницу в заголовке Link.
+func New(log *slog.Logger, lister MemberLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.groups.members.list.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		gid := chi.URLParam(r, "gid")
+
+		opts, err := server.PageOptions(r)
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid pagination parameters", sl.Err(err))
+
+			server.BadRequest("invalid pagination parameters", err, w, r)
+
+			return
+		}
+		opts.Group = gid
+
+		// Пустая страница не должна скрывать опечатку в идентификаторе группы
+		if _, err := lister.Group(r.Context(), gid); err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "reading group interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrGroupNotFound) {
+				log.InfoContext(r.Context(), "group not found", slog.String("id", gid))
+				server.NotFound("group not found", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error reading group", sl.Err(err))
+
+			server.InternalError("error reading group", err, w, r)
+
+			return
+		}
+
+		contacts, next, err := lister.GetAll(r.Context(), opts)
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "listing group members interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrInvalidCursor) {
+				log.InfoContext(r.Context(), "invalid cursor", sl.Err(err))
+				server.BadRequest("invalid pagination parameters", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error listing group members", sl.Err(err))
+
+			server.InternalError("error listing group members", err, w, r)
+
+			return
+		}
+
+		if contacts == nil {
+			contacts = []models.Contact{}
+		}
+
+		if next != "" {
+			server.SetNextLink(next, w, r)
+		}
+
+		server.RespondOK(contacts, w, r)
+	}
+}
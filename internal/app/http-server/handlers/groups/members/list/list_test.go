@@ -0,0 +1,91 @@
+package groupMembers
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeLister struct {
+	groupErr error
+	contacts []models.Contact
+	next     string
+	err      error
+	opts     *storage.ListOptions
+}
+
+func (f *fakeLister) Group(_ context.Context, id string) (models.Group, error) {
+	return models.Group{ID: id}, f.groupErr
+}
+
+func (f *fakeLister) GetAll(_ context.Context, opts storage.ListOptions) ([]models.Contact, string, error) {
+	f.opts = &opts
+	return f.contacts, f.next, f.err
+}
+
+func TestMembers(t *testing.T) {
+	const gid = "0123456789abcdef01234567"
+
+	tests := []struct {
+		name   string
+		query  string
+		lister fakeLister
+		status int
+		body   string
+		link   string
+	}{
+		{name: "empty", status: http.StatusOK, body: "[]"},
+		{
+			name:   "page",
+			query:  "?limit=1",
+			lister: fakeLister{contacts: []models.Contact{{UserName: "ann"}}, next: "c1"},
+			status: http.StatusOK,
+			body:   `"username":"ann"`,
+			link:   `</v1/group/` + gid + `/members?cursor=c1&limit=1>; rel="next"`,
+		},
+		{name: "bad limit", query: "?limit=0", status: http.StatusBadRequest},
+		{name: "group not found", lister: fakeLister{groupErr: storage.ErrGroupNotFound}, status: http.StatusBadRequest},
+		{name: "group error", lister: fakeLister{groupErr: io.ErrUnexpectedEOF}, status: http.StatusInternalServerError},
+		{name: "invalid cursor", query: "?cursor=x", lister: fakeLister{err: storage.ErrInvalidCursor}, status: http.StatusBadRequest},
+		{name: "canceled", lister: fakeLister{err: context.Canceled}, status: 499},
+		{name: "storage error", lister: fakeLister{err: io.ErrUnexpectedEOF}, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			router.Get("/v1/group/{gid}/members", New(slog.New(slog.NewTextHandler(io.Discard, nil)), &tt.lister))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/group/"+gid+"/members"+tt.query, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.lister.groupErr != nil && tt.lister.opts != nil {
+				t.Error("members listed for a missing group")
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if tt.lister.opts.Group != gid {
+				t.Errorf("listed group %q", tt.lister.opts.Group)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("body %s, want %s inside", w.Body, tt.body)
+			}
+			if link := w.Header().Get("Link"); link != tt.link {
+				t.Errorf("Link %q, want %q", link, tt.link)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package updateMembers
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+var ErrInvalidMembers = errors.New("invalid membership change")
+
+type MemberUpdater interface {
+	UpdateMembers(ctx context.Context, id string, add, remove []string) (models.Group, storage.MembershipChange, error)
+}
+
+type Request struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+type Resp struct {
+	// Контакты, которые уже были в нужном состоянии или не существуют, не учитываются
+	Added       int64 `json:"added"`
+	Removed     int64 `json:"removed"`
+	MemberCount int64 `json:"member_count"`
+}
+
+// New добавляет контакты в группу и убирает из неё пачкой.
+func New(log *slog.Logger, updater MemberUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.groups.members.update.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		gid := chi.URLParam(r, "gid")
+
+		var req Request
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		if err := req.validate(); err != nil {
+			log.InfoContext(r.Context(), "invalid membership change", sl.Err(err))
+
+			server.BadRequest("invalid membership change", err, w, r)
+
+			return
+		}
+
+		group, change, err := updater.UpdateMembers(r.Context(), gid, req.Add, req.Remove)
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "updating group members interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrGroupNotFound) {
+				log.InfoContext(r.Context(), "group not found", slog.String("id", gid))
+				server.NotFound("group not found", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error updating group members", sl.Err(err))
+
+			server.InternalError("error updating group members", err, w, r)
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "group members updated",
+			slog.String("id", gid),
+			slog.Int64("added", change.Added),
+			slog.Int64("removed", change.Removed))
+
+		server.RespondOK(Resp{
+			Added:       change.Added,
+			Removed:     change.Removed,
+			MemberCount: group.MemberCount,
+		}, w, r)
+	}
+}
+
+func (req Request) validate() error {
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		return fmt.Errorf("%w: add or remove is required", ErrInvalidMembers)
+	}
+
+	if len(req.Add)+len(req.Remove) > storage.MaxBatchSize {
+		return fmt.Errorf("%w: at most %d contacts per request", ErrInvalidMembers, storage.MaxBatchSize)
+	}
+
+	for _, id := range req.Add {
+		if slices.Contains(req.Remove, id) {
+			return fmt.Errorf("%w: contact %s is both added and removed", ErrInvalidMembers, id)
+		}
+	}
+
+	return nil
+}
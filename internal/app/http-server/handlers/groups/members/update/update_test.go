@@ -0,0 +1,101 @@
+package updateMembers
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type fakeUpdater struct {
+	add, remove []string
+	called      bool
+	err         error
+}
+
+func (f *fakeUpdater) UpdateMembers(_ context.Context, _ string, add, remove []string) (models.Group, storage.MembershipChange, error) {
+	f.called = true
+	if f.err != nil {
+		return models.Group{}, storage.MembershipChange{}, f.err
+	}
+	f.add, f.remove = add, remove
+	return models.Group{MemberCount: 5}, storage.MembershipChange{Added: int64(len(add)), Removed: int64(len(remove))}, nil
+}
+
+func serve(updater MemberUpdater, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Patch("/v1/group/{gid}/members", New(slog.New(slog.NewTextHandler(io.Discard, nil)), updater))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/v1/group/0123456789abcdef01234567/members", strings.NewReader(body)))
+	return w
+}
+
+// tooMany возвращает тело с числом идентификаторов больше storage.MaxBatchSize.
+func tooMany() string {
+	ids := make([]string, storage.MaxBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%q", fmt.Sprintf("%024x", i))
+	}
+	return `{"add":[` + strings.Join(ids, ",") + `]}`
+}
+
+func TestUpdateMembers(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{name: "ok", body: `{"add":["a1","a2"],"remove":["r1"]}`, status: http.StatusOK},
+		{name: "only remove", body: `{"remove":["r1"]}`, status: http.StatusOK},
+		{name: "malformed json", body: `{"add":`, status: http.StatusBadRequest},
+		{name: "empty", body: `{"add":[],"remove":[]}`, status: http.StatusBadRequest},
+		{name: "added and removed", body: `{"add":["a1"],"remove":["a1"]}`, status: http.StatusBadRequest},
+		{name: "too many", body: tooMany(), status: http.StatusBadRequest},
+		{name: "not found", body: `{"add":["a1"]}`, err: storage.ErrGroupNotFound, status: http.StatusBadRequest},
+		{name: "timeout", body: `{"add":["a1"]}`, err: context.DeadlineExceeded, status: http.StatusGatewayTimeout},
+		{name: "storage error", body: `{"add":["a1"]}`, err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &fakeUpdater{err: tt.err}
+
+			w := serve(updater, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			// Некорректный запрос не доходит до хранилища
+			if tt.err == nil && tt.status != http.StatusOK && updater.called {
+				t.Error("storage called for invalid request")
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var req Request
+			_ = json.Unmarshal([]byte(tt.body), &req)
+			if !slices.Equal(updater.add, req.Add) || !slices.Equal(updater.remove, req.Remove) {
+				t.Errorf("add %v, remove %v", updater.add, updater.remove)
+			}
+
+			var resp Resp
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil ||
+				resp.Added != int64(len(req.Add)) || resp.Removed != int64(len(req.Remove)) || resp.MemberCount != 5 {
+				t.Errorf("response %+v, %v", resp, err)
+			}
+		})
+	}
+}
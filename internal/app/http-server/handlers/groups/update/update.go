@@ -0,0 +1,82 @@
+package updateGroup
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+type GroupUpdater interface {
+	UpdateGroup(ctx context.Context, group models.Group) error
+}
+
+type Resp struct {
+	OK  bool   `json:"ok"`
+	MSG string `json:"msg"`
+}
+
+// New заменяет имя, описание и цвет группы; участники не меняются.
+func New(log *slog.Logger, updater GroupUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.groups.update.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var group models.Group
+
+		if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		group.ID = chi.URLParam(r, "gid")
+
+		if err := group.Validate(); err != nil {
+			log.InfoContext(r.Context(), "invalid group", sl.Err(err))
+
+			server.BadRequest("invalid group", err, w, r)
+
+			return
+		}
+
+		if err := updater.UpdateGroup(r.Context(), group); err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "updating group interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrGroupNotFound) {
+				log.InfoContext(r.Context(), "group not found", slog.String("id", group.ID))
+				server.NotFound("group not found", err, w, r)
+				return
+			}
+
+			if errors.Is(err, storage.ErrGroupExists) {
+				log.InfoContext(r.Context(), "group name is taken", slog.String("name", group.Name))
+				server.Conflict("group with this name already exists", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error updating group", sl.Err(err))
+
+			server.InternalError("error updating group", err, w, r)
+
+			return
+		}
+
+		server.RespondOK(Resp{
+			OK:  true,
+			MSG: "complete updating group with id: " + group.ID,
+		}, w, r)
+	}
+}
@@ -0,0 +1,68 @@
+package updateGroup
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeUpdater struct {
+	updated *models.Group
+	err     error
+}
+
+func (f *fakeUpdater) UpdateGroup(_ context.Context, group models.Group) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.updated = &group
+	return nil
+}
+
+func serve(updater GroupUpdater, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Put("/v1/group/{gid}", New(slog.New(slog.NewTextHandler(io.Discard, nil)), updater))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/group/0123456789abcdef01234567", strings.NewReader(body)))
+	return w
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{name: "ok", body: `{"_id":"ignored","name":"Work","member_count":100}`, status: http.StatusOK},
+		{name: "malformed json", body: `{"name":`, status: http.StatusBadRequest},
+		{name: "invalid group", body: `{"name":""}`, status: http.StatusBadRequest},
+		{name: "not found", body: `{"name":"Work"}`, err: storage.ErrGroupNotFound, status: http.StatusBadRequest},
+		{name: "name taken", body: `{"name":"Work"}`, err: storage.ErrGroupExists, status: http.StatusConflict},
+		{name: "canceled", body: `{"name":"Work"}`, err: context.Canceled, status: 499},
+		{name: "storage error", body: `{"name":"Work"}`, err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &fakeUpdater{err: tt.err}
+
+			w := serve(updater, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.status == http.StatusOK && updater.updated.ID != "0123456789abcdef01234567" {
+				t.Errorf("id from body was not replaced by gid: %q", updater.updated.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package liveness
+
+import (
+	"contact-api/internal/app/health"
+	"contact-api/internal/app/http-server/common/server"
+	"net/http"
+)
+
+type Resp struct {
+	Status string `json:"status"`
+}
+
+// New создаёт обработчик /healthz: процесс жив, если способен ответить на запрос.
+func New() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		server.RespondOK(Resp{Status: health.StatusUp}, w, r)
+	}
+}
@@ -0,0 +1,18 @@
+package liveness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Живость не зависит от внешних сервисов: /healthz отвечает 200, даже пока MongoDB недоступна.
+func TestLiveness(t *testing.T) {
+	w := httptest.NewRecorder()
+	New()(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"up"`) {
+		t.Errorf("status %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,34 @@
+package readiness
+
+import (
+	"contact-api/internal/app/health"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+type Checker interface {
+	Check(ctx context.Context) health.Report
+}
+
+// New создаёт обработчик /readyz с результатами проверки каждой зависимости.
+func New(log *slog.Logger, checker Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.readiness.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		report := checker.Check(r.Context())
+		if report.Status != health.StatusUp {
+			log.InfoContext(r.Context(), "service is not ready", slog.Any("checks", report.Checks))
+
+			server.Respond(http.StatusServiceUnavailable, report, w, r)
+
+			return
+		}
+
+		server.RespondOK(report, w, r)
+	}
+}
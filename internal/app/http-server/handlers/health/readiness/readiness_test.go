@@ -0,0 +1,129 @@
+package readiness
+
+import (
+	"contact-api/internal/app/health"
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStorage повторяет поведение хранилища для проверок готовности.
+type fakeStorage struct {
+	pingErr error
+	ready   atomic.Bool
+}
+
+func (f *fakeStorage) Ping(context.Context) error {
+	return f.pingErr
+}
+
+func (f *fakeStorage) SetupDone(context.Context) error {
+	if !f.ready.Load() {
+		return storage.ErrStorageNotReady
+	}
+	return nil
+}
+
+func newChecker(st *fakeStorage) *health.Checker {
+	checker := health.New(100 * time.Millisecond)
+	checker.Register("mongo", st.Ping)
+	checker.Register("storage_setup", st.SetupDone)
+	return checker
+}
+
+func serve(t *testing.T, checker Checker) (int, health.Report) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	New(slog.New(slog.NewTextHandler(io.Discard, nil)), checker)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode %q: %v", w.Body, err)
+	}
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	t.Run("healthy", func(t *testing.T) {
+		st := &fakeStorage{}
+		st.ready.Store(true)
+
+		code, report := serve(t, newChecker(st))
+		if code != http.StatusOK || report.Status != health.StatusUp {
+			t.Fatalf("status %d, report %+v", code, report)
+		}
+		for _, name := range []string{"mongo", "storage_setup", "draining"} {
+			if report.Checks[name].Status != health.StatusUp {
+				t.Errorf("check %s = %+v", name, report.Checks[name])
+			}
+		}
+	})
+
+	t.Run("failing dependency", func(t *testing.T) {
+		st := &fakeStorage{pingErr: errors.New("server selection timeout")}
+		st.ready.Store(true)
+
+		code, report := serve(t, newChecker(st))
+		if code != http.StatusServiceUnavailable || report.Status != health.StatusDown {
+			t.Fatalf("status %d, report %+v", code, report)
+		}
+		if got := report.Checks["mongo"]; got.Status != health.StatusDown || got.Error != "server selection timeout" {
+			t.Errorf("mongo check = %+v", got)
+		}
+		if got := report.Checks["storage_setup"]; got.Status != health.StatusUp {
+			t.Errorf("storage_setup check = %+v", got)
+		}
+	})
+
+	t.Run("hanging dependency", func(t *testing.T) {
+		checker := health.New(20 * time.Millisecond)
+		checker.Register("mongo", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		if code, report := serve(t, checker); code != http.StatusServiceUnavailable || report.Checks["mongo"].Status != health.StatusDown {
+			t.Errorf("status %d, report %+v", code, report)
+		}
+	})
+
+	// Сервер слушает порт до окончания Setup: пока он идёт, инстанс не должен получать трафик
+	t.Run("setup in progress", func(t *testing.T) {
+		st := &fakeStorage{}
+		checker := newChecker(st)
+
+		for range 2 {
+			code, report := serve(t, checker)
+			if code != http.StatusServiceUnavailable || report.Checks["storage_setup"].Error != storage.ErrStorageNotReady.Error() {
+				t.Fatalf("before setup: status %d, report %+v", code, report)
+			}
+		}
+
+		st.ready.Store(true)
+
+		if code, _ := serve(t, checker); code != http.StatusOK {
+			t.Errorf("after setup: status %d", code)
+		}
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		st := &fakeStorage{}
+		st.ready.Store(true)
+		checker := newChecker(st)
+		checker.SetDraining(true)
+
+		code, report := serve(t, checker)
+		if code != http.StatusServiceUnavailable || report.Checks["draining"].Error != health.ErrDraining.Error() {
+			t.Errorf("status %d, report %+v", code, report)
+		}
+	})
+}
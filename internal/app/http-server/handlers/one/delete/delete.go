@@ -4,6 +4,7 @@ import (
 	"contact-api/internal/app/http-server/common/server"
 	"contact-api/internal/app/storage"
 	"contact-api/internal/pkg/logger/sl"
+	"context"
 	"errors"
 	"github.com/go-chi/chi"
 	"log/slog"
@@ -11,7 +12,7 @@ import (
 )
 
 type DeleterByID interface {
-	Delete(id string) (bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
 }
 
 type Resp struct {
@@ -22,28 +23,33 @@ type Resp struct {
 func New(log *slog.Logger, deleter DeleterByID) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.one.delete.New"
-		log = log.With(
-			slog.String("op: ", op))
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
 
 		uid := chi.URLParam(r, "uid")
 
 		if uid == "" {
-			log.Info("empty id", slog.String("id", uid))
+			log.InfoContext(r.Context(), "empty id", slog.String("id", uid))
 
 			server.BadRequest("uncorrected url, id is empty", nil, w, r)
 
 			return
 		}
 
-		res, err := deleter.Delete(uid)
+		res, err := deleter.Delete(r.Context(), uid)
 		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "deleting contact interrupted", sl.Err(err))
+				return
+			}
+
 			if err != nil && errors.Is(err, storage.ErrContactNotFound) {
-				log.Info("contact not found", slog.String("id", uid))
+				log.InfoContext(r.Context(), "contact not found", slog.String("id", uid))
 				server.BadRequest("contact not found", err, w, r)
 				return
 			}
 
-			log.Info("error deleting item", sl.Err(err))
+			log.InfoContext(r.Context(), "error deleting item", sl.Err(err))
 			server.InternalError("error deleting item", err, w, r)
 			return
 		}
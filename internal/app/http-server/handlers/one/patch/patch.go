@@ -0,0 +1,193 @@
+package patch
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+)
+
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+type Patcher interface {
+	Patch(ctx context.Context, id string, apply func(*models.Contact) error) (models.Contact, error)
+	FieldDefinitions(ctx context.Context) ([]models.FieldDefinition, error)
+}
+
+// New частично обновляет контакт по JSON Merge Patch (RFC 7396) и возвращает результат.
+// Поля, которых нет в теле, остаются как есть; null удаляет значение, списки заменяются целиком.
+func New(log *slog.Logger, patcher Patcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.one.patch.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var patch map[string]any
+
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+			if err == nil {
+				err = fmt.Errorf("%w: must be an object", ErrInvalidPatch)
+			}
+
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		uid := chi.URLParam(r, "uid")
+
+		defs, err := patcher.FieldDefinitions(r.Context())
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "reading custom fields interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error reading custom fields", sl.Err(err))
+
+			server.InternalError("error reading custom fields", err, w, r)
+
+			return
+		}
+
+		// Ошибка проверки изменённого контакта; хранилище возвращает её как есть
+		var rejected error
+
+		contact, err := patcher.Patch(r.Context(), uid, func(contact *models.Contact) error {
+			rejected = apply(contact, patch, defs)
+			return rejected
+		})
+		if err != nil {
+			if rejected != nil {
+				respondRejected(log, rejected, w, r)
+				return
+			}
+
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "patching contact interrupted", sl.Err(err))
+				return
+			}
+
+			if errors.Is(err, storage.ErrContactNotFound) {
+				log.InfoContext(r.Context(), "contact not found", slog.String("id", uid))
+				server.NotFound("contact not found", err, w, r)
+				return
+			}
+
+			if errors.Is(err, storage.ErrContactChanged) {
+				log.InfoContext(r.Context(), "contact changed concurrently", slog.String("id", uid))
+				server.Conflict("contact changed concurrently", err, w, r)
+				return
+			}
+
+			log.InfoContext(r.Context(), "error patching contact", sl.Err(err))
+
+			server.InternalError("error patching contact", err, w, r)
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "patch item complete successfully")
+
+		server.RespondOK(contact, w, r)
+	}
+}
+
+// respondRejected отвечает 400 на контакт, который после изменения не прошёл проверку.
+func respondRejected(log *slog.Logger, err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, ErrInvalidPatch):
+		log.InfoContext(r.Context(), "invalid merge patch", sl.Err(err))
+		server.BadRequest("invalid merge patch", err, w, r)
+
+	case errors.Is(err, models.ErrInvalidTag):
+		log.InfoContext(r.Context(), "invalid tags", sl.Err(err))
+		server.BadRequest("invalid tags", err, w, r)
+
+	case server.FieldViolations(err) != nil:
+		log.InfoContext(r.Context(), "invalid custom fields", sl.Err(err))
+		server.InvalidRequest("invalid custom fields", server.FieldViolations(err), err, w, r)
+
+	default:
+		log.InfoContext(r.Context(), "invalid contact", sl.Err(err))
+		server.InvalidRequest("invalid contact", server.ContactViolations(err), err, w, r)
+	}
+}
+
+// apply накладывает patch на контакт и проверяет результат так же, как PUT. Устаревшие email
+// и telephone заполняются из списков заново; переданные в patch учитываются, только если
+// соответствующий список после изменения пуст. Идентификатор и группы не меняются.
+func apply(contact *models.Contact, patch map[string]any, defs []models.FieldDefinition) error {
+	id, groups := contact.ID, contact.Groups
+	contact.Email, contact.Telephone = "", models.Telephone{}
+
+	data, err := json.Marshal(contact)
+	if err != nil {
+		return err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(mergePatch(doc, patch)); err != nil {
+		return err
+	}
+
+	var patched models.Contact
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	patched.ID, patched.Groups = id, groups
+
+	patched.Normalize()
+
+	if err := patched.Validate(); err != nil {
+		return err
+	}
+
+	if err := patched.ValidateTags(); err != nil {
+		return err
+	}
+
+	if err := patched.ValidateFields(defs); err != nil {
+		return err
+	}
+
+	*contact = patched
+
+	return nil
+}
+
+// mergePatch применяет patch к target по алгоритму MergePatch из RFC 7396.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+
+	return t
+}
@@ -0,0 +1,202 @@
+package patch
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testID = "0123456789abcdef01234567"
+
+// fakePatcher применяет изменение к одному контакту, как хранилище без параллельных запросов.
+type fakePatcher struct {
+	contact models.Contact
+	err     error
+}
+
+func (f *fakePatcher) Patch(_ context.Context, id string, apply func(*models.Contact) error) (models.Contact, error) {
+	if f.err != nil {
+		return models.Contact{}, f.err
+	}
+
+	contact := f.contact
+	contact.Normalize()
+	if err := apply(&contact); err != nil {
+		return models.Contact{}, err
+	}
+	f.contact = contact
+
+	return contact, nil
+}
+
+func (f *fakePatcher) FieldDefinitions(context.Context) ([]models.FieldDefinition, error) {
+	return []models.FieldDefinition{
+		{Name: "score", Type: models.FieldNumber},
+		{Name: "source", Type: models.FieldString, Required: true},
+	}, nil
+}
+
+func serve(patcher Patcher, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Patch("/v1/contact/{uid}", New(slog.New(slog.NewTextHandler(io.Discard, nil)), patcher))
+
+	r := httptest.NewRequest(http.MethodPatch, "/v1/contact/"+testID, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/merge-patch+json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func stored() models.Contact {
+	return models.Contact{
+		ID:       testID,
+		UserName: "ann",
+		Name:     models.Name{Display: "Ann", Given: "Ann", Family: "Lee"},
+		Emails:   []models.Email{{Label: models.LabelWork, Address: "ann@example.com"}},
+		Phones:   []models.Phone{{Label: models.LabelMobile, Number: "+100"}},
+		Title:    "engineer",
+		Fields:   map[string]any{"score": 3.0, "source": "referral"},
+		Tags:     []string{"vip"},
+		Groups:   []string{"66f1c2a9e4b0a1b2c3d4e5f7"},
+	}
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+		// Проверка контакта после изменения
+		check func(t *testing.T, c models.Contact)
+		// Поля нарушений в ответе
+		violations []string
+	}{
+		{
+			name: "scalar fields", body: `{"title":"CTO","organization":"Acme"}`, status: http.StatusOK,
+			check: func(t *testing.T, c models.Contact) {
+				if c.Title != "CTO" || c.Organization != "Acme" || c.UserName != "ann" || len(c.Emails) != 1 {
+					t.Errorf("contact = %+v", c)
+				}
+			},
+		},
+		{
+			name: "null removes nested value", body: `{"name":{"given":null},"title":null}`, status: http.StatusOK,
+			check: func(t *testing.T, c models.Contact) {
+				if c.Name != (models.Name{Display: "Ann", Family: "Lee"}) || c.Title != "" {
+					t.Errorf("contact = %+v", c)
+				}
+			},
+		},
+		{
+			name: "lists are replaced", body: `{"phones":[{"label":"home","number":"+200"}]}`, status: http.StatusOK,
+			check: func(t *testing.T, c models.Contact) {
+				if len(c.Phones) != 1 || c.Telephone.Home != "+200" || c.Telephone.Mobile != "" {
+					t.Errorf("phones = %+v, telephone = %+v", c.Phones, c.Telephone)
+				}
+			},
+		},
+		{
+			name: "legacy email fills an emptied list", body: `{"emails":null,"email":"new@example.com"}`, status: http.StatusOK,
+			check: func(t *testing.T, c models.Contact) {
+				if len(c.Emails) != 1 || c.Emails[0].Address != "new@example.com" || c.Email != "new@example.com" {
+					t.Errorf("emails = %+v, email = %q", c.Emails, c.Email)
+				}
+			},
+		},
+		{
+			name: "legacy email ignored while the list is set", body: `{"email":"new@example.com"}`, status: http.StatusOK,
+			check: func(t *testing.T, c models.Contact) {
+				if c.Email != "ann@example.com" {
+					t.Errorf("email = %q", c.Email)
+				}
+			},
+		},
+		{
+			name: "custom field changed and removed", body: `{"fields":{"score":null,"source":"ads"}}`, status: http.StatusOK,
+			check: func(t *testing.T, c models.Contact) {
+				if _, ok := c.Fields["score"]; ok || c.Fields["source"] != "ads" {
+					t.Errorf("fields = %+v", c.Fields)
+				}
+			},
+		},
+		{
+			name: "id and groups are kept", body: `{"_id":"ignored","groups":[]}`, status: http.StatusOK,
+			check: func(t *testing.T, c models.Contact) {
+				if c.ID != testID || len(c.Groups) != 1 {
+					t.Errorf("id = %q, groups = %v", c.ID, c.Groups)
+				}
+			},
+		},
+		{name: "malformed json", body: `{"title":`, status: http.StatusBadRequest},
+		{name: "not an object", body: `["title"]`, status: http.StatusBadRequest},
+		{name: "null body", body: `null`, status: http.StatusBadRequest},
+		{name: "wrong type", body: `{"username":42}`, status: http.StatusBadRequest},
+		{name: "bad birthday", body: `{"birthday":"1990-13-01"}`, status: http.StatusBadRequest, violations: []string{"birthday"}},
+		{name: "bad tag", body: `{"tags":["a,b"]}`, status: http.StatusBadRequest},
+		{name: "required field removed", body: `{"fields":{"source":null}}`, status: http.StatusBadRequest, violations: []string{"fields.source"}},
+		{name: "field type mismatch", body: `{"fields":{"score":"high"}}`, status: http.StatusBadRequest, violations: []string{"fields.score"}},
+		{name: "not found", body: `{"title":"CTO"}`, err: storage.ErrContactNotFound, status: http.StatusBadRequest},
+		{name: "changed concurrently", body: `{"title":"CTO"}`, err: storage.ErrContactChanged, status: http.StatusConflict},
+		{name: "timeout", body: `{"title":"CTO"}`, err: context.DeadlineExceeded, status: http.StatusGatewayTimeout},
+		{name: "storage error", body: `{"title":"CTO"}`, err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patcher := &fakePatcher{contact: stored(), err: tt.err}
+
+			w := serve(patcher, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.violations != nil {
+				var resp server.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+
+				var fields []string
+				for _, v := range resp.Violations {
+					fields = append(fields, v.Field)
+				}
+				if !slices.Equal(fields, tt.violations) {
+					t.Errorf("violations %+v, want fields %v", resp.Violations, tt.violations)
+				}
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var resp models.Contact
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, resp)
+			tt.check(t, patcher.contact)
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]any{"a": "b", "c": map[string]any{"d": "e", "f": "g"}, "l": []any{1.0}}
+	patch := map[string]any{"a": "z", "c": map[string]any{"f": nil}, "l": []any{2.0}, "n": map[string]any{"x": nil}}
+
+	got, _ := json.Marshal(mergePatch(target, patch))
+	if want := `{"a":"z","c":{"d":"e"},"l":[2],"n":{}}`; string(got) != want {
+		t.Errorf("mergePatch = %s, want %s", got, want)
+	}
+}
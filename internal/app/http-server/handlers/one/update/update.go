@@ -5,6 +5,7 @@ import (
 	"contact-api/internal/app/http-server/common/server"
 	"contact-api/internal/app/storage"
 	"contact-api/internal/pkg/logger/sl"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/go-chi/chi"
@@ -13,7 +14,8 @@ import (
 )
 
 type Updater interface {
-	Update(contact models.Contact) (bool, error)
+	Update(ctx context.Context, contact models.Contact) (bool, error)
+	FieldDefinitions(ctx context.Context) ([]models.FieldDefinition, error)
 }
 
 type Resp struct {
@@ -24,16 +26,35 @@ type Resp struct {
 func New(log *slog.Logger, updater Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.one.update.New"
-		log = log.With(
-			slog.String("op: ", op))
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
 
 		var contact = models.Contact{}
 
 		err := json.NewDecoder(r.Body).Decode(&contact)
 		if err != nil {
-			log.Info("error parsing request body", sl.Err(err))
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
 
-			server.InternalError("error parsing request body", err, w, r)
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		// Устаревшие email и telephone переносятся в списки
+		contact.Normalize()
+
+		if err := contact.Validate(); err != nil {
+			log.InfoContext(r.Context(), "invalid contact", sl.Err(err))
+
+			server.InvalidRequest("invalid contact", server.ContactViolations(err), err, w, r)
+
+			return
+		}
+
+		if err := contact.ValidateTags(); err != nil {
+			log.InfoContext(r.Context(), "invalid tags", sl.Err(err))
+
+			server.BadRequest("invalid tags", err, w, r)
 
 			return
 		}
@@ -42,24 +63,51 @@ func New(log *slog.Logger, updater Updater) http.HandlerFunc {
 
 		contact.ID = uid
 
-		log.Info("request body parsing complete successfully")
+		log.InfoContext(r.Context(), "request body parsing complete successfully")
+
+		defs, err := updater.FieldDefinitions(r.Context())
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "reading custom fields interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error reading custom fields", sl.Err(err))
+
+			server.InternalError("error reading custom fields", err, w, r)
+
+			return
+		}
+
+		if err := contact.ValidateFields(defs); err != nil {
+			log.InfoContext(r.Context(), "invalid custom fields", sl.Err(err))
 
-		res, err := updater.Update(contact)
+			server.InvalidRequest("invalid custom fields", server.FieldViolations(err), err, w, r)
+
+			return
+		}
+
+		res, err := updater.Update(r.Context(), contact)
 		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "updating contact interrupted", sl.Err(err))
+				return
+			}
+
 			if err != nil && errors.Is(err, storage.ErrContactNotFound) {
-				log.Info("contact not found", slog.String("id", uid))
+				log.InfoContext(r.Context(), "contact not found", slog.String("id", uid))
 				server.BadRequest("contact not found", err, w, r)
 				return
 			}
 
-			log.Info("error updating contact", sl.Err(err))
+			log.InfoContext(r.Context(), "error updating contact", sl.Err(err))
 
 			server.InternalError("error updating contact", err, w, r)
 
 			return
 		}
 
-		log.Info("update item complete successfully")
+		log.InfoContext(r.Context(), "update item complete successfully")
 
 		server.RespondOK(Resp{
 			OK:  res,
@@ -0,0 +1,102 @@
+package update
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type fakeUpdater struct {
+	updated *models.Contact
+	err     error
+}
+
+func (f *fakeUpdater) Update(_ context.Context, contact models.Contact) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	f.updated = &contact
+	return true, nil
+}
+
+func (f *fakeUpdater) FieldDefinitions(context.Context) ([]models.FieldDefinition, error) {
+	return []models.FieldDefinition{
+		{Name: "score", Type: models.FieldNumber},
+		{Name: "since", Type: models.FieldDate},
+		{Name: "site", Type: models.FieldURL},
+	}, nil
+}
+
+func serve(updater Updater, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Put("/v1/contact/{uid}", New(slog.New(slog.NewTextHandler(io.Discard, nil)), updater))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/contact/0123456789abcdef01234567", strings.NewReader(body)))
+	return w
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+		// Поля нарушений в ответе
+		violations []string
+	}{
+		{name: "ok", body: `{"_id":"ignored","username":"ann"}`, status: http.StatusOK},
+		{name: "malformed json", body: `{"username":`, status: http.StatusBadRequest},
+		{name: "wrong type", body: `{"username":42}`, status: http.StatusBadRequest},
+		// Метки и день рождения проверяются и без проверки запросов по спецификации
+		{name: "unknown label", body: `{"username":"ann","phones":[{"label":"cell","number":"+100"}]}`, status: http.StatusBadRequest},
+		{name: "bad birthday", body: `{"username":"ann","birthday":"1990-13-01"}`, status: http.StatusBadRequest},
+		// Значения пользовательских полей проверяются по их описаниям
+		{name: "fields", body: `{"username":"ann","fields":{"score":"4.5","since":"2024-02-01","site":""}}`, status: http.StatusOK},
+		{name: "field type mismatch", body: `{"username":"ann","fields":{"score":"high","since":20240201}}`, status: http.StatusBadRequest, violations: []string{"fields.score", "fields.since"}},
+		{name: "bad url field", body: `{"username":"ann","fields":{"site":"ftp://example.com"}}`, status: http.StatusBadRequest, violations: []string{"fields.site"}},
+		{name: "unknown field", body: `{"username":"ann","fields":{"crm_id":"1"}}`, status: http.StatusBadRequest, violations: []string{"fields.crm_id"}},
+		{name: "not found", body: `{"username":"ann"}`, err: storage.ErrContactNotFound, status: http.StatusBadRequest},
+		{name: "storage error", body: `{"username":"ann"}`, err: io.ErrUnexpectedEOF, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &fakeUpdater{err: tt.err}
+
+			w := serve(updater, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.violations != nil {
+				var resp server.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+
+				var fields []string
+				for _, v := range resp.Violations {
+					fields = append(fields, v.Field)
+				}
+				if !slices.Equal(fields, tt.violations) {
+					t.Errorf("violations %+v, want fields %v", resp.Violations, tt.violations)
+				}
+			}
+
+			if tt.status == http.StatusOK && updater.updated.ID != "0123456789abcdef01234567" {
+				t.Errorf("id from body was not replaced by uid: %q", updater.updated.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package listTags
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+type TagLister interface {
+	Tags(ctx context.Context) ([]storage.TagCount, error)
+}
+
+type TagResp struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// New отдаёт все метки контактов с числом контактов у каждой.
+func New(log *slog.Logger, lister TagLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tags.list.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		tags, err := lister.Tags(r.Context())
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "listing tags interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error listing tags", sl.Err(err))
+
+			server.InternalError("error listing tags", err, w, r)
+
+			return
+		}
+
+		resp := make([]TagResp, len(tags))
+		for i, t := range tags {
+			resp[i] = TagResp(t)
+		}
+
+		server.RespondOK(resp, w, r)
+	}
+}
@@ -0,0 +1,111 @@
+package updateTags
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+var ErrInvalidTagChange = errors.New("invalid tag change")
+
+type TagUpdater interface {
+	UpdateTags(ctx context.Context, ids, add, remove []string) (storage.MembershipChange, error)
+}
+
+type Request struct {
+	IDs    []string `json:"ids"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+type Resp struct {
+	// Число контактов, изменённых добавлением и удалением меток
+	Added   int64 `json:"added"`
+	Removed int64 `json:"removed"`
+}
+
+// New добавляет и убирает метки у пачки контактов.
+func New(log *slog.Logger, updater TagUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tags.update.New"
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("op", op))
+
+		var req Request
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.InfoContext(r.Context(), "error parsing request body", sl.Err(err))
+
+			server.BadRequest("error parsing request body", err, w, r)
+
+			return
+		}
+
+		if err := req.normalize(); err != nil {
+			log.InfoContext(r.Context(), "invalid tag change", sl.Err(err))
+
+			server.BadRequest("invalid tag change", err, w, r)
+
+			return
+		}
+
+		change, err := updater.UpdateTags(r.Context(), req.IDs, req.Add, req.Remove)
+		if err != nil {
+			if server.ContextError(err, w, r) {
+				log.InfoContext(r.Context(), "updating tags interrupted", sl.Err(err))
+				return
+			}
+
+			log.InfoContext(r.Context(), "error updating tags", sl.Err(err))
+
+			server.InternalError("error updating tags", err, w, r)
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "tags updated",
+			slog.Int("contacts", len(req.IDs)),
+			slog.Int64("added", change.Added),
+			slog.Int64("removed", change.Removed))
+
+		server.RespondOK(Resp(change), w, r)
+	}
+}
+
+func (req *Request) normalize() error {
+	if len(req.IDs) == 0 {
+		return fmt.Errorf("%w: ids is required", ErrInvalidTagChange)
+	}
+	if len(req.IDs) > storage.MaxBatchSize {
+		return fmt.Errorf("%w: at most %d contacts per request", ErrInvalidTagChange, storage.MaxBatchSize)
+	}
+
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		return fmt.Errorf("%w: add or remove is required", ErrInvalidTagChange)
+	}
+
+	var err error
+
+	if req.Add, err = models.NormalizeTags(req.Add); err != nil {
+		return err
+	}
+	if req.Remove, err = models.NormalizeTags(req.Remove); err != nil {
+		return err
+	}
+
+	for _, tag := range req.Add {
+		if slices.Contains(req.Remove, tag) {
+			return fmt.Errorf("%w: tag %q is both added and removed", ErrInvalidTagChange, tag)
+		}
+	}
+
+	return nil
+}
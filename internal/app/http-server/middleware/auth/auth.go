@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/pkg/principal"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// AdminPrincipal - имя клиента, предъявившего токен администратора
+const AdminPrincipal = "admin"
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+// New возвращает middleware, которое узнаёт администратора по заголовку Authorization: Bearer
+// и сохраняет его в контексте запроса. Запросы без токена или с неверным токеном остаются анонимными.
+// Должно стоять перед логгером и ограничением частоты, чтобы они видели клиента.
+func New(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token != "" && validBearer(r.Header.Get("Authorization"), token) {
+				r = r.WithContext(principal.WithPrincipal(r.Context(), AdminPrincipal))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAdmin отклоняет запросы, не прошедшие проверку в New.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal.FromContext(r.Context()) != AdminPrincipal {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			server.Unauthorized("admin token required", ErrUnauthorized, w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validBearer(header, token string) bool {
+	got, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
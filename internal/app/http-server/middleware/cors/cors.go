@@ -0,0 +1,121 @@
+package cors
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/pkg/logger/sl"
+	"github.com/go-chi/chi"
+	"github.com/rs/cors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Handler применяет CORS-политику, которую можно заменить на лету при перезагрузке конфигурации.
+type Handler struct {
+	log     *slog.Logger
+	current atomic.Pointer[cors.Cors]
+
+	mu      sync.Mutex
+	cfg     config.CORS
+	methods []string
+}
+
+const op = "middleware.cors"
+
+func New(log *slog.Logger, cfg config.CORS) *Handler {
+	h := &Handler{log: log}
+	h.Update(cfg)
+
+	return h
+}
+
+// Update атомарно подменяет политику для всех последующих запросов.
+func (h *Handler) Update(cfg config.CORS) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cfg = cfg
+	h.apply()
+}
+
+// SetRouteMethods берёт список разрешённых методов из зарегистрированных маршрутов,
+// если он не задан в конфигурации явно. Вызывается после регистрации всех маршрутов.
+func (h *Handler) SetRouteMethods(routes chi.Routes) error {
+	methods := []string{http.MethodOptions}
+
+	err := chi.Walk(routes, func(method string, _ string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// CONNECT и TRACE не используются браузерными клиентами API
+		if method == http.MethodConnect || method == http.MethodTrace {
+			return nil
+		}
+		if !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(methods)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.methods = methods
+	h.apply()
+
+	return nil
+}
+
+func (h *Handler) apply() {
+	opts := cors.Options{
+		AllowedOrigins:   h.cfg.AllowedOrigins,
+		AllowedMethods:   h.cfg.AllowedMethods,
+		AllowedHeaders:   h.cfg.AllowedHeaders,
+		ExposedHeaders:   h.cfg.ExposedHeaders,
+		AllowCredentials: h.cfg.AllowCredentials,
+		MaxAge:           int(h.cfg.MaxAge.Seconds()),
+	}
+
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = h.methods
+	}
+
+	// Пустой список в rs/cors означает "разрешить всё", у нас - запретить кросс-доменные запросы
+	if len(opts.AllowedOrigins) == 0 {
+		opts.AllowOriginFunc = func(string) bool { return false }
+	}
+
+	h.current.Store(cors.New(opts))
+
+	h.log.Info("cors policy applied",
+		slog.String("op", op),
+		slog.Any("allowed origins", opts.AllowedOrigins),
+		slog.Any("allowed methods", opts.AllowedMethods),
+		slog.Bool("allow credentials", opts.AllowCredentials))
+}
+
+func (h *Handler) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.current.Load().ServeHTTP(w, r, next.ServeHTTP)
+
+		if isPreflight(r) && w.Header().Get("Access-Control-Allow-Origin") == "" {
+			log := sl.FromContext(r.Context(), h.log).With(slog.String("op", op))
+			log.InfoContext(r.Context(), "cors preflight rejected",
+				slog.String("origin", r.Header.Get("Origin")),
+				slog.String("method", r.Header.Get("Access-Control-Request-Method")),
+				slog.String("headers", r.Header.Get("Access-Control-Request-Headers")),
+				slog.String("path", r.URL.Path))
+		}
+	})
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		strings.TrimSpace(r.Header.Get("Access-Control-Request-Method")) != ""
+}
@@ -0,0 +1,163 @@
+package cors
+
+import (
+	"bytes"
+	"contact-api/internal/app/config"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newRouter собирает маршрутизатор с политикой cfg и методами маршрутов, как serve.
+func newRouter(t *testing.T, cfg config.CORS) (*Handler, http.Handler, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	h := New(slog.New(slog.NewTextHandler(&buf, nil)), cfg)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	router := chi.NewRouter()
+	router.Use(h.Handler)
+	router.Get("/v1/contact", ok)
+	router.Post("/v1/contact", ok)
+	router.Delete("/v1/contact/{uid}", ok)
+	router.Patch("/v1/group/{gid}/members", ok)
+
+	if err := h.SetRouteMethods(router); err != nil {
+		t.Fatalf("SetRouteMethods: %v", err)
+	}
+	return h, router, &buf
+}
+
+func preflight(router http.Handler, origin, method string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/v1/contact", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPreflight(t *testing.T) {
+	origins := []string{"https://app.example.org", "https://*.example.com"}
+
+	tests := []struct {
+		name    string
+		cfg     config.CORS
+		origin  string
+		method  string
+		allowed bool
+	}{
+		{name: "allowed origin", origin: "https://app.example.org", method: http.MethodPost, allowed: true},
+		{name: "wildcard origin", origin: "https://crm.example.com", method: http.MethodGet, allowed: true},
+		{name: "disallowed origin", origin: "https://evil.example.net", method: http.MethodGet},
+		{name: "wildcard does not match apex", origin: "https://example.com", method: http.MethodGet},
+		// Методы без явной настройки берутся из маршрутов
+		{name: "route method", origin: "https://app.example.org", method: http.MethodPatch, allowed: true},
+		{name: "method without route", origin: "https://app.example.org", method: http.MethodPut},
+		{name: "trace", origin: "https://app.example.org", method: http.MethodTrace},
+		{
+			name:   "configured methods",
+			cfg:    config.CORS{AllowedMethods: []string{http.MethodGet}},
+			origin: "https://app.example.org", method: http.MethodPatch,
+		},
+		{
+			name:   "no origins",
+			cfg:    config.CORS{AllowedOrigins: []string{}},
+			origin: "https://app.example.org", method: http.MethodGet,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if cfg.AllowedOrigins == nil {
+				cfg.AllowedOrigins = origins
+			}
+
+			_, router, logs := newRouter(t, cfg)
+
+			w := preflight(router, tt.origin, tt.method)
+			if w.Code >= 300 {
+				t.Fatalf("status %d", w.Code)
+			}
+
+			gotOrigin := w.Header().Get("Access-Control-Allow-Origin")
+			gotMethods := w.Header().Get("Access-Control-Allow-Methods")
+
+			if tt.allowed {
+				if gotOrigin != tt.origin || gotMethods != tt.method {
+					t.Errorf("Allow-Origin %q, Allow-Methods %q", gotOrigin, gotMethods)
+				}
+				return
+			}
+
+			if gotOrigin != "" || gotMethods != "" {
+				t.Errorf("rejected preflight allowed: Allow-Origin %q, Allow-Methods %q", gotOrigin, gotMethods)
+			}
+			if !strings.Contains(logs.String(), "cors preflight rejected") {
+				t.Errorf("rejected preflight is not logged:\n%s", logs)
+			}
+		})
+	}
+}
+
+func TestSetRouteMethods(t *testing.T) {
+	h, _, _ := newRouter(t, config.CORS{})
+
+	want := []string{http.MethodDelete, http.MethodGet, http.MethodOptions, http.MethodPatch, http.MethodPost}
+	if strings.Join(h.methods, ",") != strings.Join(want, ",") {
+		t.Errorf("methods %v, want %v", h.methods, want)
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	_, router, _ := newRouter(t, config.CORS{
+		AllowedOrigins: []string{"https://app.example.org"},
+		ExposedHeaders: []string{"X-Request-ID"},
+	})
+
+	for origin, allowed := range map[string]bool{"https://app.example.org": true, "https://evil.example.net": false} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/contact", nil)
+		req.Header.Set("Origin", origin)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Запрос доходит до обработчика в любом случае, а ответ браузеру открывают только заголовки
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d", origin, w.Code)
+		}
+
+		gotOrigin := w.Header().Get("Access-Control-Allow-Origin")
+		if allowed && (gotOrigin != origin || w.Header().Get("Access-Control-Expose-Headers") != "X-Request-Id") {
+			t.Errorf("%s: headers %v", origin, w.Header())
+		}
+		if !allowed && gotOrigin != "" {
+			t.Errorf("%s: Allow-Origin %q", origin, gotOrigin)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	h, router, _ := newRouter(t, config.CORS{AllowedOrigins: []string{"https://old.example.org"}})
+
+	if w := preflight(router, "https://new.example.org", http.MethodPatch); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("new origin allowed before Update")
+	}
+
+	h.Update(config.CORS{AllowedOrigins: []string{"https://new.example.org"}})
+
+	// Методы маршрутов сохраняются после перезагрузки политики
+	if w := preflight(router, "https://new.example.org", http.MethodPatch); w.Header().Get("Access-Control-Allow-Origin") != "https://new.example.org" {
+		t.Errorf("new origin rejected after Update: %v", w.Header())
+	}
+	if w := preflight(router, "https://old.example.org", http.MethodGet); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("old origin still allowed after Update: %v", w.Header())
+	}
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"contact-api/internal/app/http-server/middleware/requestid"
+	"contact-api/internal/pkg/logger/sl"
+	"contact-api/internal/pkg/principal"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// New возвращает middleware, которое кладёт в контекст логгер запроса
+// и пишет одну строку access-лога по завершении запроса.
+// Должно стоять после requestid.New, чтобы идентификатор попал в логгер.
+func New(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqLog := log.With(slog.String("request_id", requestid.FromContext(r.Context())))
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			r = r.WithContext(sl.WithLogger(r.Context(), reqLog))
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				// Паника обработчика будет превращена recoverer.New в 500
+				rec := recover()
+				if rec != nil {
+					status = http.StatusInternalServerError
+				}
+
+				route := ""
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					route = rctx.RoutePattern()
+				}
+
+				reqLog.LogAttrs(r.Context(), accessLevel(status), "request completed",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("route", route),
+					slog.Int("status", status),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.Duration("latency", time.Since(start)),
+					slog.String("remote_ip", remoteIP(r)),
+					slog.String("principal", principal.FromContext(r.Context())),
+				)
+
+				if rec != nil {
+					panic(rec)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+func accessLevel(status int) slog.Level {
+	if status >= http.StatusInternalServerError {
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
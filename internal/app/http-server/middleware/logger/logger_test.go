@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"bytes"
+	"contact-api/internal/app/http-server/middleware/recoverer"
+	"contact-api/internal/app/http-server/middleware/requestid"
+	"contact-api/internal/pkg/logger/sl"
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newRouter собирает цепочку middleware в том же порядке, что и публичный маршрутизатор.
+func newRouter(buf *bytes.Buffer) *chi.Mux {
+	log := slog.New(slog.NewJSONHandler(buf, nil))
+
+	router := chi.NewRouter()
+	router.Use(recoverer.New(log))
+	router.Use(requestid.New)
+	router.Use(New(log))
+
+	router.Get("/v1/contact/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		// Обработчики пишут в логгер запроса, а глобальный логгер - только запасной
+		sl.FromContext(r.Context(), slog.New(slog.NewTextHandler(io.Discard, nil))).InfoContext(r.Context(), "handler")
+
+		switch chi.URLParam(r, "uid") {
+		case "fail":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "panic":
+			panic("boom")
+		default:
+			_, _ = w.Write([]byte("hello"))
+		}
+	})
+
+	return router
+}
+
+// records разбирает все строки лога.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("failed to decode %q: %v", line, err)
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		uid    string
+		status float64
+		level  string
+		bytes  float64
+	}{
+		{uid: "42", status: http.StatusOK, level: "INFO", bytes: 5},
+		{uid: "fail", status: http.StatusServiceUnavailable, level: "ERROR"},
+		{uid: "panic", status: http.StatusInternalServerError, level: "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uid, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/contact/"+tt.uid, nil)
+			req.Header.Set(requestid.Header, "req-1")
+
+			w := httptest.NewRecorder()
+			newRouter(&buf).ServeHTTP(w, req)
+
+			if w.Code != int(tt.status) {
+				t.Fatalf("status %d, want %v", w.Code, tt.status)
+			}
+
+			var handler, access map[string]any
+			for _, rec := range records(t, &buf) {
+				switch rec["msg"] {
+				case "handler":
+					handler = rec
+				case "request completed":
+					access = rec
+				}
+			}
+
+			if handler == nil || handler["request_id"] != "req-1" {
+				t.Errorf("handler line %v does not carry the request id", handler)
+			}
+
+			if access == nil {
+				t.Fatalf("no access log line:\n%s", buf.String())
+			}
+			want := map[string]any{
+				"level":      tt.level,
+				"request_id": "req-1",
+				"method":     http.MethodGet,
+				"path":       "/v1/contact/" + tt.uid,
+				"route":      "/v1/contact/{uid}",
+				"status":     tt.status,
+				"bytes":      tt.bytes,
+			}
+			for key, value := range want {
+				if access[key] != value {
+					t.Errorf("%s = %v, want %v", key, access[key], value)
+				}
+			}
+			if latency, ok := access["latency"].(float64); !ok || latency <= 0 {
+				t.Errorf("latency = %v", access["latency"])
+			}
+		})
+	}
+}
+
+func TestFromContextFallback(t *testing.T) {
+	fallback := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if sl.FromContext(req.Context(), fallback) != fallback {
+		t.Error("fallback is not used without a request logger")
+	}
+
+	var reqLog *slog.Logger
+	New(fallback)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqLog = sl.FromContext(r.Context(), nil)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	if reqLog == nil || reqLog == fallback {
+		t.Errorf("request logger = %v", reqLog)
+	}
+}
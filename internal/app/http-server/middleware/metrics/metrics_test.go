@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"contact-api/internal/app/metrics"
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(New)
+	router.Get("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// Обработчик, не вызвавший WriteHeader, отвечает 200
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		path   string
+		labels []string
+	}{
+		// Идентификатор не попадает в метку: используется шаблон маршрута
+		{"/metrics-test/1", []string{"/metrics-test/{id}", http.MethodGet, "200"}},
+		{"/metrics-test/missing", []string{"/metrics-test/{id}", http.MethodGet, "404"}},
+		{"/metrics-test-unknown", []string{"unmatched", http.MethodGet, "404"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(tt.labels...))
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(tt.labels...)) - before; got != 1 {
+				t.Errorf("requests_total%v grew by %v, want 1", tt.labels, got)
+			}
+
+			var m dto.Metric
+			if err := metrics.HTTPDuration.WithLabelValues(tt.labels...).(prometheus.Metric).Write(&m); err != nil {
+				t.Fatal(err)
+			}
+			if m.GetHistogram().GetSampleCount() == 0 {
+				t.Errorf("request_duration_seconds%v has no samples", tt.labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package ratelimit
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/pkg/logger/sl"
+	"contact-api/internal/pkg/principal"
+	"contact-api/internal/pkg/tokenbucket"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/go-chi/chi/middleware"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrQuotaExceeded = errors.New("daily write quota exceeded")
+)
+
+const cleanupInterval = time.Minute
+
+// QuotaCounter ведёт суточные счётчики записей клиентов. IncrementQuota атомарно увеличивает
+// счётчик и возвращает новое значение, RefundQuota возвращает списанную запись.
+type QuotaCounter interface {
+	IncrementQuota(ctx context.Context, key string, day string) (int64, error)
+	RefundQuota(ctx context.Context, key string, day string) error
+}
+
+type class int
+
+const (
+	classRead class = iota
+	classWrite
+	classDestructive
+)
+
+// Limiter ограничивает частоту запросов и суточное число записей для каждого клиента.
+// Настройки можно заменить на лету через Update.
+type Limiter struct {
+	log   *slog.Logger
+	quota QuotaCounter
+
+	state atomic.Pointer[state]
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+type state struct {
+	cfg     config.RateLimit
+	buckets map[class]*tokenbucket.Limiter
+	// Хэши выданных ключей: сравнение хэшей не выдаёт по времени ответа, сколько символов ключа угадано
+	apiKeys map[[sha256.Size]byte]struct{}
+}
+
+// charge - запись, списанная с суточной квоты клиента.
+type charge struct {
+	key string
+	day string
+}
+
+const op = "middleware.ratelimit"
+
+func New(log *slog.Logger, cfg config.RateLimit, quota QuotaCounter) *Limiter {
+	l := &Limiter{
+		log:         log,
+		quota:       quota,
+		lastCleanup: time.Now(),
+	}
+	l.Update(cfg)
+
+	return l
+}
+
+// Update атомарно применяет новые лимиты. Bucket'и классов с неизменившимися
+// настройками сохраняются, чтобы перезагрузка не обнуляла накопленные счётчики клиентов.
+func (l *Limiter) Update(cfg config.RateLimit) {
+	next := &state{
+		cfg:     cfg,
+		buckets: make(map[class]*tokenbucket.Limiter),
+		apiKeys: make(map[[sha256.Size]byte]struct{}, len(cfg.APIKeys)),
+	}
+	prev := l.state.Load()
+
+	for _, key := range cfg.APIKeys {
+		next.apiKeys[sha256.Sum256([]byte(key))] = struct{}{}
+	}
+
+	for c, b := range bucketConfigs(cfg) {
+		if b.Rate <= 0 {
+			continue
+		}
+
+		if prev != nil && bucketConfigs(prev.cfg)[c] == b && prev.buckets[c] != nil {
+			next.buckets[c] = prev.buckets[c]
+			continue
+		}
+
+		next.buckets[c] = tokenbucket.New(b.Rate, b.Burst)
+	}
+
+	l.state.Store(next)
+}
+
+// Handler возвращает middleware, применяющее текущие лимиты.
+// Квоту расходуют только принятые записи: если обработчик ответил ошибкой (невалидное тело,
+// отказ в доступе, отмена запроса клиентом), списанная запись возвращается.
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ch, ok := l.allow(w, r)
+		if !ok {
+			return
+		}
+
+		if ch == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		if ww.Status() >= http.StatusBadRequest {
+			l.refund(r, ch)
+		}
+	})
+}
+
+func bucketConfigs(cfg config.RateLimit) map[class]config.Bucket {
+	return map[class]config.Bucket{
+		classRead:        cfg.Read,
+		classWrite:       cfg.Write,
+		classDestructive: cfg.Destructive,
+	}
+}
+
+// allow проверяет лимиты запроса. Если запрос принят и списал запись с квоты, возвращается
+// списание, которое нужно вернуть при неуспешном ответе.
+func (l *Limiter) allow(w http.ResponseWriter, r *http.Request) (*charge, bool) {
+	st := l.state.Load()
+	if !st.cfg.Enabled {
+		return nil, true
+	}
+
+	l.cleanup(st)
+
+	key := st.clientKey(r)
+	c := classify(r.Method)
+
+	if b, ok := st.buckets[c]; ok {
+		res := b.Allow(key)
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", seconds(res.Reset))
+
+		if !res.Allowed {
+			l.logger(r).InfoContext(r.Context(), "rate limit exceeded", slog.String("client", key), slog.String("method", r.Method))
+
+			w.Header().Set("Retry-After", seconds(res.RetryAfter))
+			server.TooManyRequests("rate limit exceeded", ErrRateLimited, w, r)
+
+			return nil, false
+		}
+	}
+
+	if c == classRead || st.cfg.DailyWriteQuota <= 0 || l.quota == nil {
+		return nil, true
+	}
+
+	now := time.Now().UTC()
+	ch := &charge{key: key, day: now.Format(time.DateOnly)}
+
+	used, err := l.quota.IncrementQuota(r.Context(), ch.key, ch.day)
+	if err != nil {
+		// Недоступность хранилища квот не должна блокировать запись контактов
+		l.logger(r).ErrorContext(r.Context(), "failed to increment write quota", sl.Err(err))
+		return nil, true
+	}
+
+	if used > st.cfg.DailyWriteQuota {
+		// Отклонённая запись не расходует квоту, иначе счётчик рос бы и после исчерпания
+		l.refund(r, ch)
+
+		l.logger(r).InfoContext(r.Context(), "daily write quota exceeded", slog.String("client", key), slog.Int64("used", used))
+
+		midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		w.Header().Set("Retry-After", seconds(midnight.Sub(now)))
+		server.TooManyRequests("daily write quota exceeded", ErrQuotaExceeded, w, r)
+
+		return nil, false
+	}
+
+	return ch, true
+}
+
+// refund возвращает списанную запись. Контекст запроса к этому моменту может быть
+// уже отменён клиентом, поэтому отмена от него не наследуется.
+func (l *Limiter) refund(r *http.Request, ch *charge) {
+	ctx := context.WithoutCancel(r.Context())
+
+	if err := l.quota.RefundQuota(ctx, ch.key, ch.day); err != nil {
+		l.logger(r).ErrorContext(ctx, "failed to refund write quota", sl.Err(err))
+	}
+}
+
+// logger возвращает логгер запроса, если он есть в контексте.
+func (l *Limiter) logger(r *http.Request) *slog.Logger {
+	return sl.FromContext(r.Context(), l.log).With(slog.String("op", op))
+}
+
+// clientKey выбирает первый доступный идентификатор клиента в порядке, заданном key_by.
+// Ключ из заголовка учитывается, только если он выдан клиенту: иначе случайный заголовок
+// в каждом запросе давал бы новый bucket и новую квоту.
+func (st *state) clientKey(r *http.Request) string {
+	for _, source := range st.cfg.KeyBy {
+		switch source {
+		case "api_key":
+			key := r.Header.Get(st.cfg.APIKeyHeader)
+			if key == "" {
+				continue
+			}
+
+			sum := sha256.Sum256([]byte(key))
+			if _, ok := st.apiKeys[sum]; ok {
+				// Сами ключи не должны попадать в логи и коллекцию квот
+				return "key:" + hex.EncodeToString(sum[:8])
+			}
+		case "principal":
+			if name := principal.FromContext(r.Context()); name != "" {
+				return "principal:" + name
+			}
+		case "ip":
+			return "ip:" + remoteIP(r)
+		}
+	}
+
+	return "ip:" + remoteIP(r)
+}
+
+func (l *Limiter) cleanup(st *state) {
+	l.mu.Lock()
+	if time.Since(l.lastCleanup) < cleanupInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastCleanup = time.Now()
+	l.mu.Unlock()
+
+	for _, b := range st.buckets {
+		b.Cleanup()
+	}
+}
+
+func classify(method string) class {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return classRead
+	case http.MethodDelete:
+		return classDestructive
+	default:
+		return classWrite
+	}
+}
+
+// remoteIP возвращает адрес клиента; middleware.RealIP к этому моменту уже подставил его из заголовков прокси.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int((d + time.Second - 1) / time.Second))
+}
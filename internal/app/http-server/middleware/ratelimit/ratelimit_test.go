@@ -0,0 +1,260 @@
+package ratelimit
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/pkg/principal"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeQuota - счётчики квот в памяти.
+type fakeQuota struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	err    error
+}
+
+func newFakeQuota() *fakeQuota {
+	return &fakeQuota{counts: make(map[string]int64)}
+}
+
+func (q *fakeQuota) IncrementQuota(_ context.Context, key string, day string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.err != nil {
+		return 0, q.err
+	}
+	q.counts[key+"|"+day]++
+	return q.counts[key+"|"+day], nil
+}
+
+func (q *fakeQuota) RefundQuota(_ context.Context, key string, day string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.counts[key+"|"+day] > 0 {
+		q.counts[key+"|"+day]--
+	}
+	return nil
+}
+
+// total возвращает сумму счётчиков всех клиентов.
+func (q *fakeQuota) total() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for _, c := range q.counts {
+		n += c
+	}
+	return n
+}
+
+func testConfig() config.RateLimit {
+	return config.RateLimit{
+		Enabled:      true,
+		KeyBy:        []string{"api_key", "principal", "ip"},
+		APIKeyHeader: "X-API-Key",
+		APIKeys:      []string{"issued-key"},
+	}
+}
+
+func newTestLimiter(cfg config.RateLimit, quota QuotaCounter) *Limiter {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, quota)
+}
+
+func TestClientKey(t *testing.T) {
+	cfg := testConfig()
+	sum := sha256.Sum256([]byte("issued-key"))
+	issued := "key:" + hex.EncodeToString(sum[:8])
+
+	tests := []struct {
+		name      string
+		keyBy     []string
+		apiKey    string
+		principal string
+		want      string
+	}{
+		{name: "issued key", apiKey: "issued-key", want: issued},
+		{name: "unknown key falls back to ip", apiKey: "random-123", want: "ip:192.0.2.1"},
+		{name: "unknown key falls back to principal", apiKey: "random-123", principal: "admin", want: "principal:admin"},
+		{name: "no key", want: "ip:192.0.2.1"},
+		{name: "principal before ip", principal: "admin", want: "principal:admin"},
+		{name: "ip only", keyBy: []string{"ip"}, apiKey: "issued-key", principal: "admin", want: "ip:192.0.2.1"},
+		{name: "empty key_by", keyBy: []string{}, want: "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			if tt.keyBy != nil {
+				c.KeyBy = tt.keyBy
+			}
+			l := newTestLimiter(c, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/contact", nil)
+			r.RemoteAddr = "192.0.2.1:12345"
+			if tt.apiKey != "" {
+				r.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.principal != "" {
+				r = r.WithContext(principal.WithPrincipal(r.Context(), tt.principal))
+			}
+
+			if got := l.state.Load().clientKey(r); got != tt.want {
+				t.Errorf("clientKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandomKeysShareBucket(t *testing.T) {
+	cfg := testConfig()
+	cfg.Write = config.Bucket{Rate: 0.001, Burst: 2}
+	h := newTestLimiter(cfg, nil).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/v1/contact", nil)
+		r.RemoteAddr = "192.0.2.1:12345"
+		r.Header.Set("X-API-Key", "random-"+strings.Repeat("x", i))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		codes = append(codes, w.Code)
+	}
+
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses %v: unknown api keys bypassed the rate limit", codes)
+	}
+}
+
+func TestQuotaCountsAcceptedWrites(t *testing.T) {
+	cfg := testConfig()
+	cfg.DailyWriteQuota = 2
+
+	tests := []struct {
+		name   string
+		status int
+		want   int64
+	}{
+		{name: "success", status: http.StatusOK, want: 1},
+		{name: "created", status: http.StatusCreated, want: 1},
+		{name: "no explicit status", status: 0, want: 1},
+		{name: "bad request", status: http.StatusBadRequest, want: 0},
+		{name: "forbidden", status: http.StatusForbidden, want: 0},
+		{name: "internal error", status: http.StatusInternalServerError, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota := newFakeQuota()
+			h := newTestLimiter(cfg, quota).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+			}))
+
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/contact", nil))
+
+			if got := quota.total(); got != tt.want {
+				t.Errorf("quota used %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	cfg := testConfig()
+	cfg.DailyWriteQuota = 2
+	quota := newFakeQuota()
+	h := newTestLimiter(cfg, quota).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/contact/x", nil))
+
+		if w.Code != want {
+			t.Errorf("request %d: status %d, want %d", i, w.Code, want)
+		}
+		if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("request %d: no Retry-After", i)
+		}
+	}
+
+	if got := quota.total(); got != 2 {
+		t.Errorf("quota used %d, want 2: rejected writes must not be counted", got)
+	}
+}
+
+func TestQuotaSkippedForReads(t *testing.T) {
+	cfg := testConfig()
+	cfg.DailyWriteQuota = 1
+	quota := newFakeQuota()
+	h := newTestLimiter(cfg, quota).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/contact", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("read %d: status %d", i, w.Code)
+		}
+	}
+
+	if got := quota.total(); got != 0 {
+		t.Errorf("reads consumed quota: %d", got)
+	}
+}
+
+func TestQuotaStorageFailureAllowsWrite(t *testing.T) {
+	cfg := testConfig()
+	cfg.DailyWriteQuota = 1
+	quota := newFakeQuota()
+	quota.err = errors.New("storage unavailable")
+	h := newTestLimiter(cfg, quota).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/contact", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status %d, want 200 when the quota storage fails", w.Code)
+	}
+}
+
+func TestUpdateKeepsBuckets(t *testing.T) {
+	cfg := testConfig()
+	cfg.Read = config.Bucket{Rate: 0.001, Burst: 1}
+	l := newTestLimiter(cfg, nil)
+	h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/contact", nil))
+		return w.Code
+	}
+
+	serve()
+
+	// Изменение других настроек не обнуляет bucket чтения
+	cfg.DailyWriteQuota = 10
+	l.Update(cfg)
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Errorf("status %d after unrelated update, want 429", code)
+	}
+
+	cfg.Read = config.Bucket{Rate: 0.001, Burst: 5}
+	l.Update(cfg)
+	if code := serve(); code != http.StatusOK {
+		t.Errorf("status %d after raising burst, want 200", code)
+	}
+}
@@ -0,0 +1,45 @@
+package recoverer
+
+import (
+	"contact-api/internal/app/http-server/middleware/requestid"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+const op = "middleware.recoverer"
+
+// New возвращает middleware, которое превращает панику обработчика в ответ 500 и пишет её стек в лог.
+// Заменяет middleware.Recoverer из chi v1.5: тот разбирает стек в устаревшем формате
+// и сам паникует на стеках текущих версий Go, так что клиент получал обрыв соединения вместо 500.
+// Стоит первым в цепочке, поэтому идентификатор запроса берётся из уже выставленного заголовка ответа.
+func New(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// ErrAbortHandler - штатный способ оборвать ответ, его обрабатывает net/http
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				log.ErrorContext(r.Context(), "handler panicked",
+					slog.String("op", op),
+					slog.String("request_id", w.Header().Get(requestid.Header)),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Any("panic", rec),
+					slog.String("stack", string(debug.Stack())))
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var generatedRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{name: "incoming", incoming: "req-42/abc", kept: true},
+		{name: "missing"},
+		{name: "too long", incoming: strings.Repeat("a", maxLength+1)},
+		{name: "max length", incoming: strings.Repeat("a", maxLength), kept: true},
+		{name: "space", incoming: "req 42"},
+		{name: "control character", incoming: "req-42\x1b[31m"},
+		{name: "non-ascii", incoming: "запрос-42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromCtx string
+			handler := New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromCtx = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(Header, tt.incoming)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(Header)
+			if tt.kept && id != tt.incoming {
+				t.Errorf("id %q, want incoming %q", id, tt.incoming)
+			}
+			if !tt.kept && !generatedRe.MatchString(id) {
+				t.Errorf("id %q is not generated", id)
+			}
+
+			// Обработчик видит тот же идентификатор, что получает клиент
+			if fromCtx != id {
+				t.Errorf("context id %q, response id %q", fromCtx, id)
+			}
+		})
+	}
+}
+
+func TestGeneratedIDsDiffer(t *testing.T) {
+	handler := New(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	seen := make(map[string]bool)
+	for range 100 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		id := w.Header().Get(Header)
+		if seen[id] {
+			t.Fatalf("id %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
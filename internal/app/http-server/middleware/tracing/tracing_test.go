@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"bytes"
+	"contact-api/internal/app/http-server/middleware/logger"
+	"contact-api/internal/pkg/logger/handlers/slogtrace"
+	"contact-api/internal/pkg/logger/sl"
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// useRecorder подменяет глобальный провайдер на записывающий спаны до конца теста.
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	return recorder
+}
+
+// newRouter собирает трассировку и логгер в том же порядке, что и публичный маршрутизатор.
+func newRouter(buf *bytes.Buffer) *chi.Mux {
+	log := slog.New(slogtrace.New(slog.NewJSONHandler(buf, nil)))
+
+	router := chi.NewRouter()
+	router.Use(New)
+	router.Use(logger.New(log))
+
+	router.Get("/v1/contact/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		sl.FromContext(r.Context(), log).InfoContext(r.Context(), "handler")
+
+		if chi.URLParam(r, "uid") == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	return router
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestSpan(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+		code   codes.Code
+	}{
+		{path: "/v1/contact/42", status: http.StatusOK, code: codes.Unset},
+		{path: "/v1/contact/fail", status: http.StatusBadGateway, code: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			recorder := useRecorder(t)
+
+			var buf bytes.Buffer
+			w := httptest.NewRecorder()
+			newRouter(&buf).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+
+			// Имя по шаблону маршрута, а не по пути, чтобы не плодить имена спанов по идентификаторам
+			if span.Name() != "GET /v1/contact/{uid}" || span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("span %q, kind %v", span.Name(), span.SpanKind())
+			}
+			if route := attr(span, semconv.HTTPRouteKey).AsString(); route != "/v1/contact/{uid}" {
+				t.Errorf("http.route = %q", route)
+			}
+			if status := attr(span, semconv.HTTPResponseStatusCodeKey).AsInt64(); status != int64(tt.status) {
+				t.Errorf("status code attribute = %d, want %d", status, tt.status)
+			}
+			if span.Status().Code != tt.code {
+				t.Errorf("span status %v, want %v", span.Status().Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestTraceInLogs(t *testing.T) {
+	recorder := useRecorder(t)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/contact/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	var buf bytes.Buffer
+	newRouter(&buf).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	sc := spans[0].SpanContext()
+
+	// Трасса продолжается из traceparent клиента
+	if sc.TraceID().String() != traceID || spans[0].Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("trace %s, parent %s", sc.TraceID(), spans[0].Parent().SpanID())
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want handler and access lines:\n%s", len(lines), buf.String())
+	}
+
+	// И строка обработчика, и строка access-лога связаны со спаном запроса
+	for _, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec["trace_id"] != traceID || rec["span_id"] != sc.SpanID().String() {
+			t.Errorf("%s: trace_id %v, span_id %v; want %s, %s", rec["msg"], rec["trace_id"], rec["span_id"], traceID, sc.SpanID())
+		}
+	}
+}
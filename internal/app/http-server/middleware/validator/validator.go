@@ -0,0 +1,253 @@
+package validator
+
+import (
+	"bytes"
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/openapi"
+	"contact-api/internal/pkg/logger/sl"
+	"contact-api/internal/pkg/routepath"
+	"errors"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+var ErrContractViolation = errors.New("response does not match api contract")
+
+const op = "middleware.validator"
+
+// Validator проверяет запросы и, вне prod, ответы по спецификации OpenAPI.
+type Validator struct {
+	log    *slog.Logger
+	cfg    config.OpenAPIValidation
+	router routers.Router
+	opts   *openapi3filter.Options
+}
+
+func New(log *slog.Logger, spec *openapi.Spec, cfg config.OpenAPIValidation) (*Validator, error) {
+	router, err := gorillamux.NewRouter(spec.Doc())
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &openapi3filter.Options{
+		MultiError:            true,
+		IncludeResponseStatus: true,
+		// _id в теле допускается и игнорируется, чтобы клиент мог отправить обратно полученный контакт
+		ExcludeReadOnlyValidations: true,
+		// X-API-Key необязателен и проверяется ограничителем частоты, а не здесь
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	// По умолчанию текст ошибки содержит проверяемое значение, а в теле могут быть персональные данные
+	opts.WithCustomSchemaErrorFunc(func(err *openapi3.SchemaError) string {
+		return err.Reason
+	})
+
+	return &Validator{
+		log:    log,
+		cfg:    cfg,
+		router: router,
+		opts:   opts,
+	}, nil
+}
+
+// Handler возвращает middleware. Запросы к путям и методам, которых нет в спецификации,
+// пропускаются без проверки: на них ответит маршрутизатор.
+func (v *Validator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.findRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.opts,
+		}
+
+		if v.cfg.Requests {
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				vs := violations(err)
+
+				v.logger(r).InfoContext(r.Context(), "request does not match api contract", slog.Any("violations", vs))
+
+				server.InvalidRequest("request does not match api contract", vs, err, w, r)
+
+				return
+			}
+		}
+
+		if v.cfg.Responses == config.ResponseValidationOff || v.cfg.Responses == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		resp := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.status,
+			Header:                 w.Header(),
+			Options:                v.opts,
+		}
+		resp.SetBodyBytes(rec.body.Bytes())
+
+		err = openapi3filter.ValidateResponse(r.Context(), resp)
+		if err != nil {
+			v.logger(r).ErrorContext(r.Context(), "response does not match api contract",
+				slog.Int("status", rec.status),
+				sl.Err(err))
+
+			if v.cfg.Responses == config.ResponseValidationFail {
+				server.InternalError("response does not match api contract", errors.Join(ErrContractViolation, err), w, r)
+				return
+			}
+		}
+
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(rec.body.Bytes())
+	})
+}
+
+// findRoute ищет операцию спецификации. chi принимает пути и с завершающим слешем, и без него,
+// а в спецификации они записаны без слеша.
+func (v *Validator) findRoute(r *http.Request) (*routers.Route, map[string]string, error) {
+	path := routepath.Normalize(r.URL.Path)
+	if path == r.URL.Path {
+		return v.router.FindRoute(r)
+	}
+
+	lookup := r.Clone(r.Context())
+	lookup.URL.Path = path
+	lookup.URL.RawPath = ""
+
+	return v.router.FindRoute(lookup)
+}
+
+// logger возвращает логгер запроса, если он есть в контексте.
+func (v *Validator) logger(r *http.Request) *slog.Logger {
+	return sl.FromContext(r.Context(), v.log).With(slog.String("op", op))
+}
+
+// violations раскладывает ошибку валидации на отдельные нарушения для ответа клиенту.
+// В сообщения не попадают сами значения, чтобы не возвращать и не логировать персональные данные.
+func violations(err error) []server.Violation {
+	var errs []error
+
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		errs = multi
+	} else {
+		errs = []error{err}
+	}
+
+	var result []server.Violation
+
+	for _, err := range errs {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(err, &reqErr) {
+			result = append(result, server.Violation{In: "body", Message: err.Error()})
+			continue
+		}
+
+		switch {
+		case reqErr.Parameter != nil:
+			result = append(result, server.Violation{
+				In:      reqErr.Parameter.In,
+				Field:   reqErr.Parameter.Name,
+				Message: reason(reqErr),
+			})
+		case reqErr.RequestBody != nil:
+			result = append(result, bodyViolations(reqErr)...)
+		default:
+			result = append(result, server.Violation{In: "body", Message: reason(reqErr)})
+		}
+	}
+
+	return result
+}
+
+func bodyViolations(reqErr *openapi3filter.RequestError) []server.Violation {
+	var schemaErrs []error
+
+	var multi openapi3.MultiError
+	if errors.As(reqErr.Err, &multi) {
+		schemaErrs = multi
+	} else {
+		schemaErrs = []error{reqErr.Err}
+	}
+
+	var result []server.Violation
+
+	for _, err := range schemaErrs {
+		var schemaErr *openapi3.SchemaError
+		if !errors.As(err, &schemaErr) {
+			result = append(result, server.Violation{In: "body", Message: reason(reqErr)})
+			continue
+		}
+
+		result = append(result, server.Violation{
+			In:      "body",
+			Field:   strings.Join(schemaErr.JSONPointer(), "."),
+			Message: schemaErr.Reason,
+		})
+	}
+
+	return result
+}
+
+func reason(reqErr *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		return schemaErr.Reason
+	}
+
+	// Текст ParseError начинается с разобранного значения, поэтому берётся только причина
+	var parseErr *openapi3filter.ParseError
+	if errors.As(reqErr.Err, &parseErr) {
+		if parseErr.Reason != "" {
+			return parseErr.Reason
+		}
+		return "invalid value"
+	}
+
+	if reqErr.Reason != "" {
+		return reqErr.Reason
+	}
+
+	if reqErr.Err != nil {
+		return reqErr.Err.Error()
+	}
+
+	return "invalid value"
+}
+
+// recorder буферизует ответ, чтобы проверить его до отправки клиенту.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.status = status
+	rec.wroteHeader = true
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(b)
+}
@@ -0,0 +1,195 @@
+package validator
+
+import (
+	"bytes"
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/http-server/common/server"
+	"contact-api/internal/app/openapi"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Небольшая спецификация: одна операция с телом и параметром запроса и одна с ответом по схеме
+const testSpec = `
+openapi: "3.0.3"
+info: {title: test, version: "1"}
+servers: [{url: /}]
+paths:
+  /v1/item:
+    post:
+      parameters:
+        - {name: limit, in: query, schema: {type: integer, minimum: 1}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string, minLength: 1}
+                count: {type: integer}
+      responses:
+        "201": {description: created}
+  /v1/item/{id}:
+    get:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+      responses:
+        "200":
+          description: item
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name: {type: string}
+`
+
+func newValidator(t *testing.T, cfg config.OpenAPIValidation) (*Validator, *bytes.Buffer) {
+	t.Helper()
+
+	spec, err := openapi.Parse(context.Background(), []byte(testSpec))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var buf bytes.Buffer
+	v, err := New(slog.New(slog.NewTextHandler(&buf, nil)), spec, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return v, &buf
+}
+
+// serve пропускает запрос через middleware; next отвечает status и body.
+func serve(v *Validator, req *http.Request, status int, body string) (*httptest.ResponseRecorder, bool) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	})
+
+	w := httptest.NewRecorder()
+	v.Handler(next).ServeHTTP(w, req)
+	return w, called
+}
+
+func post(path, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *http.Request
+		status     int
+		violations []string
+	}{
+		{name: "valid", req: post("/v1/item?limit=5", `{"name":"pen","count":2}`), status: http.StatusCreated},
+		{name: "body", req: post("/v1/item", `{"name":"","count":"secret-value"}`), status: http.StatusBadRequest, violations: []string{"body:count", "body:name"}},
+		{name: "required property", req: post("/v1/item", `{"count":1}`), status: http.StatusBadRequest, violations: []string{"body:name"}},
+		{name: "query", req: post("/v1/item?limit=secret-value", `{"name":"pen"}`), status: http.StatusBadRequest, violations: []string{"query:limit"}},
+		// chi принимает путь со слешем в конце, поэтому и проверять его нужно по той же операции
+		{name: "trailing slash", req: post("/v1/item/", `{"count":"secret-value"}`), status: http.StatusBadRequest, violations: []string{"body:count", "body:name"}},
+		{name: "unknown path", req: post("/v1/other", `{"count":"secret-value"}`), status: http.StatusCreated},
+		{name: "unknown method", req: httptest.NewRequest(http.MethodDelete, "/v1/item", nil), status: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, logs := newValidator(t, config.OpenAPIValidation{Requests: true})
+
+			w, called := serve(v, tt.req, http.StatusCreated, "")
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if called != (tt.status != http.StatusBadRequest) {
+				t.Errorf("next called: %v", called)
+			}
+
+			if tt.violations == nil {
+				return
+			}
+
+			// Отклонённые значения не возвращаются клиенту и не попадают в лог
+			if strings.Contains(w.Body.String(), "secret-value") || strings.Contains(logs.String(), "secret-value") {
+				t.Errorf("value echoed:\n%s\n%s", w.Body, logs)
+			}
+
+			var resp server.ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, vl := range resp.Violations {
+				got = append(got, vl.In+":"+vl.Field)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.violations) {
+				t.Errorf("violations %+v, want %v", resp.Violations, tt.violations)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		v, _ := newValidator(t, config.OpenAPIValidation{})
+
+		if w, called := serve(v, post("/v1/item", `{}`), http.StatusCreated, ""); !called || w.Code != http.StatusCreated {
+			t.Errorf("status %d, next called %v", w.Code, called)
+		}
+	})
+}
+
+func TestResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		body   string
+		status int
+		logged bool
+	}{
+		{name: "valid", mode: config.ResponseValidationFail, body: `{"name":"pen"}`, status: http.StatusOK},
+		{name: "fail", mode: config.ResponseValidationFail, body: `{"name":5}`, status: http.StatusInternalServerError, logged: true},
+		{name: "log", mode: config.ResponseValidationLog, body: `{"name":5}`, status: http.StatusOK, logged: true},
+		{name: "off", mode: config.ResponseValidationOff, body: `{"name":5}`, status: http.StatusOK},
+		{name: "trailing slash", mode: config.ResponseValidationFail, body: `{}`, status: http.StatusInternalServerError, logged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, logs := newValidator(t, config.OpenAPIValidation{Responses: tt.mode})
+
+			path := "/v1/item/1"
+			if tt.name == "trailing slash" {
+				path += "/"
+			}
+
+			w, _ := serve(v, httptest.NewRequest(http.MethodGet, path, nil), http.StatusOK, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			// В режиме log и без нарушений клиент получает ответ обработчика без изменений
+			if tt.status == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("body %q, want %q", w.Body, tt.body)
+			}
+
+			if logged := strings.Contains(logs.String(), "response does not match api contract"); logged != tt.logged {
+				t.Errorf("violation logged: %v\n%s", logged, logs)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	deleteAll "contact-api/internal/app/http-server/handlers/all/delete"
+	getAll "contact-api/internal/app/http-server/handlers/all/get"
+	importAll "contact-api/internal/app/http-server/handlers/all/import"
+	"contact-api/internal/app/http-server/handlers/all/save"
+	deleteField "contact-api/internal/app/http-server/handlers/fields/delete"
+	listFields "contact-api/internal/app/http-server/handlers/fields/list"
+	putField "contact-api/internal/app/http-server/handlers/fields/put"
+	createGroup "contact-api/internal/app/http-server/handlers/groups/create"
+	deleteGroup "contact-api/internal/app/http-server/handlers/groups/delete"
+	getGroup "contact-api/internal/app/http-server/handlers/groups/get"
+	listGroups "contact-api/internal/app/http-server/handlers/groups/list"
+	groupMembers "contact-api/internal/app/http-server/handlers/groups/members/list"
+	updateMembers "contact-api/internal/app/http-server/handlers/groups/members/update"
+	updateGroup "contact-api/internal/app/http-server/handlers/groups/update"
+	deleteOne "contact-api/internal/app/http-server/handlers/one/delete"
+	getOne "contact-api/internal/app/http-server/handlers/one/get"
+	"contact-api/internal/app/http-server/handlers/one/patch"
+	"contact-api/internal/app/http-server/handlers/one/update"
+	listTags "contact-api/internal/app/http-server/handlers/tags/list"
+	updateTags "contact-api/internal/app/http-server/handlers/tags/update"
+	"contact-api/internal/app/http-server/middleware/auth"
+	"contact-api/internal/app/http-server/middleware/cors"
+	"contact-api/internal/app/http-server/middleware/logger"
+	httpMetrics "contact-api/internal/app/http-server/middleware/metrics"
+	"contact-api/internal/app/http-server/middleware/ratelimit"
+	"contact-api/internal/app/http-server/middleware/recoverer"
+	"contact-api/internal/app/http-server/middleware/requestid"
+	httpTracing "contact-api/internal/app/http-server/middleware/tracing"
+	"contact-api/internal/app/http-server/middleware/validator"
+	"contact-api/internal/app/openapi"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"log/slog"
+)
+
+// Storage - операции хранилища, которые нужны обработчикам публичного API.
+type Storage interface {
+	getAll.ContactsAll
+	save.ContactSaver
+	deleteAll.ContactsDeleter
+	importAll.ContactsImporter
+	getOne.GetterByID
+	update.Updater
+	patch.Patcher
+	deleteOne.DeleterByID
+	listFields.FieldLister
+	putField.FieldPutter
+	deleteField.FieldDeleter
+	listGroups.GroupLister
+	createGroup.GroupCreator
+	getGroup.GroupGetter
+	updateGroup.GroupUpdater
+	deleteGroup.GroupDeleter
+	groupMembers.MemberLister
+	updateMembers.MemberUpdater
+	listTags.TagLister
+	updateTags.TagUpdater
+}
+
+// Deps - компоненты, из которых собирается публичный API. Все они меняют настройки на лету,
+// поэтому создаются снаружи и передаются готовыми.
+type Deps struct {
+	Storage    Storage
+	Limiter    *ratelimit.Limiter
+	Validator  *validator.Validator
+	CORS       *cors.Handler
+	AdminToken string
+	// Описание API для /openapi.json; Swagger UI к нему остаётся на admin-listener'е
+	Spec *openapi.Spec
+}
+
+// New собирает маршруты публичного API. Этим же конструктором пользуются тесты,
+// сверяющие маршруты со спецификацией, поэтому все маршруты регистрируются здесь.
+func New(log *slog.Logger, deps Deps) *chi.Mux {
+	storage := deps.Storage
+
+	router := chi.NewRouter()
+
+	router.Use(recoverer.New(log))
+	router.Use(middleware.RealIP)
+	router.Use(requestid.New)
+	router.Use(auth.New(deps.AdminToken))
+	router.Use(httpTracing.New)
+	router.Use(logger.New(log))
+	router.Use(httpMetrics.New)
+
+	// Применяем CORS middleware
+	router.Use(deps.CORS.Handler)
+
+	// Спецификация только читается и нужна клиентам и генераторам SDK, поэтому отдаётся и на публичном порту
+	router.Get("/openapi.json", deps.Spec.Handler())
+
+	router.Route("/v1/contact", func(r chi.Router) {
+		r.Use(deps.Limiter.Handler)
+		r.Use(deps.Validator.Handler)
+
+		r.Get("/", getAll.New(log, storage))
+		r.Post("/", save.New(log, storage))
+		r.Delete("/", deleteAll.New(log, storage))
+		r.Post("/import", importAll.New(log, storage))
+
+		r.Route("/{uid}", func(r chi.Router) {
+			r.Get("/", getOne.New(log, storage))
+			r.Delete("/", deleteOne.New(log, storage))
+			r.Put("/", update.New(log, storage))
+			r.Patch("/", patch.New(log, storage))
+		})
+	})
+
+	// Описания пользовательских полей читают все клиенты, а меняет только администратор
+	router.Route("/v1/field", func(r chi.Router) {
+		r.Use(deps.Limiter.Handler)
+		r.Use(deps.Validator.Handler)
+
+		r.Get("/", listFields.New(log, storage))
+
+		r.Route("/{name}", func(r chi.Router) {
+			r.Use(auth.RequireAdmin)
+
+			r.Put("/", putField.New(log, storage))
+			r.Delete("/", deleteField.New(log, storage))
+		})
+	})
+
+	router.Route("/v1/group", func(r chi.Router) {
+		r.Use(deps.Limiter.Handler)
+		r.Use(deps.Validator.Handler)
+
+		r.Get("/", listGroups.New(log, storage))
+		r.Post("/", createGroup.New(log, storage))
+
+		r.Route("/{gid}", func(r chi.Router) {
+			r.Get("/", getGroup.New(log, storage))
+			r.Put("/", updateGroup.New(log, storage))
+			r.Delete("/", deleteGroup.New(log, storage))
+
+			r.Get("/members", groupMembers.New(log, storage))
+			r.Patch("/members", updateMembers.New(log, storage))
+		})
+	})
+
+	router.Route("/v1/tag", func(r chi.Router) {
+		r.Use(deps.Limiter.Handler)
+		r.Use(deps.Validator.Handler)
+
+		r.Get("/", listTags.New(log, storage))
+		r.Patch("/", updateTags.New(log, storage))
+	})
+
+	return router
+}
@@ -0,0 +1,161 @@
+package loglevel
+
+import (
+	"contact-api/internal/pkg/principal"
+	"contact-api/internal/pkg/routepath"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrInvalidOverride  = errors.New("invalid log level override")
+	ErrOverrideNotFound = errors.New("log level override not found")
+)
+
+// Override временно понижает уровень логирования для запросов к маршруту,
+// запросов клиента или их сочетания, если заданы оба поля.
+type Override struct {
+	ID string `json:"id"`
+	// Шаблон маршрута chi, например /v1/contact/{uid}
+	Route string `json:"route,omitempty"`
+	// Имя аутентифицированного клиента. Сейчас клиентов различает только токен администратора,
+	// поэтому единственное значение, которое может совпасть, - auth.AdminPrincipal ("admin")
+	Principal string     `json:"principal,omitempty"`
+	Level     slog.Level `json:"level"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+// Overrides хранит действующие переопределения уровня.
+// Чтение выполняется на каждую запись лога, поэтому список заменяется целиком и читается без блокировок.
+type Overrides struct {
+	maxTTL time.Duration
+
+	mu   sync.Mutex
+	list atomic.Pointer[[]Override]
+}
+
+func NewOverrides(maxTTL time.Duration) *Overrides {
+	return &Overrides{maxTTL: maxTTL}
+}
+
+// Add регистрирует переопределение на ttl и возвращает его с присвоенным идентификатором.
+func (o *Overrides) Add(ov Override, ttl time.Duration) (Override, error) {
+	ov.Route = routepath.Normalize(ov.Route)
+
+	switch {
+	case ov.Route == "" && ov.Principal == "":
+		return Override{}, fmt.Errorf("%w: route or principal must be set", ErrInvalidOverride)
+	case ttl <= 0:
+		return Override{}, fmt.Errorf("%w: ttl must be positive", ErrInvalidOverride)
+	case ttl > o.maxTTL:
+		return Override{}, fmt.Errorf("%w: ttl must not exceed %s", ErrInvalidOverride, o.maxTTL)
+	}
+
+	ov.ID = newID()
+	ov.ExpiresAt = time.Now().Add(ttl).UTC()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	next := append(o.active(time.Now()), ov)
+	o.list.Store(&next)
+
+	return ov, nil
+}
+
+// Remove снимает переопределение до истечения срока.
+func (o *Overrides) Remove(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	list := o.active(time.Now())
+
+	i := slices.IndexFunc(list, func(ov Override) bool { return ov.ID == id })
+	if i < 0 {
+		return ErrOverrideNotFound
+	}
+
+	next := slices.Delete(list, i, i+1)
+	o.list.Store(&next)
+
+	return nil
+}
+
+// List возвращает переопределения, срок которых ещё не истёк.
+func (o *Overrides) List() []Override {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	list := o.active(time.Now())
+	o.list.Store(&list)
+
+	return slices.Clone(list)
+}
+
+// Level возвращает наименьший уровень среди переопределений, подходящих запросу из ctx.
+// Маршрут берётся из контекста chi, поэтому до завершения маршрутизации учитываются только переопределения по клиенту.
+func (o *Overrides) Level(ctx context.Context) (slog.Level, bool) {
+	list := o.list.Load()
+	if list == nil || len(*list) == 0 {
+		return 0, false
+	}
+
+	var route string
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		route = routepath.Normalize(rctx.RoutePattern())
+	}
+	name := principal.FromContext(ctx)
+	now := time.Now()
+
+	var (
+		level slog.Level
+		found bool
+	)
+
+	for _, ov := range *list {
+		if now.After(ov.ExpiresAt) {
+			continue
+		}
+		if ov.Route != "" && ov.Route != route {
+			continue
+		}
+		if ov.Principal != "" && ov.Principal != name {
+			continue
+		}
+
+		if !found || ov.Level < level {
+			level, found = ov.Level, true
+		}
+	}
+
+	return level, found
+}
+
+// active возвращает копию списка без истёкших переопределений. Вызывается под o.mu.
+func (o *Overrides) active(now time.Time) []Override {
+	var list []Override
+	if current := o.list.Load(); current != nil {
+		for _, ov := range *current {
+			if now.Before(ov.ExpiresAt) {
+				list = append(list, ov)
+			}
+		}
+	}
+
+	return list
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,108 @@
+package loglevel
+
+import (
+	"contact-api/internal/pkg/principal"
+	"context"
+	"errors"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// requestContext возвращает контекст запроса, прошедшего маршрутизацию chi по шаблону pattern.
+func requestContext(pattern, name string) context.Context {
+	rctx := chi.NewRouteContext()
+	rctx.RoutePatterns = []string{pattern}
+
+	ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+	if name != "" {
+		ctx = principal.WithPrincipal(ctx, name)
+	}
+	return ctx
+}
+
+func TestOverridesAdd(t *testing.T) {
+	o := NewOverrides(time.Hour)
+
+	tests := []struct {
+		name string
+		ov   Override
+		ttl  time.Duration
+	}{
+		{name: "no route or principal", ttl: time.Minute},
+		{name: "zero ttl", ov: Override{Route: "/v1/contact"}},
+		{name: "ttl over max", ov: Override{Route: "/v1/contact"}, ttl: 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		if _, err := o.Add(tt.ov, tt.ttl); !errors.Is(err, ErrInvalidOverride) {
+			t.Errorf("%s: err = %v, want ErrInvalidOverride", tt.name, err)
+		}
+	}
+
+	ov, err := o.Add(Override{Route: "/v1/contact//{uid}/", Level: slog.LevelDebug}, time.Minute)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if ov.ID == "" || ov.Route != "/v1/contact/{uid}" {
+		t.Errorf("added override %+v: want id and normalized route", ov)
+	}
+	if list := o.List(); len(list) != 1 || list[0].ID != ov.ID {
+		t.Errorf("List = %+v", list)
+	}
+}
+
+func TestOverridesLevel(t *testing.T) {
+	o := NewOverrides(time.Hour)
+
+	if _, ok := o.Level(context.Background()); ok {
+		t.Fatal("level found without overrides")
+	}
+
+	mustAdd := func(ov Override) Override {
+		t.Helper()
+		added, err := o.Add(ov, time.Minute)
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		return added
+	}
+
+	mustAdd(Override{Route: "/v1/contact/{uid}", Level: slog.LevelDebug})
+	mustAdd(Override{Principal: "admin", Level: slog.LevelInfo})
+	both := mustAdd(Override{Route: "/v1/group", Principal: "admin", Level: slog.LevelDebug - 4})
+
+	tests := []struct {
+		name      string
+		pattern   string
+		principal string
+		want      slog.Level
+		found     bool
+	}{
+		{name: "route", pattern: "/v1/contact/{uid}/", want: slog.LevelDebug, found: true},
+		{name: "principal", pattern: "/v1/tag", principal: "admin", want: slog.LevelInfo, found: true},
+		{name: "lowest of matching", pattern: "/v1/contact/{uid}", principal: "admin", want: slog.LevelDebug, found: true},
+		{name: "route and principal", pattern: "/v1/group", principal: "admin", want: slog.LevelDebug - 4, found: true},
+		{name: "route without principal", pattern: "/v1/group"},
+		{name: "no match", pattern: "/v1/tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, found := o.Level(requestContext(tt.pattern, tt.principal))
+			if found != tt.found || level != tt.want {
+				t.Errorf("Level = %v, %v; want %v, %v", level, found, tt.want, tt.found)
+			}
+		})
+	}
+
+	if err := o.Remove(both.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, found := o.Level(requestContext("/v1/group", "admin")); !found {
+		t.Error("principal override should still match after removing the combined one")
+	}
+	if err := o.Remove(both.ID); !errors.Is(err, ErrOverrideNotFound) {
+		t.Errorf("second Remove: err = %v, want ErrOverrideNotFound", err)
+	}
+}
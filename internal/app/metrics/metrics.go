@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+	"time"
+)
+
+const namespace = "contact_api"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route pattern, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	StorageOps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operations_total",
+		Help:      "Number of storage operations by method and result.",
+	}, []string{"operation", "result"})
+
+	StorageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "Storage operation latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	StorageConnectRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "connect_retries_total",
+		Help:      "Number of failed MongoDB pings retried during startup.",
+	})
+
+	StorageLazyMigrations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "lazy_migrations_total",
+		Help:      "Number of documents upgraded to the current schema on read, by migration version.",
+	}, []string{"version"})
+
+	poolConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "connections",
+		Help:      "Number of open connections in the MongoDB pool.",
+	}, []string{"address"})
+
+	poolInUse = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "connections_in_use",
+		Help:      "Number of connections checked out of the MongoDB pool.",
+	}, []string{"address"})
+
+	poolCheckoutFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "checkout_failures_total",
+		Help:      "Number of failed connection checkouts by reason.",
+	}, []string{"address", "reason"})
+)
+
+// ObserveStorage записывает длительность и результат одной операции хранилища.
+// Отмена запроса клиентом и истечение таймаута учитываются отдельно от ошибок.
+func ObserveStorage(operation string, start time.Time, err error) {
+	result := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		result = "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		result = "timeout"
+	default:
+		result = "error"
+	}
+
+	StorageOps.WithLabelValues(operation, result).Inc()
+	StorageDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// PoolMonitor возвращает монитор пула соединений драйвера, обновляющий gauge'и пула.
+func PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			switch evt.Type {
+			case event.ConnectionCreated:
+				poolConnections.WithLabelValues(evt.Address).Inc()
+			case event.ConnectionClosed:
+				poolConnections.WithLabelValues(evt.Address).Dec()
+			case event.GetSucceeded:
+				poolInUse.WithLabelValues(evt.Address).Inc()
+			case event.ConnectionReturned:
+				poolInUse.WithLabelValues(evt.Address).Dec()
+			case event.GetFailed:
+				poolCheckoutFailures.WithLabelValues(evt.Address, evt.Reason).Inc()
+			}
+			// PoolCleared не сбрасывает connections_in_use: выданные соединения ещё вернутся в пул
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.mongodb.org/mongo-driver/event"
+	"testing"
+	"time"
+)
+
+func TestObserveStorage(t *testing.T) {
+	tests := []struct {
+		err    error
+		result string
+	}{
+		{nil, "ok"},
+		{fmt.Errorf("find: %w", context.Canceled), "canceled"},
+		{fmt.Errorf("find: %w", context.DeadlineExceeded), "timeout"},
+		{errors.New("connection refused"), "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.result, func(t *testing.T) {
+			// Отдельная операция на каждый случай: счётчики глобальные
+			operation := "test_observe_" + tt.result
+			ObserveStorage(operation, time.Now(), tt.err)
+
+			if got := testutil.ToFloat64(StorageOps.WithLabelValues(operation, tt.result)); got != 1 {
+				t.Errorf("operations_total{result=%q} = %v, want 1", tt.result, got)
+			}
+			if got := testutil.CollectAndCount(StorageDuration, "contact_api_storage_operation_duration_seconds"); got == 0 {
+				t.Error("operation duration is not observed")
+			}
+		})
+	}
+}
+
+func TestPoolMonitor(t *testing.T) {
+	const addr = "pool-monitor-test:27017"
+	monitor := PoolMonitor()
+
+	emit := func(typ string) {
+		monitor.Event(&event.PoolEvent{Type: typ, Address: addr, Reason: event.ReasonTimedOut})
+	}
+
+	emit(event.ConnectionCreated)
+	emit(event.ConnectionCreated)
+	emit(event.GetSucceeded)
+	emit(event.GetSucceeded)
+	emit(event.GetFailed)
+
+	// Очистка пула не возвращает выданные соединения: они вернутся позже обычным ConnectionReturned
+	emit(event.PoolCleared)
+	if got := testutil.ToFloat64(poolInUse.WithLabelValues(addr)); got != 2 {
+		t.Errorf("in use after clear = %v, want 2", got)
+	}
+
+	emit(event.ConnectionReturned)
+	emit(event.ConnectionReturned)
+	emit(event.ConnectionClosed)
+
+	if got := testutil.ToFloat64(poolInUse.WithLabelValues(addr)); got != 0 {
+		t.Errorf("in use after checkin = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(poolConnections.WithLabelValues(addr)); got != 1 {
+		t.Errorf("open connections = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(poolCheckoutFailures.WithLabelValues(addr, event.ReasonTimedOut)); got != 1 {
+		t.Errorf("checkout failures = %v, want 1", got)
+	}
+}
@@ -0,0 +1,116 @@
+package openapi
+
+import (
+	"contact-api/internal/pkg/routepath"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Описание API поддерживается вручную; CheckRoutes не даёт ему разойтись с маршрутами.
+//
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Префикс маршрутов, которые обязаны быть описаны в спецификации
+const apiPrefix = "/v1/"
+
+var ErrRoutesMismatch = errors.New("openapi spec does not match registered routes")
+
+// Spec - проверенный документ OpenAPI.
+type Spec struct {
+	doc  *openapi3.T
+	json []byte
+}
+
+// Load разбирает встроенный документ и проверяет его корректность.
+func Load(ctx context.Context) (*Spec, error) {
+	return Parse(ctx, specYAML)
+}
+
+// Parse разбирает и проверяет документ data; нужен для спецификаций, отличных от встроенной, например в тестах.
+func Parse(ctx context.Context, data []byte) (*Spec, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse openapi spec: %w", err)
+	}
+
+	if err := doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+
+	out, err := doc.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi spec: %w", err)
+	}
+
+	return &Spec{doc: doc, json: out}, nil
+}
+
+// Doc возвращает разобранный документ.
+func (s *Spec) Doc() *openapi3.T {
+	return s.doc
+}
+
+// JSON возвращает документ в формате JSON.
+func (s *Spec) JSON() []byte {
+	return s.json
+}
+
+// Handler отдаёт документ по /openapi.json.
+func (s *Spec) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(s.json)
+	}
+}
+
+// CheckRoutes сравнивает маршруты API, зарегистрированные в chi, с операциями спецификации
+// и перечисляет все расхождения в обе стороны.
+func (s *Spec) CheckRoutes(routes chi.Routes) error {
+	registered := make(map[string]bool)
+
+	err := chi.Walk(routes, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = routepath.Normalize(route)
+		if strings.HasPrefix(route+"/", apiPrefix) && method != http.MethodOptions {
+			registered[method+" "+route] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk routes: %w", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range s.doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	var diff []string
+	for op := range registered {
+		if !documented[op] {
+			diff = append(diff, "not documented: "+op)
+		}
+	}
+	for op := range documented {
+		if !registered[op] {
+			diff = append(diff, "not registered: "+op)
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil
+	}
+
+	slices.Sort(diff)
+
+	return fmt.Errorf("%w:\n  %s", ErrRoutesMismatch, strings.Join(diff, "\n  "))
+}
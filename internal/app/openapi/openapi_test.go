@@ -0,0 +1,120 @@
+package openapi_test
+
+import (
+	"bytes"
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/http-server/middleware/cors"
+	"contact-api/internal/app/http-server/middleware/ratelimit"
+	"contact-api/internal/app/http-server/middleware/validator"
+	"contact-api/internal/app/http-server/router"
+	"contact-api/internal/app/openapi"
+	"context"
+	"errors"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func loadSpec(t *testing.T) *openapi.Spec {
+	t.Helper()
+
+	spec, err := openapi.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return spec
+}
+
+// newRouter собирает публичный API тем же конструктором, что и serve.
+// Хранилище не нужно: обработчики только регистрируются и не вызываются.
+func newRouter(t *testing.T, spec *openapi.Spec) *chi.Mux {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	validate, err := validator.New(log, spec, config.OpenAPIValidation{Requests: true, Responses: "off"})
+	if err != nil {
+		t.Fatalf("validator.New: %v", err)
+	}
+
+	return router.New(log, router.Deps{
+		Limiter:   ratelimit.New(log, config.RateLimit{}, nil),
+		Validator: validate,
+		CORS:      cors.New(log, config.CORS{}),
+		Spec:      spec,
+	})
+}
+
+func TestCheckRoutes(t *testing.T) {
+	spec := loadSpec(t)
+
+	if err := spec.CheckRoutes(newRouter(t, spec)); err != nil {
+		t.Fatalf("spec does not match the router:\n%v", err)
+	}
+}
+
+// Спецификация доступна клиентам на публичном порту, а не только на admin-listener'е.
+func TestPublicSpec(t *testing.T) {
+	spec := loadSpec(t)
+
+	w := httptest.NewRecorder()
+	newRouter(t, spec).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !bytes.Equal(w.Body.Bytes(), spec.JSON()) {
+		t.Error("served document differs from the embedded spec")
+	}
+}
+
+func TestCheckRoutesMismatch(t *testing.T) {
+	spec := loadSpec(t)
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	t.Run("undocumented route", func(t *testing.T) {
+		r := newRouter(t, spec)
+		r.Get("/v1/contact/{uid}/history/", noop)
+		r.Post("/v1/export", noop)
+
+		err := spec.CheckRoutes(r)
+		if !errors.Is(err, openapi.ErrRoutesMismatch) {
+			t.Fatalf("err = %v, want ErrRoutesMismatch", err)
+		}
+		for _, want := range []string{"not documented: GET /v1/contact/{uid}/history", "not documented: POST /v1/export"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error does not mention %q:\n%v", want, err)
+			}
+		}
+	})
+
+	t.Run("unregistered operation", func(t *testing.T) {
+		r := chi.NewRouter()
+		r.Get("/v1/contact", noop)
+
+		err := spec.CheckRoutes(r)
+		if !errors.Is(err, openapi.ErrRoutesMismatch) {
+			t.Fatalf("err = %v, want ErrRoutesMismatch", err)
+		}
+		if !strings.Contains(err.Error(), "not registered: DELETE /v1/contact/{uid}") {
+			t.Errorf("error does not list missing operations:\n%v", err)
+		}
+		if strings.Contains(err.Error()+"\n", "not registered: GET /v1/contact\n") {
+			t.Errorf("registered route reported as missing:\n%v", err)
+		}
+	})
+
+	t.Run("routes outside /v1 are ignored", func(t *testing.T) {
+		r := newRouter(t, spec)
+		r.Get("/internal/status", noop)
+		r.Get("/v10/contact", noop)
+
+		if err := spec.CheckRoutes(r); err != nil {
+			t.Errorf("unexpected mismatch:\n%v", err)
+		}
+	})
+}
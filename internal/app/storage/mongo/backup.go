@@ -0,0 +1,364 @@
+package mongo
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/e"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Выгрузка и загрузка идут по всей коллекции, поэтому таймауты отдельных операций к ним не применяются:
+// длительность ограничивает контекст команды.
+
+// ExportContacts передаёт в fn контакты, изменённые не раньше since, в порядке идентификаторов.
+// При нулевом since выгружаются все контакты.
+func (db *DB) ExportContacts(ctx context.Context, since time.Time, fn func(storage.ContactRecord) error) (err error) {
+	defer db.observe(ctx, "ExportContacts", time.Now(), &err)
+
+	filter := bson.D{}
+	if !since.IsZero() {
+		// $gte: документ, изменённый ровно в момент прошлой копии, лучше выгрузить повторно, чем потерять
+		filter = bson.D{{Key: "updated_at", Value: bson.D{{Key: "$gte", Value: since}}}}
+	}
+
+	cursor, err := db.contacts.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return e.Err("failed to export contacts", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		contact, err := decodeContact(cursor.Current)
+		if err != nil {
+			return e.Err("failed to decode contact", err)
+		}
+
+		if err := fn(storage.ContactRecord{Contact: RepoToContact(contact), UpdatedAt: contact.UpdatedAt}); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return e.Err("failed to export contacts", err)
+	}
+
+	return nil
+}
+
+// ExportQuotas передаёт в fn все счётчики квот.
+func (db *DB) ExportQuotas(ctx context.Context, fn func(storage.QuotaRecord) error) (err error) {
+	defer db.observe(ctx, "ExportQuotas", time.Now(), &err)
+
+	cursor, err := db.quotas.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return e.Err("failed to export quotas", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var quota Quota
+		if err := cursor.Decode(&quota); err != nil {
+			return e.Err("failed to decode quota", err)
+		}
+
+		if err := fn(storage.QuotaRecord{
+			Key:       quota.Key,
+			Day:       quota.Day,
+			Count:     quota.Count,
+			CreatedAt: quota.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return e.Err("failed to export quotas", err)
+	}
+
+	return nil
+}
+
+// ImportContacts записывает контакты с их исходными идентификаторами и членством в группах, заменяя существующие.
+// Повторная загрузка того же архива ничего не меняет. Число участников групп не меняется, см. RecountGroups.
+func (db *DB) ImportContacts(ctx context.Context, records []storage.ContactRecord) (err error) {
+	defer db.observe(ctx, "ImportContacts", time.Now(), &err)
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(records))
+	for _, r := range records {
+		contact, err := ContactToRepo(r.Contact)
+		if err != nil {
+			return e.Err("invalid contact id "+r.Contact.ID, err)
+		}
+		contact.UpdatedAt = r.UpdatedAt
+
+		contact.Groups, err = objectIDs(r.Contact.Groups)
+		if err != nil {
+			return e.Err("invalid group of contact "+r.Contact.ID, err)
+		}
+
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: contact.ID}}).
+			SetReplacement(contact).
+			SetUpsert(true))
+	}
+
+	if _, err := db.contacts.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return e.Err("failed to import contacts", err)
+	}
+
+	return nil
+}
+
+// ImportQuotas записывает счётчики квот, заменяя существующие.
+func (db *DB) ImportQuotas(ctx context.Context, records []storage.QuotaRecord) (err error) {
+	defer db.observe(ctx, "ImportQuotas", time.Now(), &err)
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(records))
+	for _, r := range records {
+		quota := Quota{
+			ID:        r.Key + "|" + r.Day,
+			Key:       r.Key,
+			Day:       r.Day,
+			Count:     r.Count,
+			CreatedAt: r.CreatedAt,
+		}
+
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: quota.ID}}).
+			SetReplacement(quota).
+			SetUpsert(true))
+	}
+
+	if _, err := db.quotas.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return e.Err("failed to import quotas", err)
+	}
+
+	return nil
+}
+
+// ExportFields передаёт в fn все описания пользовательских полей.
+func (db *DB) ExportFields(ctx context.Context, fn func(models.FieldDefinition) error) (err error) {
+	defer db.observe(ctx, "ExportFields", time.Now(), &err)
+
+	defs, err := db.FieldDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range defs {
+		if err := fn(def); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ImportFields записывает описания пользовательских полей, заменяя существующие, и создаёт их индексы.
+// В отличие от PutField тип поля может измениться: значения в архиве соответствуют описаниям из него же.
+func (db *DB) ImportFields(ctx context.Context, defs []models.FieldDefinition) (err error) {
+	defer db.observe(ctx, "ImportFields", time.Now(), &err)
+
+	if len(defs) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(defs))
+	for _, def := range defs {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: def.Name}}).
+			SetReplacement(Field{
+				Name:        def.Name,
+				Type:        def.Type,
+				Required:    def.Required,
+				Values:      def.Values,
+				Description: def.Description,
+				UpdatedAt:   time.Now().UTC(),
+			}).
+			SetUpsert(true))
+	}
+
+	if _, err := db.fields.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return e.Err("failed to import custom fields", err)
+	}
+
+	for _, def := range defs {
+		if err := db.createFieldIndex(ctx, def.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportGroups передаёт в fn все группы в порядке идентификаторов.
+func (db *DB) ExportGroups(ctx context.Context, fn func(models.Group) error) (err error) {
+	defer db.observe(ctx, "ExportGroups", time.Now(), &err)
+
+	cursor, err := db.groups.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return e.Err("failed to export groups", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var group Group
+		if err := cursor.Decode(&group); err != nil {
+			return e.Err("failed to decode group", err)
+		}
+
+		if err := fn(repoToGroup(group)); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return e.Err("failed to export groups", err)
+	}
+
+	return nil
+}
+
+// ImportGroups записывает группы с их исходными идентификаторами, заменяя имя, описание и цвет существующих.
+// Число участников сохраняется, а после загрузки контактов пересчитывается через RecountGroups.
+func (db *DB) ImportGroups(ctx context.Context, groups []models.Group) (err error) {
+	defer db.observe(ctx, "ImportGroups", time.Now(), &err)
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	writes := make([]mongo.WriteModel, 0, len(groups))
+	for _, g := range groups {
+		gid, err := primitive.ObjectIDFromHex(g.ID)
+		if err != nil {
+			return e.Err("invalid group id "+g.ID, err)
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: gid}}).
+			SetUpdate(bson.D{
+				{Key: "$set", Value: bson.D{
+					{Key: "name", Value: g.Name},
+					{Key: "description", Value: g.Description},
+					{Key: "color", Value: g.Color},
+					{Key: "updated_at", Value: now},
+				}},
+				{Key: "$setOnInsert", Value: bson.D{
+					{Key: "member_count", Value: 0},
+					{Key: "created_at", Value: now},
+				}},
+			}).
+			SetUpsert(true))
+	}
+
+	if _, err := db.groups.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return e.Err("failed to import groups", err)
+	}
+
+	return nil
+}
+
+// ExportDeletions передаёт в fn отметки об удалениях в промежутке [since, until) в порядке времени удаления.
+func (db *DB) ExportDeletions(ctx context.Context, since, until time.Time, fn func(storage.DeletionRecord) error) (err error) {
+	defer db.observe(ctx, "ExportDeletions", time.Now(), &err)
+
+	filter := bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$gte", Value: since}, {Key: "$lt", Value: until}}}}
+
+	cursor, err := db.deletions.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "deleted_at", Value: 1}}))
+	if err != nil {
+		return e.Err("failed to export deletions", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var deletion Deletion
+		if err := cursor.Decode(&deletion); err != nil {
+			return e.Err("failed to decode deletion", err)
+		}
+
+		if err := fn(storage.DeletionRecord{
+			Collection: deletion.Collection,
+			ID:         deletion.Target,
+			DeletedAt:  deletion.DeletedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return e.Err("failed to export deletions", err)
+	}
+
+	return nil
+}
+
+// ApplyDeletions удаляет документы по отметкам из архива. Членство в группах и значения полей
+// не трогает: изменённые удалением контакты попадают в ту же копию, см. DeleteGroup и DeleteField.
+// Отметки в этой базе не записываются.
+func (db *DB) ApplyDeletions(ctx context.Context, records []storage.DeletionRecord) (err error) {
+	defer db.observe(ctx, "ApplyDeletions", time.Now(), &err)
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	for _, r := range records {
+		switch r.Collection {
+		case storage.DeletedContacts:
+			filter := bson.D{}
+			if r.ID != "" {
+				oid, err := primitive.ObjectIDFromHex(r.ID)
+				if err != nil {
+					return e.Err("invalid contact id "+r.ID, err)
+				}
+				filter = bson.D{{Key: "_id", Value: oid}}
+			}
+
+			if _, err := db.contacts.DeleteMany(ctx, filter); err != nil {
+				return e.Err("failed to delete contacts", err)
+			}
+
+		case storage.DeletedGroups:
+			gid, err := primitive.ObjectIDFromHex(r.ID)
+			if err != nil {
+				return e.Err("invalid group id "+r.ID, err)
+			}
+
+			if _, err := db.groups.DeleteOne(ctx, bson.D{{Key: "_id", Value: gid}}); err != nil {
+				return e.Err("failed to delete group", err)
+			}
+
+		case storage.DeletedFields:
+			if _, err := db.fields.DeleteOne(ctx, bson.D{{Key: "_id", Value: r.ID}}); err != nil {
+				return e.Err("failed to delete custom field", err)
+			}
+
+			_, err := db.contacts.Indexes().DropOne(ctx, fieldIndexPrefix+r.ID)
+			if err != nil && !hasErrorCode(err, indexNotFoundCode) {
+				return e.Err("failed to drop custom field index", err)
+			}
+
+		default:
+			return fmt.Errorf("unknown deletion collection %q", r.Collection)
+		}
+	}
+
+	return nil
+}
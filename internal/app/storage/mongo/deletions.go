@@ -0,0 +1,70 @@
+package mongo
+
+import (
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/e"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Отметки об удалении нужны только инкрементальным резервным копиям: удалённого документа уже нет,
+// и выборка по updated_at его не найдёт. Отметка пишется после удаления и со временем его завершения:
+// если документ ещё попал в копию, отметка окажется позже её границы и попадёт в следующую.
+// Если запись отметки не удалась, операция возвращает ошибку, а удаление попадёт только в следующую полную копию.
+
+// Deletion - отметка об удалении документа. _id составлен из коллекции и идентификатора,
+// поэтому повторное удаление обновляет отметку, а не добавляет новую.
+type Deletion struct {
+	ID         string    `bson:"_id"`
+	Collection string    `bson:"collection"`
+	Target     string    `bson:"target"`
+	DeletedAt  time.Time `bson:"deleted_at"`
+}
+
+// recordDeletion записывает отметку об удалении документа id из collection.
+func (db *DB) recordDeletion(ctx context.Context, collection, id string, at time.Time) error {
+	_, err := db.deletions.ReplaceOne(ctx,
+		bson.D{{Key: "_id", Value: collection + "|" + id}},
+		Deletion{ID: collection + "|" + id, Collection: collection, Target: id, DeletedAt: at},
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return e.Err("failed to record deletion", err)
+	}
+
+	return nil
+}
+
+// recordDeleteAll записывает отметку об удалении всех контактов. Отметки об удалении отдельных
+// контактов до этого момента она перекрывает, поэтому они удаляются.
+func (db *DB) recordDeleteAll(ctx context.Context, at time.Time) error {
+	if err := db.recordDeletion(ctx, storage.DeletedContacts, "", at); err != nil {
+		return err
+	}
+
+	_, err := db.deletions.DeleteMany(ctx, bson.D{
+		{Key: "collection", Value: storage.DeletedContacts},
+		{Key: "target", Value: bson.D{{Key: "$ne", Value: ""}}},
+		{Key: "deleted_at", Value: bson.D{{Key: "$lte", Value: at}}},
+	})
+	if err != nil {
+		return e.Err("failed to compact deletions", err)
+	}
+
+	return nil
+}
+
+// ensureDeletionIndexes создаёт индекс по времени удаления для выгрузки инкрементальных копий.
+func (db *DB) ensureDeletionIndexes(ctx context.Context) error {
+	_, err := db.deletions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "deleted_at", Value: 1}},
+	})
+	if err != nil {
+		return e.Err("failed to create deletion indexes", err)
+	}
+
+	return nil
+}
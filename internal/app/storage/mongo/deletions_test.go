@@ -0,0 +1,149 @@
+package mongo
+
+import (
+	"contact-api/internal/app/storage"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+// mockDeletionsDB - хранилище, все коллекции которого отвечают из mt.
+func mockDeletionsDB(mt *mtest.T) *DB {
+	db := &DB{
+		log:       discardLogger(),
+		contacts:  mt.Coll,
+		groups:    mt.Coll,
+		deletions: mt.Coll,
+	}
+	db.SetTimeouts(testTimeouts())
+	return db
+}
+
+// startedCommands возвращает отправленные команды по порядку.
+func startedCommands(mt *mtest.T) []bson.Raw {
+	var commands []bson.Raw
+	for ev := mt.GetStartedEvent(); ev != nil; ev = mt.GetStartedEvent() {
+		commands = append(commands, ev.Command)
+	}
+	return commands
+}
+
+func TestDeleteRecordsDeletion(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("one contact", func(mt *mtest.T) {
+		oid := primitive.NewObjectID()
+
+		// findAndModify, затем upsert отметки
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: oid}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		if _, err := mockDeletionsDB(mt).Delete(context.Background(), oid.Hex()); err != nil {
+			mt.Fatalf("Delete: %v", err)
+		}
+
+		commands := startedCommands(mt)
+		if len(commands) != 2 {
+			mt.Fatalf("sent %d commands, want 2", len(commands))
+		}
+
+		update := commands[1].Lookup("updates", "0")
+		if id := update.Document().Lookup("q", "_id").StringValue(); id != "contacts|"+oid.Hex() {
+			mt.Errorf("deletion _id = %q", id)
+		}
+		if target := update.Document().Lookup("u", "target").StringValue(); target != oid.Hex() {
+			mt.Errorf("deletion target = %q", target)
+		}
+		if upsert, _ := update.Document().Lookup("upsert").BooleanOK(); !upsert {
+			mt.Error("deletion is not upserted")
+		}
+	})
+
+	mt.Run("all contacts", func(mt *mtest.T) {
+		// delete контактов, update групп, upsert отметки и delete перекрытых отметок
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 5}),
+		)
+
+		if n, err := mockDeletionsDB(mt).DeleteAll(context.Background()); err != nil || n != 2 {
+			mt.Fatalf("DeleteAll = %d, %v", n, err)
+		}
+
+		commands := startedCommands(mt)
+		if len(commands) != 4 {
+			mt.Fatalf("sent %d commands, want 4", len(commands))
+		}
+
+		wipe := commands[2].Lookup("updates", "0", "u").Document()
+		if id := wipe.Lookup("_id").StringValue(); id != "contacts|" {
+			mt.Errorf("wipe _id = %q", id)
+		}
+		at := wipe.Lookup("deleted_at").Time()
+
+		// Отметка об удалении всех контактов не должна удалить сама себя
+		filter := commands[3].Lookup("deletes", "0", "q").Document()
+		if ne := filter.Lookup("target", "$ne").StringValue(); ne != "" {
+			mt.Errorf("compaction filter target = %s", filter)
+		}
+		if lte := filter.Lookup("deleted_at", "$lte").Time(); !lte.Equal(at) {
+			mt.Errorf("compaction bound %s, wipe at %s", lte, at)
+		}
+	})
+}
+
+func TestApplyDeletions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("contacts", func(mt *mtest.T) {
+		oid := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		err := mockDeletionsDB(mt).ApplyDeletions(context.Background(), []storage.DeletionRecord{
+			{Collection: storage.DeletedContacts, DeletedAt: time.Now()},
+			{Collection: storage.DeletedContacts, ID: oid.Hex(), DeletedAt: time.Now()},
+		})
+		if err != nil {
+			mt.Fatalf("ApplyDeletions: %v", err)
+		}
+
+		commands := startedCommands(mt)
+		if len(commands) != 2 {
+			mt.Fatalf("sent %d commands, want 2", len(commands))
+		}
+		if all := commands[0].Lookup("deletes", "0", "q").Document(); len(mustElements(mt, all)) != 0 {
+			mt.Errorf("delete of all contacts has filter %s", all)
+		}
+		if id := commands[1].Lookup("deletes", "0", "q", "_id").ObjectID(); id != oid {
+			mt.Errorf("deleted contact %s, want %s", id.Hex(), oid.Hex())
+		}
+	})
+
+	mt.Run("unknown collection", func(mt *mtest.T) {
+		err := mockDeletionsDB(mt).ApplyDeletions(context.Background(), []storage.DeletionRecord{{Collection: "quotas", ID: "k"}})
+		if err == nil {
+			mt.Fatal("unknown collection is accepted")
+		}
+		if commands := startedCommands(mt); len(commands) != 0 {
+			mt.Errorf("sent %d commands", len(commands))
+		}
+	})
+}
+
+func mustElements(mt *mtest.T, doc bson.Raw) []bson.RawElement {
+	elems, err := doc.Elements()
+	if err != nil {
+		mt.Fatal(err)
+	}
+	return elems
+}
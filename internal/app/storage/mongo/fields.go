@@ -0,0 +1,184 @@
+package mongo
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/e"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+// Field - описание пользовательского поля; имя служит идентификатором.
+type Field struct {
+	Name        string    `bson:"_id"`
+	Type        string    `bson:"type"`
+	Required    bool      `bson:"required"`
+	Values      []string  `bson:"values,omitempty"`
+	Description string    `bson:"description,omitempty"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+// Префиксы пути значений пользовательских полей в документе контакта и имён их индексов
+const (
+	fieldsPath       = "fields."
+	fieldIndexPrefix = "fields_"
+)
+
+// Коды ошибок MongoDB
+const (
+	indexNotFoundCode = 27
+	// Индекс с теми же ключами уже есть под другим именем
+	indexOptionsConflictCode = 85
+)
+
+// FieldDefinitions возвращает описания пользовательских полей в порядке имён.
+func (db *DB) FieldDefinitions(ctx context.Context) (_ []models.FieldDefinition, err error) {
+	defer db.observe(ctx, "FieldDefinitions", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().FieldDefinitions)
+	defer cancel()
+
+	cursor, err := db.fields.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, e.Err("failed to get custom fields", err)
+	}
+
+	var fields []Field
+	if err := cursor.All(ctx, &fields); err != nil {
+		return nil, e.Err("failed to decode custom fields", err)
+	}
+
+	defs := make([]models.FieldDefinition, len(fields))
+	for i, f := range fields {
+		defs[i] = models.FieldDefinition{
+			Name:        f.Name,
+			Type:        f.Type,
+			Required:    f.Required,
+			Values:      f.Values,
+			Description: f.Description,
+		}
+	}
+
+	return defs, nil
+}
+
+// PutField создаёт или заменяет описание поля и создаёт индекс для фильтрации и сортировки по нему.
+// Изменить тип существующего поля нельзя: сохранённые значения перестали бы ему соответствовать.
+// Новые ограничения (required, values) проверяются только при следующей записи контакта.
+func (db *DB) PutField(ctx context.Context, def models.FieldDefinition) (created bool, err error) {
+	defer db.observe(ctx, "PutField", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().PutField)
+	defer cancel()
+
+	// Документ с тем же именем, но другим типом не подходит под фильтр, и upsert упирается в _id
+	filter := bson.D{{Key: "_id", Value: def.Name}, {Key: "type", Value: def.Type}}
+	field := Field{
+		Name:        def.Name,
+		Type:        def.Type,
+		Required:    def.Required,
+		Values:      def.Values,
+		Description: def.Description,
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	result, err := db.fields.ReplaceOne(ctx, filter, field, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, storage.ErrFieldTypeChanged
+	}
+	if err != nil {
+		return false, e.Err("failed to save custom field", err)
+	}
+
+	if err := db.createFieldIndex(ctx, def.Name); err != nil {
+		return false, err
+	}
+
+	return result.UpsertedCount > 0, nil
+}
+
+// DeleteField удаляет описание поля, его значения во всех контактах и его индекс.
+func (db *DB) DeleteField(ctx context.Context, name string) (err error) {
+	defer db.observe(ctx, "DeleteField", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().DeleteField)
+	defer cancel()
+
+	// Описание удаляется первым, чтобы новые записи уже не принимали значения поля
+	result, err := db.fields.DeleteOne(ctx, bson.D{{Key: "_id", Value: name}})
+	if err != nil {
+		return e.Err("failed to delete custom field", err)
+	}
+	if result.DeletedCount == 0 {
+		return storage.ErrFieldNotFound
+	}
+
+	// updated_at меняется, чтобы инкрементальная резервная копия увидела очищенные контакты
+	_, err = db.contacts.UpdateMany(ctx,
+		bson.D{{Key: fieldsPath + name, Value: bson.D{{Key: "$exists", Value: true}}}},
+		bson.D{
+			{Key: "$unset", Value: bson.D{{Key: fieldsPath + name, Value: ""}}},
+			{Key: "$set", Value: bson.D{{Key: "updated_at", Value: time.Now().UTC()}}},
+		},
+	)
+	if err != nil {
+		return e.Err("failed to remove custom field values", err)
+	}
+
+	_, err = db.contacts.Indexes().DropOne(ctx, fieldIndexPrefix+name)
+	if err != nil && !hasErrorCode(err, indexNotFoundCode) {
+		return e.Err("failed to drop custom field index", err)
+	}
+
+	if err := db.recordDeletion(ctx, storage.DeletedFields, name, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ensureFieldIndexes создаёт индексы полей, описания которых появились, пока сервис был остановлен,
+// или для которых создание индекса в PutField не завершилось.
+func (db *DB) ensureFieldIndexes(ctx context.Context) error {
+	defs, err := db.FieldDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range defs {
+		if err := db.createFieldIndex(ctx, def.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createFieldIndex создаёт индекс по значению поля и _id: второй ключ нужен для постраничной сортировки.
+func (db *DB) createFieldIndex(ctx context.Context, name string) error {
+	_, err := db.contacts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: fieldsPath + name, Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName(fieldIndexPrefix + name),
+	})
+	if err != nil && !hasErrorCode(err, indexOptionsConflictCode) {
+		return e.Err("failed to create custom field index", err)
+	}
+
+	return nil
+}
+
+// isFieldIndex сообщает, что индексом управляют описания пользовательских полей, а не схема коллекции.
+func isFieldIndex(spec *mongo.IndexSpecification) bool {
+	elems, err := spec.KeysDocument.Elements()
+	return err == nil && len(elems) > 0 && strings.HasPrefix(elems[0].Key(), fieldsPath)
+}
+
+func hasErrorCode(err error, code int) bool {
+	var se mongo.ServerError
+	return errors.As(err, &se) && se.HasErrorCode(code)
+}
@@ -0,0 +1,130 @@
+package mongo
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+// Поле, значения которого ещё есть в контактах, удаляется вместе со значениями.
+func TestDeleteFieldInUse(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mockFieldsDB := func(mt *mtest.T) *DB {
+		db := mockDeletionsDB(mt)
+		db.fields = mt.Coll
+		return db
+	}
+
+	mt.Run("values removed", func(mt *mtest.T) {
+		// delete описания, $unset значений, dropIndexes, upsert отметки
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}, bson.E{Key: "nModified", Value: 3}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		if err := mockFieldsDB(mt).DeleteField(context.Background(), "crm_id"); err != nil {
+			mt.Fatalf("DeleteField: %v", err)
+		}
+
+		commands := startedCommands(mt)
+		if len(commands) != 4 {
+			mt.Fatalf("sent %d commands, want 4", len(commands))
+		}
+
+		update := commands[1].Lookup("updates", "0").Document()
+		if _, err := update.LookupErr("q", "fields.crm_id", "$exists"); err != nil {
+			mt.Errorf("values are not selected by field: %s", update)
+		}
+		if _, err := update.LookupErr("u", "$unset", "fields.crm_id"); err != nil {
+			mt.Errorf("values are not unset: %s", update)
+		}
+		if index := commands[2].Lookup("index").StringValue(); index != "fields_crm_id" {
+			mt.Errorf("dropped index %q", index)
+		}
+	})
+
+	mt.Run("index already dropped", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: indexNotFoundCode, Message: "index not found"}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		if err := mockFieldsDB(mt).DeleteField(context.Background(), "crm_id"); err != nil {
+			mt.Errorf("DeleteField: %v", err)
+		}
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+
+		if err := mockFieldsDB(mt).DeleteField(context.Background(), "crm_id"); !errors.Is(err, storage.ErrFieldNotFound) {
+			mt.Errorf("DeleteField = %v", err)
+		}
+		if commands := startedCommands(mt); len(commands) != 1 {
+			mt.Errorf("values touched for a missing field: %d commands", len(commands))
+		}
+	})
+}
+
+func TestDeleteFieldRemovesValues(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, def := range []models.FieldDefinition{
+		{Name: "crm_id", Type: models.FieldString},
+		{Name: "score", Type: models.FieldNumber},
+	} {
+		if _, err := db.PutField(ctx, def); err != nil {
+			t.Fatalf("PutField: %v", err)
+		}
+	}
+
+	id, err := db.Save(ctx, models.Contact{UserName: "ann", Fields: map[string]any{"crm_id": "A-1", "score": 4.5}})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Тип используемого поля не меняется
+	if _, err := db.PutField(ctx, models.FieldDefinition{Name: "crm_id", Type: models.FieldNumber}); !errors.Is(err, storage.ErrFieldTypeChanged) {
+		t.Errorf("PutField with another type = %v", err)
+	}
+
+	if err := db.DeleteField(ctx, "crm_id"); err != nil {
+		t.Fatalf("DeleteField: %v", err)
+	}
+
+	contact, err := db.ContactById(ctx, id)
+	if err != nil {
+		t.Fatalf("ContactById: %v", err)
+	}
+	if _, ok := contact.Fields["crm_id"]; ok || contact.Fields["score"] != 4.5 {
+		t.Errorf("fields after delete = %v", contact.Fields)
+	}
+
+	defs, err := db.FieldDefinitions(ctx)
+	if err != nil {
+		t.Fatalf("FieldDefinitions: %v", err)
+	}
+	if len(defs) != 1 || defs[0].Name != "score" {
+		t.Errorf("definitions after delete = %+v", defs)
+	}
+
+	specs, err := db.contacts.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		t.Fatalf("ListSpecifications: %v", err)
+	}
+	for _, spec := range specs {
+		if spec.Name == fieldIndexPrefix+"crm_id" {
+			t.Error("index of the deleted field is left")
+		}
+	}
+}
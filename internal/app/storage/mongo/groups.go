@@ -0,0 +1,459 @@
+package mongo
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"contact-api/internal/pkg/e"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Group - группа контактов. Членство хранится в Contact.Groups, а MemberCount
+// меняется вместе с ним, чтобы число участников не приходилось считать по контактам.
+type Group struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	Color       string             `bson:"color,omitempty"`
+	MemberCount int64              `bson:"member_count"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at"`
+}
+
+func repoToGroup(g Group) models.Group {
+	return models.Group{
+		ID:          g.ID.Hex(),
+		Name:        g.Name,
+		Description: g.Description,
+		Color:       g.Color,
+		MemberCount: g.MemberCount,
+	}
+}
+
+// Groups возвращает все группы в порядке имён.
+func (db *DB) Groups(ctx context.Context) (_ []models.Group, err error) {
+	defer db.observe(ctx, "Groups", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Groups)
+	defer cancel()
+
+	cursor, err := db.groups.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, e.Err("failed to get groups", err)
+	}
+
+	var groups []Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, e.Err("failed to decode groups", err)
+	}
+
+	result := make([]models.Group, len(groups))
+	for i, g := range groups {
+		result[i] = repoToGroup(g)
+	}
+
+	return result, nil
+}
+
+// Group возвращает группу по идентификатору; некорректный идентификатор считается отсутствующей группой.
+func (db *DB) Group(ctx context.Context, id string) (_ models.Group, err error) {
+	defer db.observe(ctx, "Group", time.Now(), &err)
+
+	gid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.Group{}, storage.ErrGroupNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Groups)
+	defer cancel()
+
+	var group Group
+	if err := db.groups.FindOne(ctx, bson.D{{Key: "_id", Value: gid}}).Decode(&group); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Group{}, storage.ErrGroupNotFound
+		}
+
+		return models.Group{}, e.Err("failed to get group", err)
+	}
+
+	return repoToGroup(group), nil
+}
+
+// CreateGroup создаёт пустую группу и возвращает её идентификатор. Имена групп уникальны.
+func (db *DB) CreateGroup(ctx context.Context, group models.Group) (_ string, err error) {
+	defer db.observe(ctx, "CreateGroup", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().SaveGroup)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	result, err := db.groups.InsertOne(ctx, Group{
+		Name:        group.Name,
+		Description: group.Description,
+		Color:       group.Color,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return "", storage.ErrGroupExists
+	}
+	if err != nil {
+		return "", e.Err("failed to insert group", err)
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// UpdateGroup меняет имя, описание и цвет группы; членство и число участников не затрагиваются.
+func (db *DB) UpdateGroup(ctx context.Context, group models.Group) (err error) {
+	defer db.observe(ctx, "UpdateGroup", time.Now(), &err)
+
+	gid, err := primitive.ObjectIDFromHex(group.ID)
+	if err != nil {
+		return storage.ErrGroupNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().SaveGroup)
+	defer cancel()
+
+	result, err := db.groups.UpdateOne(ctx, bson.D{{Key: "_id", Value: gid}}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "name", Value: group.Name},
+		{Key: "description", Value: group.Description},
+		{Key: "color", Value: group.Color},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	}}})
+	if mongo.IsDuplicateKeyError(err) {
+		return storage.ErrGroupExists
+	}
+	if err != nil {
+		return e.Err("failed to update group", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return storage.ErrGroupNotFound
+	}
+
+	return nil
+}
+
+// DeleteGroup удаляет группу и убирает её из всех контактов. Возвращает число контактов, бывших в группе.
+func (db *DB) DeleteGroup(ctx context.Context, id string) (_ int64, err error) {
+	defer db.observe(ctx, "DeleteGroup", time.Now(), &err)
+
+	gid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, storage.ErrGroupNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().DeleteGroup)
+	defer cancel()
+
+	// Группа удаляется первой: UpdateMembers, начатый одновременно, увидит это и откатит свои изменения
+	result, err := db.groups.DeleteOne(ctx, bson.D{{Key: "_id", Value: gid}})
+	if err != nil {
+		return 0, e.Err("failed to delete group", err)
+	}
+	if result.DeletedCount == 0 {
+		return 0, storage.ErrGroupNotFound
+	}
+
+	detached, err := db.pullGroup(ctx, gid, bson.D{{Key: "groups", Value: gid}})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := db.recordDeletion(ctx, storage.DeletedGroups, gid.Hex(), time.Now().UTC()); err != nil {
+		return 0, err
+	}
+
+	return detached, nil
+}
+
+// UpdateMembers добавляет контакты add в группу и убирает из неё контакты remove,
+// меняя число участников на фактическое изменение. Возвращает группу после изменения.
+func (db *DB) UpdateMembers(ctx context.Context, id string, add, remove []string) (_ models.Group, _ storage.MembershipChange, err error) {
+	defer db.observe(ctx, "UpdateMembers", time.Now(), &err)
+
+	var change storage.MembershipChange
+
+	gid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.Group{}, change, storage.ErrGroupNotFound
+	}
+
+	addIDs, err := objectIDs(add)
+	if err != nil {
+		return models.Group{}, change, err
+	}
+	removeIDs, err := objectIDs(remove)
+	if err != nil {
+		return models.Group{}, change, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().UpdateMembers)
+	defer cancel()
+
+	if err := db.groups.FindOne(ctx, bson.D{{Key: "_id", Value: gid}}).Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Group{}, change, storage.ErrGroupNotFound
+		}
+		return models.Group{}, change, e.Err("failed to get group", err)
+	}
+
+	now := time.Now().UTC()
+
+	if len(addIDs) > 0 {
+		// Условие на groups отсекает контакты, уже бывшие в группе, поэтому ModifiedCount - число новых участников
+		result, err := db.contacts.UpdateMany(ctx,
+			bson.D{
+				{Key: "_id", Value: bson.D{{Key: "$in", Value: addIDs}}},
+				{Key: "groups", Value: bson.D{{Key: "$ne", Value: gid}}},
+			},
+			bson.D{
+				{Key: "$push", Value: bson.D{{Key: "groups", Value: gid}}},
+				{Key: "$set", Value: bson.D{{Key: "updated_at", Value: now}}},
+			},
+		)
+		if err != nil {
+			return models.Group{}, change, e.Err("failed to add group members", err)
+		}
+		change.Added = result.ModifiedCount
+	}
+
+	if len(removeIDs) > 0 {
+		removed, err := db.pullGroup(ctx, gid, bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$in", Value: removeIDs}}},
+			{Key: "groups", Value: gid},
+		})
+		if err != nil {
+			return models.Group{}, change, err
+		}
+		change.Removed = removed
+	}
+
+	var group Group
+	err = db.groups.FindOneAndUpdate(ctx,
+		bson.D{{Key: "_id", Value: gid}},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "member_count", Value: change.Added - change.Removed}}},
+			{Key: "$set", Value: bson.D{{Key: "updated_at", Value: now}}},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&group)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Группу удалили во время изменения: DeleteGroup мог не увидеть только что добавленных участников
+		if _, err := db.pullGroup(ctx, gid, bson.D{{Key: "groups", Value: gid}}); err != nil {
+			return models.Group{}, change, err
+		}
+		return models.Group{}, storage.MembershipChange{}, storage.ErrGroupNotFound
+	}
+	if err != nil {
+		return models.Group{}, change, e.Err("failed to update group member count", err)
+	}
+
+	return repoToGroup(group), change, nil
+}
+
+// pullGroup убирает группу из контактов, подходящих под filter, и возвращает их число.
+func (db *DB) pullGroup(ctx context.Context, gid primitive.ObjectID, filter bson.D) (int64, error) {
+	result, err := db.contacts.UpdateMany(ctx, filter, bson.D{
+		{Key: "$pull", Value: bson.D{{Key: "groups", Value: gid}}},
+		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: time.Now().UTC()}}},
+	})
+	if err != nil {
+		return 0, e.Err("failed to remove group members", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// decrementGroups уменьшает число участников групп удалённого контакта.
+func (db *DB) decrementGroups(ctx context.Context, groups []primitive.ObjectID) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	_, err := db.groups.UpdateMany(ctx,
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: groups}}}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "member_count", Value: -1}}}},
+	)
+	if err != nil {
+		return e.Err("failed to update group member counts", err)
+	}
+
+	return nil
+}
+
+// RecountGroups пересчитывает число участников всех групп по контактам.
+// Нужен после загрузки контактов в обход UpdateMembers, например при восстановлении из копии.
+func (db *DB) RecountGroups(ctx context.Context) (err error) {
+	defer db.observe(ctx, "RecountGroups", time.Now(), &err)
+
+	cursor, err := db.contacts.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$unwind", Value: "$groups"}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$groups"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	})
+	if err != nil {
+		return e.Err("failed to count group members", err)
+	}
+
+	var counts []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return e.Err("failed to count group members", err)
+	}
+
+	writes := []mongo.WriteModel{
+		mongo.NewUpdateManyModel().
+			SetFilter(bson.D{}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "member_count", Value: 0}}}}),
+	}
+	for _, c := range counts {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: c.ID}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "member_count", Value: c.Count}}}}))
+	}
+
+	// Упорядоченно: обнуление должно выполниться раньше записи счётчиков
+	if _, err := db.groups.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(true)); err != nil {
+		return e.Err("failed to update group member counts", err)
+	}
+
+	return nil
+}
+
+// Tags возвращает все метки контактов с числом контактов у каждой, в порядке меток.
+func (db *DB) Tags(ctx context.Context) (_ []storage.TagCount, err error) {
+	defer db.observe(ctx, "Tags", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Tags)
+	defer cancel()
+
+	cursor, err := db.contacts.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$tags"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, e.Err("failed to count tags", err)
+	}
+
+	var counts []struct {
+		Tag   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, e.Err("failed to count tags", err)
+	}
+
+	result := make([]storage.TagCount, len(counts))
+	for i, c := range counts {
+		result[i] = storage.TagCount(c)
+	}
+
+	return result, nil
+}
+
+// UpdateTags убирает метки remove и добавляет метки add у контактов ids.
+// Added и Removed - число контактов, изменённых каждой из операций. Контакты,
+// у которых после добавления меток стало бы больше models.MaxTags, не меняются.
+func (db *DB) UpdateTags(ctx context.Context, ids, add, remove []string) (_ storage.MembershipChange, err error) {
+	defer db.observe(ctx, "UpdateTags", time.Now(), &err)
+
+	var change storage.MembershipChange
+
+	contactIDs, err := objectIDs(ids)
+	if err != nil {
+		return change, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().UpdateMembers)
+	defer cancel()
+
+	byID := bson.E{Key: "_id", Value: bson.D{{Key: "$in", Value: contactIDs}}}
+	now := time.Now().UTC()
+
+	// $addToSet и $pull над одним полем нельзя выполнить одним обновлением.
+	// Удаление идёт первым, чтобы освободившиеся места учитывались в ограничении на число меток.
+	// Условия на tags отсекают контакты, которые не изменятся, иначе их посчитал бы ModifiedCount из-за updated_at
+	if len(remove) > 0 {
+		result, err := db.contacts.UpdateMany(ctx,
+			bson.D{byID, {Key: "tags", Value: bson.D{{Key: "$in", Value: remove}}}},
+			bson.D{
+				{Key: "$pull", Value: bson.D{{Key: "tags", Value: bson.D{{Key: "$in", Value: remove}}}}},
+				{Key: "$set", Value: bson.D{{Key: "updated_at", Value: now}}},
+			},
+		)
+		if err != nil {
+			return change, e.Err("failed to remove tags", err)
+		}
+		change.Removed = result.ModifiedCount
+	}
+
+	if len(add) > 0 {
+		result, err := db.contacts.UpdateMany(ctx,
+			bson.D{
+				byID,
+				{Key: "tags", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$all", Value: add}}}}},
+				{Key: "$expr", Value: bson.D{{Key: "$lte", Value: bson.A{
+					bson.D{{Key: "$size", Value: bson.D{{Key: "$setUnion", Value: bson.A{
+						bson.D{{Key: "$ifNull", Value: bson.A{"$tags", bson.A{}}}},
+						add,
+					}}}}},
+					models.MaxTags,
+				}}}},
+			},
+			bson.D{
+				{Key: "$addToSet", Value: bson.D{{Key: "tags", Value: bson.D{{Key: "$each", Value: add}}}}},
+				{Key: "$set", Value: bson.D{{Key: "updated_at", Value: now}}},
+			},
+		)
+		if err != nil {
+			return change, e.Err("failed to add tags", err)
+		}
+		change.Added = result.ModifiedCount
+	}
+
+	return change, nil
+}
+
+// ensureGroupIndexes создаёт уникальный индекс по имени группы.
+func (db *DB) ensureGroupIndexes(ctx context.Context) error {
+	_, err := db.groups.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return e.Err("failed to create group indexes", err)
+	}
+
+	return nil
+}
+
+func objectIDs(ids []string) ([]primitive.ObjectID, error) {
+	result := make([]primitive.ObjectID, len(ids))
+	for i, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ObjectID: %s", id)
+		}
+		result[i] = oid
+	}
+
+	return result, nil
+}
@@ -0,0 +1,236 @@
+package mongo
+
+import (
+	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/storage"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"sync"
+	"testing"
+)
+
+// Если группу удалили между проверкой и обновлением счётчика, UpdateMembers
+// убирает группу из контактов, которые успел в неё добавить.
+func TestUpdateMembersRollback(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("group deleted", func(mt *mtest.T) {
+		gid := primitive.NewObjectID()
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+
+		// find группы, $push участников, findAndModify без документа, $pull
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: gid}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+		)
+
+		add := []string{primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()}
+		_, change, err := mockDeletionsDB(mt).UpdateMembers(context.Background(), gid.Hex(), add, nil)
+		if !errors.Is(err, storage.ErrGroupNotFound) {
+			mt.Fatalf("UpdateMembers = %v", err)
+		}
+		if change != (storage.MembershipChange{}) {
+			mt.Errorf("change = %+v for a deleted group", change)
+		}
+
+		commands := startedCommands(mt)
+		if len(commands) != 4 {
+			mt.Fatalf("sent %d commands, want 4", len(commands))
+		}
+
+		pull := commands[3].Lookup("updates", "0", "u", "$pull", "groups")
+		if pulled, ok := pull.ObjectIDOK(); !ok || pulled != gid {
+			mt.Errorf("last command does not pull the group: %s", commands[3])
+		}
+	})
+}
+
+// setupTestDB возвращает базу из newTestDB после Setup.
+func setupTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	db := newTestDB(t)
+	if err := db.Setup(context.Background()); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	return db
+}
+
+// saveContacts сохраняет n контактов и возвращает их идентификаторы.
+func saveContacts(t *testing.T, db *DB, n int) []string {
+	t.Helper()
+
+	ids := make([]string, n)
+	for i := range ids {
+		id, err := db.Save(context.Background(), models.Contact{UserName: fmt.Sprintf("user%d", i)})
+		if err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+// checkMemberCount сверяет MemberCount группы с числом контактов, в которых она записана.
+func checkMemberCount(t *testing.T, db *DB, gid string, want int64) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	group, err := db.Group(ctx, gid)
+	if err != nil {
+		t.Fatalf("Group: %v", err)
+	}
+
+	oid, _ := primitive.ObjectIDFromHex(gid)
+	members, err := db.contacts.CountDocuments(ctx, bson.D{{Key: "groups", Value: oid}})
+	if err != nil {
+		t.Fatalf("CountDocuments: %v", err)
+	}
+
+	if group.MemberCount != want || members != want {
+		t.Errorf("member_count %d, members %d; want %d", group.MemberCount, members, want)
+	}
+}
+
+func TestGroupMemberCounts(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	ids := saveContacts(t, db, 4)
+
+	gid, err := db.CreateGroup(ctx, models.Group{Name: "Family"})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	checkMemberCount(t, db, gid, 0)
+
+	if _, change, err := db.UpdateMembers(ctx, gid, ids[:3], nil); err != nil || change.Added != 3 {
+		t.Fatalf("add: %+v, %v", change, err)
+	}
+	checkMemberCount(t, db, gid, 3)
+
+	// Уже добавленные и отсутствующие в группе контакты счётчик не меняют
+	group, change, err := db.UpdateMembers(ctx, gid, ids[2:], []string{ids[0], ids[3]})
+	if err != nil {
+		t.Fatalf("add and remove: %v", err)
+	}
+	if change.Added != 1 || change.Removed != 1 || group.MemberCount != 3 {
+		t.Errorf("add and remove: %+v, member_count %d", change, group.MemberCount)
+	}
+	checkMemberCount(t, db, gid, 3)
+
+	// Удаление контакта уменьшает счётчик его групп
+	if _, err := db.Delete(ctx, ids[1]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	checkMemberCount(t, db, gid, 2)
+
+	// Пересчёт по контактам даёт то же число
+	if err := db.RecountGroups(ctx); err != nil {
+		t.Fatalf("RecountGroups: %v", err)
+	}
+	checkMemberCount(t, db, gid, 2)
+
+	if _, _, err := db.UpdateMembers(ctx, primitive.NewObjectID().Hex(), ids[:1], nil); !errors.Is(err, storage.ErrGroupNotFound) {
+		t.Errorf("UpdateMembers of a missing group = %v", err)
+	}
+}
+
+func TestDeleteGroupDetachesMembers(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	ids := saveContacts(t, db, 3)
+
+	gid, err := db.CreateGroup(ctx, models.Group{Name: "Work"})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	other, err := db.CreateGroup(ctx, models.Group{Name: "Friends"})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	if _, _, err := db.UpdateMembers(ctx, gid, ids[:2], nil); err != nil {
+		t.Fatalf("UpdateMembers: %v", err)
+	}
+	if _, _, err := db.UpdateMembers(ctx, other, ids, nil); err != nil {
+		t.Fatalf("UpdateMembers: %v", err)
+	}
+
+	detached, err := db.DeleteGroup(ctx, gid)
+	if err != nil || detached != 2 {
+		t.Fatalf("DeleteGroup = %d, %v; want 2", detached, err)
+	}
+
+	for _, id := range ids {
+		contact, err := db.ContactById(ctx, id)
+		if err != nil {
+			t.Fatalf("ContactById: %v", err)
+		}
+		if len(contact.Groups) != 1 || contact.Groups[0] != other {
+			t.Errorf("contact %s groups = %v, want only %s", id, contact.Groups, other)
+		}
+	}
+	checkMemberCount(t, db, other, 3)
+
+	if _, err := db.Group(ctx, gid); !errors.Is(err, storage.ErrGroupNotFound) {
+		t.Errorf("Group after delete = %v", err)
+	}
+	if _, err := db.DeleteGroup(ctx, gid); !errors.Is(err, storage.ErrGroupNotFound) {
+		t.Errorf("second DeleteGroup = %v", err)
+	}
+}
+
+// Одновременные DeleteGroup и UpdateMembers не должны оставлять удалённую группу в контактах,
+// в каком бы порядке ни выполнились их шаги.
+func TestDeleteGroupDuringUpdateMembers(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	ids := saveContacts(t, db, 20)
+
+	for i := range 20 {
+		gid, err := db.CreateGroup(ctx, models.Group{Name: fmt.Sprintf("group%d", i)})
+		if err != nil {
+			t.Fatalf("CreateGroup: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var updateErr, deleteErr error
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _, updateErr = db.UpdateMembers(ctx, gid, ids, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			_, deleteErr = db.DeleteGroup(ctx, gid)
+		}()
+		wg.Wait()
+
+		if deleteErr != nil {
+			t.Fatalf("DeleteGroup: %v", deleteErr)
+		}
+		if updateErr != nil && !errors.Is(updateErr, storage.ErrGroupNotFound) {
+			t.Fatalf("UpdateMembers: %v", updateErr)
+		}
+
+		oid, _ := primitive.ObjectIDFromHex(gid)
+		left, err := db.contacts.CountDocuments(ctx, bson.D{{Key: "groups", Value: oid}})
+		if err != nil {
+			t.Fatalf("CountDocuments: %v", err)
+		}
+		if left != 0 {
+			t.Fatalf("run %d: deleted group left in %d contacts", i, left)
+		}
+	}
+}
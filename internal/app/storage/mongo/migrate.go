@@ -0,0 +1,466 @@
+package mongo
+
+import (
+	"contact-api/internal/app/metrics"
+	"contact-api/internal/pkg/e"
+	"contact-api/internal/pkg/logger/sl"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrMigrationLocked       = errors.New("migrations are locked by another process")
+	ErrMigrationLockLost     = errors.New("migration lock lost")
+	ErrMigrationIrreversible = errors.New("migration cannot be reverted")
+)
+
+// Версии записываются в коллекцию миграций числовыми _id, блокировка - документом с этим _id.
+const migrationLockID = "lock"
+
+// Collections - коллекции, доступные миграциям.
+type Collections struct {
+	Contacts *mongo.Collection
+	Quotas   *mongo.Collection
+}
+
+// migration - версионированное изменение схемы. Версии идут по возрастанию без пропусков.
+type migration struct {
+	Version     int
+	Description string
+
+	// Up переписывает коллекции целиком. Должна быть идемпотентной: при падении посередине
+	// версия не записывается и миграция выполнится снова.
+	Up func(ctx context.Context, c Collections) error
+	// Down откатывает Up; nil - миграция необратима
+	Down func(ctx context.Context, c Collections) error
+
+	// Document приводит один документ контакта к этой версии. Если задана, миграцию можно
+	// применить лениво: Up не вызывается, а документы преобразуются при чтении и
+	// сохраняются в новой схеме при следующей записи.
+	Document func(doc bson.M) error
+}
+
+// MigrateOptions - параметры migrate up/down.
+type MigrateOptions struct {
+	// Целевая версия; для up 0 - последняя, для down -1 - на одну версию назад
+	To int
+	// Применять миграции с Document лениво
+	Lazy bool
+}
+
+// MigrationStatus - состояние одной миграции.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+	// Применена без переписывания коллекции
+	Lazy       bool
+	Reversible bool
+	// Версия записана в базе, но неизвестна этой сборке - её применила более новая версия сервиса
+	Unknown bool
+}
+
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+	Lazy        bool      `bson:"lazy"`
+}
+
+// documentVersion - версия схемы документа контакта, которую пишет эта сборка.
+var documentVersion = func() int {
+	version := 0
+	for _, m := range migrations {
+		if m.Document != nil {
+			version = m.Version
+		}
+	}
+	return version
+}()
+
+// MigrationStatus возвращает состояние всех известных и применённых миграций
+// и число документов, которые ещё не приведены к текущей схеме.
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationStatus, int64, error) {
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		s := MigrationStatus{
+			Version:     m.Version,
+			Description: m.Description,
+			Reversible:  m.Down != nil,
+		}
+		if r, ok := applied[m.Version]; ok {
+			s.Applied, s.AppliedAt, s.Lazy = true, r.AppliedAt, r.Lazy
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, s)
+	}
+
+	for _, r := range applied {
+		statuses = append(statuses, MigrationStatus{
+			Version:     r.Version,
+			Description: r.Description,
+			Applied:     true,
+			AppliedAt:   r.AppliedAt,
+			Lazy:        r.Lazy,
+			Unknown:     true,
+		})
+	}
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return a.Version - b.Version })
+
+	outdated, err := db.contacts.CountDocuments(ctx, outdatedFilter())
+	if err != nil {
+		return nil, 0, e.Err("failed to count outdated contacts", err)
+	}
+
+	return statuses, outdated, nil
+}
+
+// MigrateUp применяет ожидающие миграции до opts.To под блокировкой и возвращает применённые версии.
+// Реплики, запущенные одновременно, ждут блокировку и затем находят, что применять нечего.
+func (db *DB) MigrateUp(ctx context.Context, opts MigrateOptions) ([]int, error) {
+	const op = "storage.mongo.MigrateUp"
+	log := db.log.With(slog.String("op", op))
+
+	ctx, release, err := db.lockMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for version := range applied {
+		if !slices.ContainsFunc(migrations, func(m migration) bool { return m.Version == version }) {
+			log.Warn("database has a migration unknown to this build", slog.Int("version", version))
+		}
+	}
+
+	var done []int
+
+	for _, m := range migrations {
+		if opts.To > 0 && m.Version > opts.To {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		lazy := opts.Lazy && m.Document != nil
+		start := time.Now()
+
+		if m.Up != nil && !lazy {
+			log.Info("applying migration", slog.Int("version", m.Version), slog.String("description", m.Description))
+
+			if err := m.Up(ctx, db.collections()); err != nil {
+				return done, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+			}
+		}
+
+		_, err := db.migrations.InsertOne(ctx, migrationRecord{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now().UTC(),
+			Lazy:        lazy,
+		})
+		if err != nil {
+			return done, e.Err(fmt.Sprintf("failed to record migration %d", m.Version), err)
+		}
+
+		log.Info("migration applied",
+			slog.Int("version", m.Version),
+			slog.Bool("lazy", lazy),
+			slog.Duration("duration", time.Since(start)))
+
+		done = append(done, m.Version)
+	}
+
+	return done, nil
+}
+
+// MigrateDown откатывает применённые миграции новее opts.To и возвращает откаченные версии.
+func (db *DB) MigrateDown(ctx context.Context, opts MigrateOptions) ([]int, error) {
+	const op = "storage.mongo.MigrateDown"
+	log := db.log.With(slog.String("op", op))
+
+	ctx, release, err := db.lockMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := slices.Sorted(maps.Keys(applied))
+	slices.Reverse(versions)
+
+	to := opts.To
+	if to < 0 {
+		to = 0
+		if len(versions) > 1 {
+			to = versions[1]
+		}
+	}
+
+	var done []int
+
+	for _, version := range versions {
+		if version <= to {
+			break
+		}
+
+		i := slices.IndexFunc(migrations, func(m migration) bool { return m.Version == version })
+		if i < 0 {
+			return done, fmt.Errorf("migration %d is unknown to this build, revert it with the version that applied it", version)
+		}
+
+		m := migrations[i]
+		if m.Down == nil {
+			return done, fmt.Errorf("%w: %d (%s)", ErrMigrationIrreversible, m.Version, m.Description)
+		}
+
+		log.Info("reverting migration", slog.Int("version", m.Version), slog.String("description", m.Description))
+
+		if err := m.Down(ctx, db.collections()); err != nil {
+			return done, fmt.Errorf("revert migration %d (%s): %w", m.Version, m.Description, err)
+		}
+
+		if _, err := db.migrations.DeleteOne(ctx, bson.D{{Key: "_id", Value: m.Version}}); err != nil {
+			return done, e.Err(fmt.Sprintf("failed to unrecord migration %d", m.Version), err)
+		}
+
+		done = append(done, m.Version)
+	}
+
+	return done, nil
+}
+
+func (db *DB) collections() Collections {
+	return Collections{Contacts: db.contacts, Quotas: db.quotas}
+}
+
+func (db *DB) appliedMigrations(ctx context.Context) (map[int]migrationRecord, error) {
+	cursor, err := db.migrations.Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$type", Value: "number"}}}})
+	if err != nil {
+		return nil, e.Err("failed to read applied migrations", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, e.Err("failed to decode applied migrations", err)
+	}
+
+	applied := make(map[int]migrationRecord, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// lockMigrations берёт блокировку миграций, дожидаясь её не дольше lock_wait, и продлевает её в фоне.
+// Возвращённый контекст отменяется, если блокировку продлить не удалось, чтобы миграция
+// не продолжалась параллельно с другой репликой.
+func (db *DB) lockMigrations(ctx context.Context) (context.Context, func(), error) {
+	cfg := db.migrationsCfg
+	owner := lockOwner()
+
+	waitCtx, cancelWait := context.WithTimeout(ctx, cfg.LockWait)
+	defer cancelWait()
+
+	for logged := false; ; {
+		ok, err := db.tryLock(waitCtx, owner, cfg.LockTTL)
+		if err != nil {
+			if waitCtx.Err() != nil && ctx.Err() == nil {
+				return nil, nil, ErrMigrationLocked
+			}
+			return nil, nil, err
+		}
+		if ok {
+			break
+		}
+
+		if !logged {
+			db.log.Info("waiting for migration lock held by another process", slog.String("op", "storage.mongo.lockMigrations"))
+			logged = true
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+			return nil, nil, ErrMigrationLocked
+		case <-time.After(min(time.Second, cfg.LockTTL/4)):
+		}
+	}
+
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(cfg.LockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-ticker.C:
+				ok, err := db.renewLock(lockCtx, owner, cfg.LockTTL)
+				if lockCtx.Err() != nil {
+					return
+				}
+				if err != nil {
+					// Временная ошибка: блокировка ещё действует, попробуем при следующем тике
+					db.log.Warn("failed to renew migration lock", sl.Err(err))
+					continue
+				}
+				if !ok {
+					cancel(ErrMigrationLockLost)
+					return
+				}
+			}
+		}
+	}()
+
+	release := func() {
+		cancel(nil)
+		<-done
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if _, err := db.migrations.DeleteOne(ctx, bson.D{{Key: "_id", Value: migrationLockID}, {Key: "owner", Value: owner}}); err != nil {
+			db.log.Warn("failed to release migration lock, it will expire", sl.Err(err))
+		}
+	}
+
+	return lockCtx, release, nil
+}
+
+// tryLock атомарно берёт свободную или просроченную блокировку. Если её держит другой процесс,
+// upsert упирается в уникальный _id и возвращается false.
+func (db *DB) tryLock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	filter := bson.D{
+		{Key: "_id", Value: migrationLockID},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lt", Value: now}}}},
+			bson.D{{Key: "owner", Value: owner}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "owner", Value: owner},
+		{Key: "acquired_at", Value: now},
+		{Key: "expires_at", Value: now.Add(ttl)},
+	}}}
+
+	_, err := db.migrations.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, e.Err("failed to acquire migration lock", err)
+	}
+
+	return true, nil
+}
+
+func (db *DB) renewLock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	res, err := db.migrations.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: migrationLockID}, {Key: "owner", Value: owner}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "expires_at", Value: time.Now().UTC().Add(ttl)}}}},
+	)
+	if err != nil {
+		return false, e.Err("failed to renew migration lock", err)
+	}
+
+	return res.MatchedCount == 1, nil
+}
+
+// lockOwner - уникальное имя процесса; хост и pid упрощают поиск реплики, держащей блокировку.
+func lockOwner() string {
+	host, _ := os.Hostname()
+
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+
+	return host + "/" + strconv.Itoa(os.Getpid()) + "/" + hex.EncodeToString(b[:])
+}
+
+// outdatedFilter выбирает документы со схемой старше documentVersion, в том числе без schema_version.
+func outdatedFilter() bson.D {
+	return bson.D{{Key: "schema_version", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gte", Value: documentVersion}}}}}}
+}
+
+// decodeContact декодирует документ контакта, при необходимости приводя его к текущей схеме.
+// Результат в базу не записывается: документ сохранится в новой схеме при следующем изменении.
+func decodeContact(raw bson.Raw) (Contact, error) {
+	var contact Contact
+
+	version := 0
+	if v, err := raw.LookupErr("schema_version"); err == nil {
+		if n, ok := v.AsInt64OK(); ok {
+			version = int(n)
+		}
+	}
+
+	if version >= documentVersion {
+		err := bson.Unmarshal(raw, &contact)
+		return contact, err
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return contact, err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= version || m.Document == nil {
+			continue
+		}
+
+		if err := m.Document(doc); err != nil {
+			return contact, fmt.Errorf("migration %d: %w", m.Version, err)
+		}
+		doc["schema_version"] = m.Version
+
+		metrics.StorageLazyMigrations.WithLabelValues(strconv.Itoa(m.Version)).Inc()
+	}
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return contact, err
+	}
+
+	err = bson.Unmarshal(data, &contact)
+	return contact, err
+}
@@ -0,0 +1,377 @@
+package mongo
+
+import (
+	"contact-api/internal/app/config"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func mockDB(mt *mtest.T, lockWait time.Duration) *DB {
+	return &DB{
+		log:        discardLogger(),
+		contacts:   mt.Coll,
+		migrations: mt.Coll,
+		migrationsCfg: config.Migrations{
+			LockTTL:  time.Minute,
+			LockWait: lockWait,
+		},
+	}
+}
+
+// withMigrations подменяет список миграций на время теста.
+func withMigrations(t *testing.T, list []migration) {
+	saved := migrations
+	migrations = list
+	t.Cleanup(func() { migrations = saved })
+}
+
+func TestMigrationWritesBypassValidation(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	for _, m := range migrations {
+		for dir, fn := range map[string]func(context.Context, Collections) error{"up": m.Up, "down": m.Down} {
+			if fn == nil {
+				continue
+			}
+
+			mt.Run(fmt.Sprintf("%d/%s", m.Version, dir), func(mt *mtest.T) {
+				// update, затем listIndexes или createIndexes
+				mt.AddMockResponses(
+					mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+					mtest.CreateCursorResponse(0, "test.contacts", mtest.FirstBatch),
+				)
+
+				if err := fn(context.Background(), Collections{Contacts: mt.Coll}); err != nil {
+					mt.Fatalf("migration: %v", err)
+				}
+
+				updates := 0
+				for ev := mt.GetStartedEvent(); ev != nil; ev = mt.GetStartedEvent() {
+					if ev.CommandName != "update" {
+						continue
+					}
+					updates++
+
+					bypass, ok := ev.Command.Lookup("bypassDocumentValidation").BooleanOK()
+					if !ok || !bypass {
+						mt.Errorf("update without bypassDocumentValidation: %s", ev.Command)
+					}
+				}
+				if updates == 0 {
+					mt.Error("migration sent no updates")
+				}
+			})
+		}
+	}
+}
+
+func TestMigrateUp(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("applies pending in order", func(mt *mtest.T) {
+		var ran []int
+		up := func(version int) func(context.Context, Collections) error {
+			return func(context.Context, Collections) error {
+				ran = append(ran, version)
+				return nil
+			}
+		}
+		withMigrations(mt.T, []migration{
+			{Version: 1, Description: "one", Up: up(1)},
+			{Version: 2, Description: "two", Up: up(2)},
+			{Version: 3, Description: "three", Up: up(3)},
+		})
+
+		ok := mtest.CreateSuccessResponse()
+		mt.AddMockResponses(
+			// блокировка
+			ok,
+			// применена только версия 1
+			mtest.CreateCursorResponse(0, "test.migrations", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: 1}, {Key: "description", Value: "one"}, {Key: "applied_at", Value: time.Now()},
+			}),
+			// записи версий 2 и 3, снятие блокировки
+			ok, ok, ok,
+		)
+
+		done, err := mockDB(mt, time.Second).MigrateUp(context.Background(), MigrateOptions{})
+		if err != nil {
+			mt.Fatalf("MigrateUp: %v", err)
+		}
+		if !slices.Equal(done, []int{2, 3}) || !slices.Equal(ran, []int{2, 3}) {
+			mt.Fatalf("applied %v, ran %v, want [2 3]", done, ran)
+		}
+
+		var inserted []int32
+		for ev := mt.GetStartedEvent(); ev != nil; ev = mt.GetStartedEvent() {
+			if ev.CommandName == "insert" {
+				inserted = append(inserted, ev.Command.Lookup("documents", "0", "_id").Int32())
+			}
+		}
+		if !slices.Equal(inserted, []int32{2, 3}) {
+			mt.Errorf("recorded versions %v, want [2 3]", inserted)
+		}
+	})
+
+	mt.Run("stops on failure without recording", func(mt *mtest.T) {
+		failure := errors.New("boom")
+		withMigrations(mt.T, []migration{
+			{Version: 1, Description: "one", Up: func(context.Context, Collections) error { return failure }},
+			{Version: 2, Description: "two", Up: func(context.Context, Collections) error { mt.Error("ran after failure"); return nil }},
+		})
+
+		ok := mtest.CreateSuccessResponse()
+		mt.AddMockResponses(ok, mtest.CreateCursorResponse(0, "test.migrations", mtest.FirstBatch), ok)
+
+		done, err := mockDB(mt, time.Second).MigrateUp(context.Background(), MigrateOptions{})
+		if !errors.Is(err, failure) || len(done) != 0 {
+			mt.Fatalf("MigrateUp = %v, %v; want no versions and the migration error", done, err)
+		}
+
+		for ev := mt.GetStartedEvent(); ev != nil; ev = mt.GetStartedEvent() {
+			if ev.CommandName == "insert" {
+				mt.Errorf("failed migration was recorded: %s", ev.Command)
+			}
+		}
+	})
+
+	mt.Run("lazy skips up", func(mt *mtest.T) {
+		withMigrations(mt.T, []migration{{
+			Version:     1,
+			Description: "lazy",
+			Up:          func(context.Context, Collections) error { mt.Error("Up ran for a lazy migration"); return nil },
+			Document:    func(bson.M) error { return nil },
+		}})
+
+		ok := mtest.CreateSuccessResponse()
+		mt.AddMockResponses(ok, mtest.CreateCursorResponse(0, "test.migrations", mtest.FirstBatch), ok, ok)
+
+		done, err := mockDB(mt, time.Second).MigrateUp(context.Background(), MigrateOptions{Lazy: true})
+		if err != nil || !slices.Equal(done, []int{1}) {
+			mt.Fatalf("MigrateUp = %v, %v", done, err)
+		}
+
+		for ev := mt.GetStartedEvent(); ev != nil; ev = mt.GetStartedEvent() {
+			if ev.CommandName == "insert" && !ev.Command.Lookup("documents", "0", "lazy").Boolean() {
+				mt.Errorf("lazy migration recorded as applied: %s", ev.Command)
+			}
+		}
+	})
+}
+
+func TestMigrateDownIrreversible(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("irreversible", func(mt *mtest.T) {
+		withMigrations(mt.T, []migration{{Version: 1, Description: "one", Up: func(context.Context, Collections) error { return nil }}})
+
+		ok := mtest.CreateSuccessResponse()
+		mt.AddMockResponses(ok, mtest.CreateCursorResponse(0, "test.migrations", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: 1}, {Key: "description", Value: "one"},
+		}), ok)
+
+		_, err := mockDB(mt, time.Second).MigrateDown(context.Background(), MigrateOptions{To: -1})
+		if !errors.Is(err, ErrMigrationIrreversible) {
+			mt.Fatalf("MigrateDown error = %v, want ErrMigrationIrreversible", err)
+		}
+	})
+}
+
+func TestMigrationLock(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("held by another process", func(mt *mtest.T) {
+		// upsert упирается в _id блокировки, которую держит другой процесс
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "duplicate key"}))
+
+		_, _, err := mockDB(mt, 50*time.Millisecond).lockMigrations(context.Background())
+		if !errors.Is(err, ErrMigrationLocked) {
+			mt.Fatalf("lockMigrations error = %v, want ErrMigrationLocked", err)
+		}
+	})
+
+	mt.Run("takes free or expired lock", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		ctx, release, err := mockDB(mt, time.Second).lockMigrations(context.Background())
+		if err != nil {
+			mt.Fatalf("lockMigrations: %v", err)
+		}
+
+		ev := mt.GetStartedEvent()
+		filter := ev.Command.Lookup("updates", "0", "q")
+		if filter.Document().Lookup("_id").StringValue() != migrationLockID {
+			mt.Fatalf("lock filter %s", filter)
+		}
+		// Свою или просроченную блокировку можно взять, чужую действующую - нет
+		if _, err := filter.Document().LookupErr("$or"); err != nil {
+			mt.Fatalf("lock filter does not check expiry and owner: %s", filter)
+		}
+		if !ev.Command.Lookup("updates", "0", "upsert").Boolean() {
+			mt.Fatal("lock is not taken with upsert")
+		}
+
+		release()
+		if ctx.Err() == nil {
+			mt.Error("lock context is not cancelled after release")
+		}
+
+		ev = mt.GetStartedEvent()
+		if ev == nil || ev.CommandName != "delete" {
+			mt.Fatalf("release did not delete the lock: %v", ev)
+		}
+	})
+
+	mt.Run("lost lock cancels context", func(mt *mtest.T) {
+		db := mockDB(mt, time.Second)
+		db.migrationsCfg.LockTTL = 30 * time.Millisecond
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			// продление не нашло блокировку: её забрал другой процесс
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		ctx, release, err := db.lockMigrations(context.Background())
+		if err != nil {
+			mt.Fatalf("lockMigrations: %v", err)
+		}
+		defer release()
+
+		select {
+		case <-ctx.Done():
+			if !errors.Is(context.Cause(ctx), ErrMigrationLockLost) {
+				mt.Errorf("cause = %v, want ErrMigrationLockLost", context.Cause(ctx))
+			}
+		case <-time.After(time.Second):
+			mt.Fatal("context not cancelled after losing the lock")
+		}
+	})
+}
+
+func TestDecodeContactLazyUpgrade(t *testing.T) {
+	id := primitive.NewObjectID()
+	raw, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "username", Value: "ivan"},
+		{Key: "email", Value: "ivan@example.com"},
+		{Key: "telephone", Value: bson.D{{Key: "mobile", Value: "+79990000000"}, {Key: "home", Value: ""}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contact, err := decodeContact(raw)
+	if err != nil {
+		t.Fatalf("decodeContact: %v", err)
+	}
+
+	if contact.UpdatedAt.IsZero() {
+		t.Error("updated_at is not backfilled from the ObjectID")
+	}
+	if len(contact.Emails) != 1 || contact.Emails[0].Address != "ivan@example.com" {
+		t.Errorf("emails = %+v", contact.Emails)
+	}
+	if len(contact.Phones) != 1 || contact.Phones[0].Number != "+79990000000" {
+		t.Errorf("phones = %+v", contact.Phones)
+	}
+}
+
+// contact047 - документ контакта в схеме user-047: email и telephone обязательны.
+type contact047 struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserName  string             `bson:"username"`
+	Email     string             `bson:"email"`
+	Telephone struct {
+		Mobile string `bson:"mobile"`
+		Home   string `bson:"home"`
+	} `bson:"telephone"`
+	UpdatedAt     time.Time `bson:"updated_at,omitempty"`
+	SchemaVersion int       `bson:"schema_version,omitempty"`
+}
+
+// Обновление развёртывания с валидатором user-047: миграция 2 убирает обязательные в нём поля.
+func TestSetupUpgradesFrom047(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	err := db.contacts.Database().CreateCollection(ctx, db.contacts.Name(), options.CreateCollection().
+		SetValidator(bson.D{{Key: "$jsonSchema", Value: jsonSchema(reflect.TypeOf(contact047{}))}}).
+		SetValidationLevel(validationLevel).
+		SetValidationAction("error"))
+	if err != nil {
+		t.Fatalf("create 047 collection: %v", err)
+	}
+
+	old := contact047{ID: primitive.NewObjectID(), UserName: "ivan", Email: "ivan@example.com", SchemaVersion: 1}
+	old.Telephone.Mobile = "+79990000000"
+	if _, err := db.contacts.InsertOne(ctx, old); err != nil {
+		t.Fatalf("insert 047 contact: %v", err)
+	}
+	if _, err := db.migrations.InsertOne(ctx, migrationRecord{Version: 1, Description: "backfill", AppliedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Setup(ctx); err != nil {
+		t.Fatalf("Setup on a 047 deployment: %v", err)
+	}
+
+	var doc bson.M
+	if err := db.contacts.FindOne(ctx, bson.D{{Key: "_id", Value: old.ID}}).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc["email"]; ok {
+		t.Errorf("email not removed: %v", doc)
+	}
+	if doc["schema_version"] != int32(2) {
+		t.Errorf("schema_version = %v, want 2", doc["schema_version"])
+	}
+
+	drift, err := db.SchemaDrift(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 0 {
+		t.Errorf("drift after Setup: %+v", drift)
+	}
+
+	// Повторный запуск ничего не применяет
+	done, err := db.MigrateUp(ctx, MigrateOptions{})
+	if err != nil || len(done) != 0 {
+		t.Errorf("second MigrateUp = %v, %v", done, err)
+	}
+}
+
+func TestMigrationLockExclusive(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	db.migrationsCfg.LockWait = 100 * time.Millisecond
+
+	_, release, err := db.lockMigrations(ctx)
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	if _, _, err := db.lockMigrations(ctx); !errors.Is(err, ErrMigrationLocked) {
+		t.Fatalf("second lock error = %v, want ErrMigrationLocked", err)
+	}
+
+	release()
+
+	_, release, err = db.lockMigrations(ctx)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	release()
+}
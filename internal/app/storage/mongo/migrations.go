@@ -0,0 +1,187 @@
+package mongo
+
+import (
+	"contact-api/internal/app/domain/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationWrite - параметры записей миграций. Валидатор коллекции описывает документы одной версии схемы,
+// а миграция переводит их в другую: валидатор предыдущей версии, например требующий email и telephone,
+// отклонил бы миграцию 2, а Setup сверяет валидатор только после миграций. Поэтому записи миграций
+// идут в обход валидатора; пользователю MongoDB нужно действие bypassDocumentValidation.
+var migrationWrite = options.Update().SetBypassDocumentValidation(true)
+
+// migrations - все миграции схемы в порядке версий. Применённую миграцию нельзя менять,
+// новые добавляются в конец со следующим номером.
+var migrations = []migration{
+	{
+		Version:     1,
+		Description: "backfill contacts updated_at from ObjectID creation time",
+		Up: func(ctx context.Context, c Collections) error {
+			_, err := c.Contacts.UpdateMany(ctx,
+				bson.D{{Key: "schema_version", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gte", Value: 1}}}}}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.D{
+					{Key: "updated_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$updated_at", bson.D{{Key: "$toDate", Value: "$_id"}}}}}},
+					{Key: "schema_version", Value: 1},
+				}}}},
+				migrationWrite,
+			)
+			return err
+		},
+		// Заполненные updated_at остаются: предыдущие версии сервиса их не читают,
+		// а отличить их от настоящих уже нельзя
+		Down: func(ctx context.Context, c Collections) error {
+			_, err := c.Contacts.UpdateMany(ctx,
+				bson.D{{Key: "schema_version", Value: 1}},
+				bson.D{{Key: "$unset", Value: bson.D{{Key: "schema_version", Value: ""}}}},
+				migrationWrite,
+			)
+			return err
+		},
+		Document: func(doc bson.M) error {
+			if _, ok := doc["updated_at"]; ok {
+				return nil
+			}
+			if id, ok := doc["_id"].(primitive.ObjectID); ok {
+				doc["updated_at"] = primitive.NewDateTimeFromTime(id.Timestamp())
+			}
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "move email and telephone into labeled emails and phones lists",
+		Up: func(ctx context.Context, c Collections) error {
+			_, err := c.Contacts.UpdateMany(ctx,
+				bson.D{{Key: "schema_version", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gte", Value: 2}}}}}},
+				mongo.Pipeline{
+					{{Key: "$set", Value: bson.D{
+						{Key: "emails", Value: bson.D{{Key: "$cond", Value: bson.A{
+							bson.D{{Key: "$isArray", Value: "$emails"}},
+							"$emails",
+							legacyItem("$email", bson.D{{Key: "label", Value: models.LabelOther}, {Key: "address", Value: "$email"}}),
+						}}}},
+						{Key: "phones", Value: bson.D{{Key: "$cond", Value: bson.A{
+							bson.D{{Key: "$isArray", Value: "$phones"}},
+							"$phones",
+							bson.D{{Key: "$concatArrays", Value: bson.A{
+								legacyItem("$telephone.mobile", bson.D{{Key: "label", Value: models.LabelMobile}, {Key: "number", Value: "$telephone.mobile"}}),
+								legacyItem("$telephone.home", bson.D{{Key: "label", Value: models.LabelHome}, {Key: "number", Value: "$telephone.home"}}),
+							}}},
+						}}}},
+						{Key: "schema_version", Value: 2},
+					}}},
+					{{Key: "$unset", Value: bson.A{"email", "telephone"}}},
+				},
+				migrationWrite,
+			)
+			if err != nil {
+				return err
+			}
+
+			return dropIndexes(ctx, c.Contacts, "email", "telephone.mobile", "telephone.home")
+		},
+		// Списки остаются: предыдущая версия сервиса их не читает
+		Down: func(ctx context.Context, c Collections) error {
+			_, err := c.Contacts.UpdateMany(ctx,
+				bson.D{{Key: "schema_version", Value: 2}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.D{
+					{Key: "email", Value: firstOf("$emails", "address", nil)},
+					{Key: "telephone", Value: bson.D{
+						{Key: "mobile", Value: firstOf("$phones", "number", bson.D{{Key: "$eq", Value: bson.A{"$$this.label", models.LabelMobile}}})},
+						{Key: "home", Value: firstOf("$phones", "number", bson.D{{Key: "$eq", Value: bson.A{"$$this.label", models.LabelHome}}})},
+					}},
+					{Key: "schema_version", Value: 1},
+				}}}},
+				migrationWrite,
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = c.Contacts.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "email", Value: 1}}},
+				{Keys: bson.D{{Key: "telephone.mobile", Value: 1}}},
+				{Keys: bson.D{{Key: "telephone.home", Value: 1}}},
+			})
+			return err
+		},
+		Document: func(doc bson.M) error {
+			if _, ok := doc["emails"]; !ok {
+				if email, _ := doc["email"].(string); email != "" {
+					doc["emails"] = bson.A{bson.M{"label": models.LabelOther, "address": email}}
+				}
+			}
+
+			if _, ok := doc["phones"]; !ok {
+				tel, _ := doc["telephone"].(bson.M)
+
+				var phones bson.A
+				if mobile, _ := tel["mobile"].(string); mobile != "" {
+					phones = append(phones, bson.M{"label": models.LabelMobile, "number": mobile})
+				}
+				if home, _ := tel["home"].(string); home != "" {
+					phones = append(phones, bson.M{"label": models.LabelHome, "number": home})
+				}
+				if phones != nil {
+					doc["phones"] = phones
+				}
+			}
+
+			delete(doc, "email")
+			delete(doc, "telephone")
+
+			return nil
+		},
+	},
+}
+
+// legacyItem - выражение агрегации: массив из одного item, если строковое поле field непустое, иначе пустой массив.
+func legacyItem(field string, item bson.D) bson.D {
+	return bson.D{{Key: "$cond", Value: bson.A{
+		bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$strLenCP", Value: bson.D{{Key: "$ifNull", Value: bson.A{field, ""}}}}}, 0}}},
+		bson.A{item},
+		bson.A{},
+	}}}
+}
+
+// firstOf - выражение агрегации: поле key первого элемента массива list, подходящего под cond, или пустая строка.
+func firstOf(list, key string, cond bson.D) bson.D {
+	input := any(bson.D{{Key: "$ifNull", Value: bson.A{list, bson.A{}}}})
+	if cond != nil {
+		input = bson.D{{Key: "$filter", Value: bson.D{{Key: "input", Value: input}, {Key: "cond", Value: cond}}}}
+	}
+
+	return bson.D{{Key: "$ifNull", Value: bson.A{
+		bson.D{{Key: "$arrayElemAt", Value: bson.A{
+			bson.D{{Key: "$map", Value: bson.D{{Key: "input", Value: input}, {Key: "in", Value: "$$this." + key}}}},
+			0,
+		}}},
+		"",
+	}}}
+}
+
+// dropIndexes удаляет одиночные индексы по перечисленным полям, если они есть.
+func dropIndexes(ctx context.Context, coll *mongo.Collection, fields ...string) error {
+	specs, err := coll.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range specs {
+		for _, field := range fields {
+			if !sameKeys(spec.KeysDocument, bson.D{{Key: field, Value: 1}}) {
+				continue
+			}
+			if _, err := coll.Indexes().DropOne(ctx, spec.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
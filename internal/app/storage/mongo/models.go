@@ -3,31 +3,110 @@ package mongo
 import (
 	"contact-api/internal/app/domain/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
 )
 
+// Contact - документ контакта в схеме documentVersion. Документы старых версий
+// приводятся к ней при чтении, см. migrations.
 type Contact struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	UserName  string             `bson:"username"`
-	Email     string             `bson:"email"`
-	Telephone Phone              `bson:"telephone"`
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	UserName string             `bson:"username"`
+	Name     Name               `bson:"name,omitempty"`
+
+	Emails    []Email   `bson:"emails,omitempty"`
+	Phones    []Phone   `bson:"phones,omitempty"`
+	Addresses []Address `bson:"addresses,omitempty"`
+	URLs      []URL     `bson:"urls,omitempty"`
+
+	Organization string `bson:"organization,omitempty"`
+	Title        string `bson:"title,omitempty"`
+	Birthday     string `bson:"birthday,omitempty"`
+	Notes        string `bson:"notes,omitempty"`
+
+	// Значения пользовательских полей; числа хранятся как double, остальные типы - строками
+	Fields map[string]any `bson:"fields,omitempty"`
+
+	Tags []string `bson:"tags,omitempty"`
+	// Идентификаторы групп; меняются вместе с Group.MemberCount, см. UpdateMembers
+	Groups []primitive.ObjectID `bson:"groups,omitempty"`
+
+	// Выставляется при каждой записи, по нему строятся инкрементальные резервные копии
+	UpdatedAt time.Time `bson:"updated_at,omitempty"`
+	// Версия схемы документа, см. migrations
+	SchemaVersion int `bson:"schema_version,omitempty"`
+}
+
+type Name struct {
+	Display string `bson:"display,omitempty"`
+	Given   string `bson:"given,omitempty"`
+	Family  string `bson:"family,omitempty"`
+}
+
+// IsZero позволяет не сохранять пустое имя: omitempty драйвера учитывает только этот метод у структур.
+func (n Name) IsZero() bool {
+	return n == Name{}
+}
+
+type Email struct {
+	Label   string `bson:"label"`
+	Address string `bson:"address"`
 }
 
 type Phone struct {
-	Mobile string `bson:"mobile"`
-	Home   string `bson:"home"`
+	Label  string `bson:"label"`
+	Number string `bson:"number"`
+}
+
+type Address struct {
+	Label      string `bson:"label"`
+	Street     string `bson:"street,omitempty"`
+	City       string `bson:"city,omitempty"`
+	Region     string `bson:"region,omitempty"`
+	PostalCode string `bson:"postal_code,omitempty"`
+	Country    string `bson:"country,omitempty"`
+}
+
+type URL struct {
+	Label string `bson:"label"`
+	URL   string `bson:"url"`
+}
+
+// Quota - суточный счётчик записей одного клиента
+type Quota struct {
+	ID        string    `bson:"_id"`
+	Key       string    `bson:"key"`
+	Day       string    `bson:"day"`
+	Count     int64     `bson:"count"`
+	CreatedAt time.Time `bson:"created_at"`
 }
 
 // Преобразование Contact (репозиторий) в models.Contact (сервисный уровень)
 func RepoToContact(repoContact Contact) models.Contact {
-	return models.Contact{
+	contact := models.Contact{
 		ID:       repoContact.ID.Hex(), // Конвертируем ObjectID в строку
 		UserName: repoContact.UserName,
-		Email:    repoContact.Email,
-		Telephone: models.Phone{
-			Mobile: repoContact.Telephone.Mobile,
-			Home:   repoContact.Telephone.Home,
+		Name: models.Name{
+			Display: repoContact.Name.Display,
+			Given:   repoContact.Name.Given,
+			Family:  repoContact.Name.Family,
 		},
+		Emails:       convertList(repoContact.Emails, func(e Email) models.Email { return models.Email(e) }),
+		Phones:       convertList(repoContact.Phones, func(p Phone) models.Phone { return models.Phone(p) }),
+		Addresses:    convertList(repoContact.Addresses, func(a Address) models.Address { return models.Address(a) }),
+		URLs:         convertList(repoContact.URLs, func(u URL) models.URL { return models.URL(u) }),
+		Organization: repoContact.Organization,
+		Title:        repoContact.Title,
+		Birthday:     repoContact.Birthday,
+		Notes:        repoContact.Notes,
+		Fields:       repoContact.Fields,
+		Tags:         repoContact.Tags,
+		Groups:       convertList(repoContact.Groups, primitive.ObjectID.Hex),
 	}
+
+	// Заполняет устаревшие email и telephone для старых клиентов
+	contact.Normalize()
+
+	return contact
 }
 
 // Преобразование массива моделей репозитория в массив сервисных моделей
@@ -50,13 +129,39 @@ func ContactToRepo(serviceContact models.Contact) (Contact, error) {
 	return repoContact, nil
 }
 
+// ContactToRepoWithoutID сохраняет только списки; устаревшие email и telephone
+// переносятся в них в Normalize до вызова. Groups не переносится: членство в группах
+// меняется только вместе с их счётчиками.
 func ContactToRepoWithoutID(serviceContact models.Contact) Contact {
 	return Contact{
-		UserName: serviceContact.UserName,
-		Email:    serviceContact.Email,
-		Telephone: Phone{
-			Mobile: serviceContact.Telephone.Mobile,
-			Home:   serviceContact.Telephone.Home,
+		SchemaVersion: documentVersion,
+		UserName:      serviceContact.UserName,
+		Name: Name{
+			Display: serviceContact.Name.Display,
+			Given:   serviceContact.Name.Given,
+			Family:  serviceContact.Name.Family,
 		},
+		Emails:       convertList(serviceContact.Emails, func(e models.Email) Email { return Email(e) }),
+		Phones:       convertList(serviceContact.Phones, func(p models.Phone) Phone { return Phone(p) }),
+		Addresses:    convertList(serviceContact.Addresses, func(a models.Address) Address { return Address(a) }),
+		URLs:         convertList(serviceContact.URLs, func(u models.URL) URL { return URL(u) }),
+		Organization: serviceContact.Organization,
+		Title:        serviceContact.Title,
+		Birthday:     serviceContact.Birthday,
+		Notes:        serviceContact.Notes,
+		Fields:       serviceContact.Fields,
+		Tags:         serviceContact.Tags,
+	}
+}
+
+func convertList[From, To any](from []From, convert func(From) To) []To {
+	if len(from) == 0 {
+		return nil
+	}
+
+	to := make([]To, len(from))
+	for i, v := range from {
+		to[i] = convert(v)
 	}
+	return to
 }
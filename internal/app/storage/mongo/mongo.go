@@ -1,92 +1,440 @@
 package mongo
 
 import (
+	"contact-api/internal/app/config"
 	"contact-api/internal/app/domain/models"
+	"contact-api/internal/app/metrics"
 	"contact-api/internal/app/storage"
 	"contact-api/internal/pkg/e"
 	"contact-api/internal/pkg/logger/sl"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"log"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"log/slog"
+	"sync/atomic"
 	"time"
 )
 
 type DB struct {
-	db *mongo.Client
+	db       *mongo.Client
+	log      *slog.Logger
+	retry    config.Retry
+	timeouts atomic.Pointer[config.StorageTimeouts]
+	ready    atomic.Bool
+	pool     *poolStats
+
+	contacts   *mongo.Collection
+	quotas     *mongo.Collection
+	migrations *mongo.Collection
+	fields     *mongo.Collection
+	groups     *mongo.Collection
+	deletions  *mongo.Collection
+
+	migrationsCfg config.Migrations
+	schemaCfg     config.Schema
 }
 
-func New(log *slog.Logger, ctx context.Context, connUrl string) (*DB, error) {
+// New создаёт клиент MongoDB, не дожидаясь доступности сервера.
+// Подключение и подготовка индексов выполняются отдельно в Setup.
+func New(log *slog.Logger, ctx context.Context, cfg config.Mongo, timeouts config.StorageTimeouts) (*DB, error) {
 	const op = "storage.mongo.New"
+	baseLog := log
 	log = log.With(
 		slog.String("op", op))
 
-	log.Debug("connect uri", slog.String("url", connUrl))
+	pool := newPoolStats()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(connUrl))
+	opts, err := clientOptions(cfg, pool)
+	if err != nil {
+		log.Error("Invalid MongoDB configuration", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("connect uri", slog.String("url", config.RedactURI(cfg.URI)), slog.Any("hosts", opts.Hosts))
+
+	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
 		log.Error("Failed to connect to MongoDB", sl.Err(err))
 		return nil, err
 	}
 
-	for i := 1; i <= 10; i++ {
-		err = client.Ping(ctx, nil)
+	database := client.Database(cfg.Database)
+
+	db := &DB{
+		db:         client,
+		log:        baseLog,
+		retry:      cfg.Retry,
+		pool:       pool,
+		contacts:   database.Collection(cfg.Collection),
+		quotas:     database.Collection(cfg.QuotaCollection),
+		migrations: database.Collection(cfg.Migrations.Collection),
+		fields:     database.Collection(cfg.FieldCollection),
+		groups:     database.Collection(cfg.GroupCollection),
+		deletions:  database.Collection(cfg.DeletionCollection),
+
+		migrationsCfg: cfg.Migrations,
+		schemaCfg:     cfg.Schema,
+	}
+	db.SetTimeouts(timeouts)
+
+	return db, nil
+}
+
+// SetTimeouts заменяет таймауты операций; применяется к запросам, начатым после вызова.
+func (db *DB) SetTimeouts(timeouts config.StorageTimeouts) {
+	db.timeouts.Store(&timeouts)
+}
+
+// Setup дожидается доступности MongoDB, создаёт служебные индексы, если включено, применяет
+// миграции, сверяет валидатор и индексы коллекции контактов и создаёт индексы пользовательских полей и групп.
+// До успешного завершения хранилище считается неготовым.
+func (db *DB) Setup(ctx context.Context) error {
+	const op = "storage.mongo.Setup"
+	log := db.log.With(
+		slog.String("op", op))
+
+	if err := db.Connect(ctx); err != nil {
+		return err
+	}
+
+	if err := db.ensureQuotaIndexes(ctx); err != nil {
+		log.Error("Failed to create quota indexes", sl.Err(err))
+		return err
+	}
+
+	if err := db.setupMigrations(ctx); err != nil {
+		log.Error("Failed to apply migrations", sl.Err(err))
+		return err
+	}
+
+	// После миграций: валидатор описывает уже новую форму документов
+	if err := db.ensureSchema(ctx); err != nil {
+		log.Error("Failed to reconcile contact collection schema", sl.Err(err))
+		return err
+	}
+
+	if err := db.ensureFieldIndexes(ctx); err != nil {
+		log.Error("Failed to create custom field indexes", sl.Err(err))
+		return err
+	}
+
+	if err := db.ensureGroupIndexes(ctx); err != nil {
+		log.Error("Failed to create group indexes", sl.Err(err))
+		return err
+	}
+
+	if err := db.ensureDeletionIndexes(ctx); err != nil {
+		log.Error("Failed to create deletion indexes", sl.Err(err))
+		return err
+	}
+
+	db.ready.Store(true)
+
+	return nil
+}
+
+// Connect дожидается доступности MongoDB, повторяя попытки по политике retry.
+// Служебные команды используют его вместо Setup, чтобы не применять миграции.
+func (db *DB) Connect(ctx context.Context) error {
+	const op = "storage.mongo.Connect"
+	log := db.log.With(
+		slog.String("op", op))
+
+	var err error
+
+	for i := 1; i <= db.retry.Attempts; i++ {
+		err = db.db.Ping(ctx, nil)
 		if err == nil {
 			log.Info("Successfully connected to MongoDB", slog.Int("try number", i))
-			return &DB{client}, nil
+			return nil
+		}
+		if i == db.retry.Attempts {
+			break
+		}
+
+		backoff := db.retry.Backoff(i)
+
+		metrics.StorageConnectRetries.Inc()
+		log.Info("MongoDB connection failed, retrying...",
+			slog.Int("try number", i),
+			slog.Duration("backoff", backoff),
+			sl.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
 		}
-		log.Info("MongoDB connection failed, retrying in 5 seconds...", slog.Int("try number", i))
-		time.Sleep(5 * time.Second)
 	}
 
 	// Если все попытки исчерпаны
 	log.Error("Failed to connect to MongoDB after multiple attempts", sl.Err(err))
-	return nil, err
+	return err
+}
+
+// setupMigrations применяет миграции при включённом auto, иначе только предупреждает об ожидающих.
+func (db *DB) setupMigrations(ctx context.Context) error {
+	log := db.log.With(slog.String("op", "storage.mongo.setupMigrations"))
+
+	if db.migrationsCfg.Auto {
+		_, err := db.MigrateUp(ctx, MigrateOptions{Lazy: db.migrationsCfg.Lazy})
+		return err
+	}
+
+	statuses, _, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if !s.Applied {
+			// Документы всё равно читаются благодаря ленивому преобразованию, но Up-часть не выполнена
+			log.Warn("pending migration, run \"contact-api migrate up\"",
+				slog.Int("version", s.Version), slog.String("description", s.Description))
+		}
+	}
+
+	return nil
+}
+
+// Ping проверяет, что MongoDB отвечает на запросы.
+func (db *DB) Ping(ctx context.Context) error {
+	if err := db.db.Ping(ctx, readpref.Primary()); err != nil {
+		return e.Err("failed to ping mongo", err)
+	}
+
+	return nil
+}
+
+// SetupDone возвращает ошибку, пока Setup не завершился успешно.
+func (db *DB) SetupDone(_ context.Context) error {
+	if !db.ready.Load() {
+		return storage.ErrStorageNotReady
+	}
+
+	return nil
+}
+
+// PoolStats возвращает состояние пулов соединений по каждому серверу.
+func (db *DB) PoolStats() []PoolStats {
+	return db.pool.snapshot()
 }
 
-func (db *DB) Close() {
-	if err := db.db.Disconnect(context.Background()); err != nil {
-		log.Println("Failed to disconnect MongoDB", sl.Err(err))
+// Close отключается от MongoDB, дожидаясь возврата соединений в пул не дольше, чем позволяет ctx.
+func (db *DB) Close(ctx context.Context) {
+	if err := db.db.Disconnect(ctx); err != nil {
+		db.log.Error("Failed to disconnect MongoDB", slog.String("op", "storage.mongo.Close"), sl.Err(err))
 	}
 }
 
-func (db *DB) GetAll() ([]models.Contact, error) {
+// GetAll возвращает контакты, подходящие под opts.Filters, opts.Tags и opts.Group, в порядке идентификаторов
+// или по opts.SortField. Если задан opts.Limit и после страницы остались контакты, next содержит курсор
+// для следующего запроса.
+func (db *DB) GetAll(ctx context.Context, opts storage.ListOptions) (_ []models.Contact, next string, err error) {
+	defer db.observe(ctx, "GetAll", time.Now(), &err)
+
 	var contactsRepo []Contact
 
-	collection := db.db.Database("contacts").Collection("contact-list")
+	filter := fieldFilters(opts.Filters)
+
+	if len(opts.Tags) > 0 {
+		filter = append(filter, bson.D{{Key: "tags", Value: bson.D{{Key: "$all", Value: opts.Tags}}}})
+	}
+
+	if opts.Group != "" {
+		gid, err := primitive.ObjectIDFromHex(opts.Group)
+		if err != nil {
+			return nil, "", storage.ErrGroupNotFound
+		}
+
+		filter = append(filter, bson.D{{Key: "groups", Value: gid}})
+	}
+
+	sortDir := 1
+	if opts.SortDesc {
+		sortDir = -1
+	}
+
+	sort := bson.D{{Key: "_id", Value: 1}}
+	if opts.SortField != "" {
+		// _id в той же сортировке делает порядок однозначным для курсора
+		sort = bson.D{{Key: fieldsPath + opts.SortField, Value: sortDir}, {Key: "_id", Value: sortDir}}
+	}
+
+	if opts.After != "" {
+		after, err := afterFilter(opts)
+		if err != nil {
+			return nil, "", err
+		}
+
+		filter = append(filter, after)
+	}
+
+	findOpts := options.Find().SetSort(sort)
+	if opts.Limit > 0 {
+		// Лишний документ показывает, есть ли следующая страница
+		findOpts.SetLimit(opts.Limit + 1)
+	}
 
-	//TODO: стоит перенести время на запрос в конфигурацию приложения
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().GetAll)
 	defer cancel()
 
-	cursor, err := collection.Find(ctx, bson.D{})
+	query := bson.D{}
+	if len(filter) > 0 {
+		query = bson.D{{Key: "$and", Value: filter}}
+	}
+
+	cursor, err := db.contacts.Find(ctx, query, findOpts)
 	if err != nil {
-		return nil, e.Err("failed to get all contacts", err)
+		return nil, "", e.Err("failed to get all contacts", err)
 	}
 	defer cursor.Close(ctx)
 
-	if err = cursor.All(ctx, &contactsRepo); err != nil {
-		return nil, e.Err("failed to decode contacts", err)
+	for cursor.Next(ctx) {
+		contact, err := decodeContact(cursor.Current)
+		if err != nil {
+			return nil, "", e.Err("failed to decode contact", err)
+		}
+		contactsRepo = append(contactsRepo, contact)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, "", e.Err("failed to get all contacts", err)
+	}
+
+	if opts.Limit > 0 && int64(len(contactsRepo)) > opts.Limit {
+		contactsRepo = contactsRepo[:opts.Limit]
+		last := contactsRepo[len(contactsRepo)-1]
+
+		next = last.ID.Hex()
+		if opts.SortField != "" {
+			next, err = encodeSortCursor(last.Fields[opts.SortField], last.ID)
+			if err != nil {
+				return nil, "", e.Err("failed to encode cursor", err)
+			}
+		}
 	}
 
 	contacts := RepoToContacts(contactsRepo)
 
-	return contacts, nil
+	return contacts, next, nil
+}
+
+// fieldFilters переводит условия на пользовательские поля в условия MongoDB; условия на одно поле объединяются.
+func fieldFilters(filters []storage.FieldFilter) bson.A {
+	var (
+		result bson.A
+		byPath = map[string]int{}
+	)
+
+	for _, f := range filters {
+		path := fieldsPath + f.Field
+		cond := bson.E{Key: "$" + f.Op, Value: f.Value}
+
+		if i, ok := byPath[path]; ok {
+			doc := result[i].(bson.D)
+			doc[0].Value = append(doc[0].Value.(bson.D), cond)
+			continue
+		}
+
+		byPath[path] = len(result)
+		result = append(result, bson.D{{Key: path, Value: bson.D{cond}}})
+	}
+
+	return result
+}
+
+// sortCursor - позиция последнего контакта страницы при сортировке по пользовательскому полю
+type sortCursor struct {
+	Value any    `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeSortCursor(value any, id primitive.ObjectID) (string, error) {
+	data, err := json.Marshal(sortCursor{Value: value, ID: id.Hex()})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
 }
 
-func (db *DB) Save(contact models.Contact) (string, error) {
+// afterFilter выбирает контакты после позиции курсора с учётом сортировки.
+// Контакты без значения поля MongoDB ставит перед остальными по возрастанию и после них по убыванию.
+func afterFilter(opts storage.ListOptions) (bson.D, error) {
+	if opts.SortField == "" {
+		after, err := primitive.ObjectIDFromHex(opts.After)
+		if err != nil {
+			return nil, storage.ErrInvalidCursor
+		}
+
+		return bson.D{{Key: "_id", Value: bson.D{{Key: "$gt", Value: after}}}}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(opts.After)
+	if err != nil {
+		return nil, storage.ErrInvalidCursor
+	}
+
+	var c sortCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, storage.ErrInvalidCursor
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, storage.ErrInvalidCursor
+	}
+
+	path := fieldsPath + opts.SortField
+
+	cmp := "$gt"
+	if opts.SortDesc {
+		cmp = "$lt"
+	}
+
+	sameValueAfter := bson.D{{Key: path, Value: c.Value}, {Key: "_id", Value: bson.D{{Key: cmp, Value: id}}}}
+
+	switch {
+	case c.Value == nil && !opts.SortDesc:
+		return bson.D{{Key: "$or", Value: bson.A{
+			sameValueAfter,
+			bson.D{{Key: path, Value: bson.D{{Key: "$ne", Value: nil}}}},
+		}}}, nil
+
+	case c.Value == nil:
+		return sameValueAfter, nil
+
+	case !opts.SortDesc:
+		return bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: path, Value: bson.D{{Key: cmp, Value: c.Value}}}},
+			sameValueAfter,
+		}}}, nil
+	}
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: path, Value: bson.D{{Key: cmp, Value: c.Value}}}},
+		sameValueAfter,
+		bson.D{{Key: path, Value: nil}},
+	}}}, nil
+}
+
+func (db *DB) Save(ctx context.Context, contact models.Contact) (_ string, err error) {
+	defer db.observe(ctx, "Save", time.Now(), &err)
+
 	repoContact := ContactToRepoWithoutID(contact)
+	repoContact.UpdatedAt = time.Now().UTC()
 
-	collection := db.db.Database("contacts").Collection("contact-list")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Save)
 	defer cancel()
 
-	result, err := collection.InsertOne(ctx, repoContact)
+	result, err := db.contacts.InsertOne(ctx, repoContact)
 	if err != nil {
 		return "", e.Err("failed to insert contact", err)
 	}
@@ -94,24 +442,74 @@ func (db *DB) Save(contact models.Contact) (string, error) {
 	return result.InsertedID.(primitive.ObjectID).Hex(), nil
 }
 
-func (db *DB) DeleteAll() (int64, error) {
-	collection := db.db.Database("contacts").Collection("contact-list")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// SaveMany сохраняет контакты одной командой insertMany без упорядочивания, поэтому ошибка записи
+// одного контакта не мешает остальным. Результаты идут в порядке contacts. Ошибка возвращается,
+// только если неизвестно, какие контакты записаны.
+func (db *DB) SaveMany(ctx context.Context, contacts []models.Contact) (_ []storage.SaveResult, err error) {
+	defer db.observe(ctx, "SaveMany", time.Now(), &err)
+
+	now := time.Now().UTC()
+	results := make([]storage.SaveResult, len(contacts))
+	docs := make([]any, len(contacts))
+
+	for i, contact := range contacts {
+		repoContact := ContactToRepoWithoutID(contact)
+		// Идентификаторы назначаются заранее, чтобы при частичной ошибке знать, какие контакты записаны
+		repoContact.ID = primitive.NewObjectID()
+		repoContact.UpdatedAt = now
+
+		docs[i] = repoContact
+		results[i].ID = repoContact.ID.Hex()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Import)
+	defer cancel()
+
+	_, err = db.contacts.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil && len(bulkErr.WriteErrors) > 0 {
+		for _, we := range bulkErr.WriteErrors {
+			results[we.Index] = storage.SaveResult{Err: e.Err("failed to insert contact", we)}
+		}
+
+		return results, nil
+	}
+	if err != nil {
+		return nil, e.Err("failed to insert contacts", err)
+	}
+
+	return results, nil
+}
+
+// DeleteAll удаляет все контакты; группы остаются, но становятся пустыми.
+func (db *DB) DeleteAll(ctx context.Context) (_ int64, err error) {
+	defer db.observe(ctx, "DeleteAll", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().DeleteAll)
 	defer cancel()
 
-	result, err := collection.DeleteMany(ctx, bson.D{})
+	result, err := db.contacts.DeleteMany(ctx, bson.D{})
 	if err != nil {
 		return 0, fmt.Errorf("failed deleting contacts: %w", err)
 	}
 
+	_, err = db.groups.UpdateMany(ctx, bson.D{}, bson.D{{Key: "$set", Value: bson.D{{Key: "member_count", Value: 0}}}})
+	if err != nil {
+		return 0, fmt.Errorf("failed resetting group member counts: %w", err)
+	}
+
+	if err := db.recordDeleteAll(ctx, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+
 	return result.DeletedCount, nil
 }
 
-func (db *DB) ContactById(id string) (models.Contact, error) {
-	var contactRepo = Contact{}
+func (db *DB) ContactById(ctx context.Context, id string) (_ models.Contact, err error) {
+	defer db.observe(ctx, "ContactById", time.Now(), &err)
 
-	collection := db.db.Database("contacts").Collection("contact-list")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().ContactById)
 	defer cancel()
 
 	mongoId, err := convertStringToObjectID(id)
@@ -119,9 +517,9 @@ func (db *DB) ContactById(id string) (models.Contact, error) {
 		return models.Contact{}, e.Err("error convert id in mongo type", err)
 	}
 
-	filter := bson.D{{"_id", mongoId}}
+	filter := bson.D{{Key: "_id", Value: mongoId}}
 
-	err = collection.FindOne(ctx, filter).Decode(&contactRepo)
+	raw, err := db.contacts.FindOne(ctx, filter).Raw()
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return models.Contact{}, storage.ErrContactNotFound
@@ -130,14 +528,20 @@ func (db *DB) ContactById(id string) (models.Contact, error) {
 		return models.Contact{}, e.Err("failed to get contact", err)
 	}
 
+	contactRepo, err := decodeContact(raw)
+	if err != nil {
+		return models.Contact{}, e.Err("failed to decode contact", err)
+	}
+
 	contact := RepoToContact(contactRepo)
 
 	return contact, nil
 }
 
-func (db *DB) Delete(id string) (bool, error) {
-	collection := db.db.Database("contacts").Collection("contact-list")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+func (db *DB) Delete(ctx context.Context, id string) (_ bool, err error) {
+	defer db.observe(ctx, "Delete", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Delete)
 	defer cancel()
 
 	mongoId, err := convertStringToObjectID(id)
@@ -145,9 +549,15 @@ func (db *DB) Delete(id string) (bool, error) {
 		return false, e.Err("error convert id in mongo type", err)
 	}
 
-	filter := bson.D{{"_id", mongoId}}
+	filter := bson.D{{Key: "_id", Value: mongoId}}
+
+	// Группы удалённого контакта нужны, чтобы уменьшить число их участников
+	var deleted struct {
+		Groups []primitive.ObjectID `bson:"groups"`
+	}
+	opts := options.FindOneAndDelete().SetProjection(bson.D{{Key: "groups", Value: 1}})
 
-	_, err = collection.DeleteOne(ctx, filter)
+	err = db.contacts.FindOneAndDelete(ctx, filter, opts).Decode(&deleted)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return false, storage.ErrContactNotFound
@@ -156,28 +566,31 @@ func (db *DB) Delete(id string) (bool, error) {
 		return false, e.Err("failed to delete contact", err)
 	}
 
+	if err := db.decrementGroups(ctx, deleted.Groups); err != nil {
+		return false, err
+	}
+
+	if err := db.recordDeletion(ctx, storage.DeletedContacts, mongoId.Hex(), time.Now().UTC()); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
-func (db *DB) Update(contact models.Contact) (bool, error) {
+func (db *DB) Update(ctx context.Context, contact models.Contact) (_ bool, err error) {
+	defer db.observe(ctx, "Update", time.Now(), &err)
+
 	contactRepo, err := ContactToRepo(contact)
 	if err != nil {
 		return false, e.Err("error convert to mongo models", err)
 	}
+	contactRepo.UpdatedAt = time.Now().UTC()
 
-	update := bson.M{
-		"$set": contactRepo,
-	}
-
-	collection := db.db.Database("contacts").Collection("contact-list")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Update)
 	defer cancel()
 
-	result, err := collection.UpdateByID(ctx, contactRepo.ID, update)
+	result, err := db.contacts.UpdateOne(ctx, bson.D{{Key: "_id", Value: contactRepo.ID}}, replaceContact(contactRepo))
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return false, storage.ErrContactNotFound
-		}
 		return false, e.Err("failed to update contact", err)
 	}
 
@@ -188,6 +601,153 @@ func (db *DB) Update(contact models.Contact) (bool, error) {
 	return true, nil
 }
 
+// replaceContact возвращает обновление, заменяющее документ контактом целиком: поля, убранные клиентом,
+// и поля старой схемы не должны остаться. Только groups переносится из текущего документа,
+// потому что членство меняется через UpdateMembers. $literal не даёт строкам вида "$..." из контакта
+// стать выражениями.
+func replaceContact(contactRepo Contact) mongo.Pipeline {
+	return mongo.Pipeline{{{Key: "$replaceWith", Value: bson.D{{Key: "$mergeObjects", Value: bson.A{
+		bson.D{{Key: "$literal", Value: contactRepo}},
+		bson.D{{Key: "groups", Value: "$groups"}},
+	}}}}}}
+}
+
+// patchAttempts - сколько раз Patch перечитывает контакт, изменённый между чтением и записью
+const patchAttempts = 3
+
+// Patch читает контакт, применяет к нему apply и записывает результат, только если документ не менялся
+// после чтения; иначе чтение повторяется. Так параллельные частичные обновления разных полей
+// не затирают друг друга. Ошибка apply возвращается без обёртки и прерывает обновление.
+func (db *DB) Patch(ctx context.Context, id string, apply func(*models.Contact) error) (_ models.Contact, err error) {
+	defer db.observe(ctx, "Patch", time.Now(), &err)
+
+	mongoId, err := convertStringToObjectID(id)
+	if err != nil {
+		return models.Contact{}, e.Err("error convert id in mongo type", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().Update)
+	defer cancel()
+
+	for range patchAttempts {
+		raw, err := db.contacts.FindOne(ctx, bson.D{{Key: "_id", Value: mongoId}}).Raw()
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return models.Contact{}, storage.ErrContactNotFound
+			}
+
+			return models.Contact{}, e.Err("failed to get contact", err)
+		}
+
+		current, err := decodeContact(raw)
+		if err != nil {
+			return models.Contact{}, e.Err("failed to decode contact", err)
+		}
+
+		contact := RepoToContact(current)
+		if err := apply(&contact); err != nil {
+			return models.Contact{}, err
+		}
+
+		contactRepo := ContactToRepoWithoutID(contact)
+		contactRepo.ID = mongoId
+		contactRepo.UpdatedAt = time.Now().UTC()
+
+		// Документ сравнивается с прочитанным целиком, включая groups и поля старой схемы
+		filter := bson.D{
+			{Key: "_id", Value: mongoId},
+			{Key: "$expr", Value: bson.D{{Key: "$eq", Value: bson.A{"$$ROOT", bson.D{{Key: "$literal", Value: raw}}}}}},
+		}
+
+		result, err := db.contacts.UpdateOne(ctx, filter, replaceContact(contactRepo))
+		if err != nil {
+			return models.Contact{}, e.Err("failed to update contact", err)
+		}
+
+		if result.MatchedCount > 0 {
+			contactRepo.Groups = current.Groups
+			return RepoToContact(contactRepo), nil
+		}
+	}
+
+	return models.Contact{}, storage.ErrContactChanged
+}
+
+// IncrementQuota увеличивает счётчик записей клиента за указанные сутки и возвращает новое значение.
+// Счётчики хранятся в отдельной коллекции, поэтому переживают перезапуск сервиса.
+func (db *DB) IncrementQuota(ctx context.Context, key string, day string) (_ int64, err error) {
+	defer db.observe(ctx, "IncrementQuota", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().IncrementQuota)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: key + "|" + day}}
+	update := bson.M{
+		"$inc":         bson.M{"count": 1},
+		"$setOnInsert": bson.M{"key": key, "day": day, "created_at": time.Now().UTC()},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var quota Quota
+	if err := db.quotas.FindOneAndUpdate(ctx, filter, update, opts).Decode(&quota); err != nil {
+		return 0, e.Err("failed to increment quota", err)
+	}
+
+	return quota.Count, nil
+}
+
+// RefundQuota уменьшает счётчик записей клиента за указанные сутки, если запись не была принята.
+func (db *DB) RefundQuota(ctx context.Context, key string, day string) (err error) {
+	defer db.observe(ctx, "RefundQuota", time.Now(), &err)
+
+	ctx, cancel := context.WithTimeout(ctx, db.timeouts.Load().IncrementQuota)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: key + "|" + day}, {Key: "count", Value: bson.M{"$gt": 0}}}
+	update := bson.M{"$inc": bson.M{"count": -1}}
+
+	if _, err := db.quotas.UpdateOne(ctx, filter, update); err != nil {
+		return e.Err("failed to refund quota", err)
+	}
+
+	return nil
+}
+
+// ensureQuotaIndexes создаёт TTL-индекс, удаляющий счётчики квот через двое суток.
+func (db *DB) ensureQuotaIndexes(ctx context.Context) error {
+	_, err := db.quotas.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32((48 * time.Hour).Seconds())),
+	})
+	if err != nil {
+		return e.Err("failed to create quota ttl index", err)
+	}
+
+	return nil
+}
+
+// observe записывает метрики операции и отладочную строку в логгер запроса.
+// Вызывается через defer с указателем на именованную ошибку метода.
+func (db *DB) observe(ctx context.Context, operation string, start time.Time, errp *error) {
+	err := *errp
+	metrics.ObserveStorage(operation, start, err)
+
+	log := sl.FromContext(ctx, db.log)
+	if !log.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("op", "storage.mongo."+operation),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, sl.Err(err))
+	}
+
+	log.LogAttrs(ctx, slog.LevelDebug, "storage operation completed", attrs...)
+}
+
 func convertStringToObjectID(idStr string) (primitive.ObjectID, error) {
 	objectID, err := primitive.ObjectIDFromHex(idStr)
 	if err != nil {
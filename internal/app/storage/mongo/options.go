@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"contact-api/internal/app/config"
+	"contact-api/internal/app/metrics"
+	"contact-api/internal/pkg/e"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"os"
+	"strconv"
+)
+
+// clientOptions собирает настройки драйвера из конфигурации приложения.
+func clientOptions(cfg config.Mongo, pool *poolStats) (*options.ClientOptions, error) {
+	opts := options.Client().
+		SetPoolMonitor(pool.monitor(metrics.PoolMonitor())).
+		SetMonitor(otelmongo.NewMonitor())
+
+	if cfg.URI != "" {
+		opts.ApplyURI(cfg.URI)
+	} else {
+		opts.SetHosts(cfg.Hosts)
+	}
+
+	if cfg.User != "" {
+		opts.SetAuth(options.Credential{
+			Username:   cfg.User,
+			Password:   cfg.Password,
+			AuthSource: cfg.AuthSource,
+		})
+	}
+
+	opts.SetMinPoolSize(cfg.MinPoolSize).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+
+	mode, err := readpref.ModeFromString(cfg.ReadPreference)
+	if err != nil {
+		return nil, e.Err("invalid read preference", err)
+	}
+
+	rp, err := readpref.New(mode)
+	if err != nil {
+		return nil, e.Err("invalid read preference", err)
+	}
+	opts.SetReadPreference(rp)
+
+	opts.SetWriteConcern(writeConcern(cfg.WriteConcern))
+
+	if cfg.TLS.Enabled {
+		tlsCfg, err := tlsConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsCfg)
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, e.Err("invalid mongo options", err)
+	}
+
+	return opts, nil
+}
+
+func writeConcern(cfg config.WriteConcern) *writeconcern.WriteConcern {
+	wc := &writeconcern.WriteConcern{
+		Journal:  &cfg.Journal,
+		WTimeout: cfg.WTimeout,
+	}
+
+	if n, err := strconv.Atoi(cfg.W); err == nil {
+		wc.W = n
+	} else {
+		wc.W = cfg.W
+	}
+
+	return wc
+}
+
+func tlsConfig(cfg config.TLS) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, e.Err("failed to read mongo CA file", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("both mongo tls cert_file and key_file must be set")
+		}
+
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, e.Err("failed to load mongo client certificate", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/event"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PoolStats - состояние пула соединений к одному серверу MongoDB.
+type PoolStats struct {
+	Address          string `json:"address"`
+	Open             int64  `json:"open"`
+	InUse            int64  `json:"in_use"`
+	CheckoutFailures int64  `json:"checkout_failures"`
+	Cleared          int64  `json:"cleared"`
+}
+
+// poolStats накапливает события пула для отдачи в admin-эндпоинте.
+// Метрики Prometheus обновляются отдельным монитором из пакета metrics.
+type poolStats struct {
+	mu     sync.Mutex
+	byAddr map[string]*PoolStats
+}
+
+func newPoolStats() *poolStats {
+	return &poolStats{byAddr: make(map[string]*PoolStats)}
+}
+
+// monitor возвращает монитор, который передаёт события в next и учитывает их в статистике.
+func (s *poolStats) monitor(next *event.PoolMonitor) *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			next.Event(evt)
+			s.observe(evt)
+		},
+	}
+}
+
+func (s *poolStats) observe(evt *event.PoolEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.byAddr[evt.Address]
+	if !ok {
+		st = &PoolStats{Address: evt.Address}
+		s.byAddr[evt.Address] = st
+	}
+
+	switch evt.Type {
+	case event.ConnectionCreated:
+		st.Open++
+	case event.ConnectionClosed:
+		st.Open--
+	case event.GetSucceeded:
+		st.InUse++
+	case event.ConnectionReturned:
+		st.InUse--
+	case event.GetFailed:
+		st.CheckoutFailures++
+	case event.PoolCleared:
+		// Выданные соединения остаются занятыми до возврата: драйвер пришлёт ConnectionReturned
+		// и для них, поэтому обнуление здесь увело бы счётчик в минус.
+		st.Cleared++
+	}
+}
+
+func (s *poolStats) snapshot() []PoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]PoolStats, 0, len(s.byAddr))
+	for _, st := range s.byAddr {
+		stats = append(stats, *st)
+	}
+
+	slices.SortFunc(stats, func(a, b PoolStats) int { return strings.Compare(a.Address, b.Address) })
+
+	return stats
+}
@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/event"
+	"testing"
+)
+
+func TestPoolStats(t *testing.T) {
+	stats := newPoolStats()
+
+	var forwarded int
+	monitor := stats.monitor(&event.PoolMonitor{Event: func(*event.PoolEvent) { forwarded++ }})
+
+	events := []struct {
+		addr string
+		typ  string
+	}{
+		{"b:27017", event.ConnectionCreated},
+		{"a:27017", event.ConnectionCreated},
+		{"a:27017", event.ConnectionCreated},
+		{"a:27017", event.GetSucceeded},
+		{"a:27017", event.GetSucceeded},
+		{"a:27017", event.GetFailed},
+		// Соединения, выданные до очистки, возвращаются уже после неё
+		{"a:27017", event.PoolCleared},
+		{"a:27017", event.ConnectionReturned},
+		{"a:27017", event.ConnectionReturned},
+		{"a:27017", event.ConnectionClosed},
+	}
+	for _, ev := range events {
+		monitor.Event(&event.PoolEvent{Type: ev.typ, Address: ev.addr})
+	}
+
+	if forwarded != len(events) {
+		t.Errorf("forwarded %d events, want %d", forwarded, len(events))
+	}
+
+	got := stats.snapshot()
+	want := []PoolStats{
+		{Address: "a:27017", Open: 1, InUse: 0, CheckoutFailures: 1, Cleared: 1},
+		{Address: "b:27017", Open: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("snapshot = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("snapshot[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package mongo
+
+import (
+	"bytes"
+	"contact-api/internal/app/config"
+	"contact-api/internal/pkg/e"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// contactIndexes - индексы коллекции контактов, которые должны существовать.
+// Сверяются по ключам, а не по именам, поэтому индексы, созданные вручную с другим именем, тоже засчитываются.
+var contactIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "emails.address", Value: 1}}},
+	{Keys: bson.D{{Key: "username", Value: 1}}},
+	{Keys: bson.D{{Key: "phones.number", Value: 1}}},
+	{Keys: bson.D{{Key: "tags", Value: 1}}},
+	// Участники группы выбираются постранично в порядке идентификаторов
+	{Keys: bson.D{{Key: "groups", Value: 1}, {Key: "_id", Value: 1}}},
+	// Инкрементальные резервные копии выбирают контакты по времени изменения
+	{Keys: bson.D{{Key: "updated_at", Value: 1}}},
+}
+
+// Drift - расхождение коллекции с ожидаемой схемой.
+type Drift struct {
+	// collection, validator, index
+	Kind   string
+	Detail string
+	// Расхождение устраняется в режиме apply; лишние индексы не удаляются никогда
+	Fixable bool
+}
+
+// ensureSchema сверяет коллекцию контактов с моделью Contact и, в режиме apply, устраняет расхождения.
+func (db *DB) ensureSchema(ctx context.Context) error {
+	const op = "storage.mongo.ensureSchema"
+	log := db.log.With(slog.String("op", op))
+
+	if db.schemaCfg.Mode == config.SchemaOff {
+		return nil
+	}
+
+	drift, err := db.SchemaDrift(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(drift) == 0 {
+		log.Info("contact collection schema is up to date")
+		return nil
+	}
+
+	if db.schemaCfg.Mode != config.SchemaApply {
+		for _, d := range drift {
+			log.Warn("contact collection schema drift", slog.String("kind", d.Kind), slog.String("detail", d.Detail))
+		}
+		return nil
+	}
+
+	for _, d := range drift {
+		if !d.Fixable {
+			log.Warn("contact collection schema drift, not changed automatically", slog.String("kind", d.Kind), slog.String("detail", d.Detail))
+		}
+	}
+
+	if err := db.ApplySchema(ctx); err != nil {
+		return err
+	}
+
+	for _, d := range drift {
+		if d.Fixable {
+			log.Info("contact collection schema drift fixed", slog.String("kind", d.Kind), slog.String("detail", d.Detail))
+		}
+	}
+
+	return nil
+}
+
+// SchemaDrift сравнивает коллекцию контактов с ожидаемыми валидатором и индексами, ничего не меняя.
+func (db *DB) SchemaDrift(ctx context.Context) ([]Drift, error) {
+	spec, err := db.collectionSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec == nil {
+		return []Drift{{Kind: "collection", Detail: fmt.Sprintf("%s does not exist", db.contacts.Name()), Fixable: true}}, nil
+	}
+
+	var drift []Drift
+
+	for _, opt := range db.validatorOptions() {
+		t, want, err := bson.MarshalValue(opt.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		got := spec.Options.Lookup(opt.Key)
+		if got.Type != t || !bytes.Equal(got.Value, want) {
+			drift = append(drift, Drift{Kind: "validator", Detail: opt.Key + " differs from the Contact model", Fixable: true})
+		}
+	}
+
+	existing, err := db.contacts.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return nil, e.Err("failed to list contact indexes", err)
+	}
+
+	for _, idx := range contactIndexes {
+		if !hasIndex(existing, idx.Keys.(bson.D)) {
+			drift = append(drift, Drift{Kind: "index", Detail: "missing index on " + indexKeys(idx.Keys.(bson.D)), Fixable: true})
+		}
+	}
+
+	for _, spec := range existing {
+		if spec.Name == "_id_" || isFieldIndex(spec) {
+			continue
+		}
+
+		declared := false
+		for _, idx := range contactIndexes {
+			if sameKeys(spec.KeysDocument, idx.Keys.(bson.D)) {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			drift = append(drift, Drift{Kind: "index", Detail: fmt.Sprintf("unexpected index %s", spec.Name)})
+		}
+	}
+
+	return drift, nil
+}
+
+// ApplySchema создаёт коллекцию контактов с валидатором или обновляет его через collMod
+// и создаёт недостающие индексы. Лишние индексы не удаляются.
+func (db *DB) ApplySchema(ctx context.Context) error {
+	spec, err := db.collectionSpec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if spec == nil {
+		createOpts := options.CreateCollection().
+			SetValidator(bson.D{{Key: "$jsonSchema", Value: contactSchema}}).
+			SetValidationLevel(validationLevel).
+			SetValidationAction(db.schemaCfg.ValidationAction)
+
+		if err := db.contacts.Database().CreateCollection(ctx, db.contacts.Name(), createOpts); err != nil {
+			return e.Err("failed to create contact collection", err)
+		}
+	} else {
+		cmd := append(bson.D{{Key: "collMod", Value: db.contacts.Name()}}, db.validatorOptions()...)
+		if err := db.contacts.Database().RunCommand(ctx, cmd).Err(); err != nil {
+			return e.Err("failed to update contact collection validator", err)
+		}
+	}
+
+	existing, err := db.contacts.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return e.Err("failed to list contact indexes", err)
+	}
+
+	var missing []mongo.IndexModel
+	for _, idx := range contactIndexes {
+		if !hasIndex(existing, idx.Keys.(bson.D)) {
+			missing = append(missing, idx)
+		}
+	}
+
+	if len(missing) > 0 {
+		if _, err := db.contacts.Indexes().CreateMany(ctx, missing); err != nil {
+			return e.Err("failed to create contact indexes", err)
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) collectionSpec(ctx context.Context) (*mongo.CollectionSpecification, error) {
+	specs, err := db.contacts.Database().ListCollectionSpecifications(ctx, bson.D{{Key: "name", Value: db.contacts.Name()}})
+	if err != nil {
+		return nil, e.Err("failed to read contact collection options", err)
+	}
+
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	return specs[0], nil
+}
+
+// Уровень moderate не проверяет изменения документов, которые уже не соответствовали схеме,
+// поэтому записанные до появления валидатора контакты остаются доступными для обновления.
+const validationLevel = "moderate"
+
+// validatorOptions возвращает параметры валидатора под теми же ключами, под которыми их хранит MongoDB.
+func (db *DB) validatorOptions() bson.D {
+	return bson.D{
+		{Key: "validator", Value: bson.D{{Key: "$jsonSchema", Value: contactSchema}}},
+		{Key: "validationLevel", Value: validationLevel},
+		{Key: "validationAction", Value: db.schemaCfg.ValidationAction},
+	}
+}
+
+// contactSchema - $jsonSchema, построенная по bson-тегам Contact: поля без omitempty обязательны.
+var contactSchema = jsonSchema(reflect.TypeOf(Contact{}))
+
+var (
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+func jsonSchema(t reflect.Type) bson.D {
+	switch t {
+	case objectIDType:
+		return bson.D{{Key: "bsonType", Value: "objectId"}}
+	case timeType:
+		return bson.D{{Key: "bsonType", Value: "date"}}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return bson.D{{Key: "bsonType", Value: "string"}}
+	case reflect.Bool:
+		return bson.D{{Key: "bsonType", Value: "bool"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return bson.D{{Key: "bsonType", Value: bson.A{"int", "long"}}}
+	case reflect.Float32, reflect.Float64:
+		return bson.D{{Key: "bsonType", Value: "number"}}
+	case reflect.Slice, reflect.Array:
+		return bson.D{{Key: "bsonType", Value: "array"}, {Key: "items", Value: jsonSchema(t.Elem())}}
+	case reflect.Map:
+		return bson.D{{Key: "bsonType", Value: "object"}, {Key: "additionalProperties", Value: jsonSchema(t.Elem())}}
+	case reflect.Interface:
+		// Значения пользовательских полей: тип проверяется по их описаниям при записи
+		return bson.D{}
+	case reflect.Pointer:
+		schema := jsonSchema(t.Elem())
+		if types, ok := schema[0].Value.(bson.A); ok {
+			schema[0].Value = append(types, "null")
+		} else {
+			schema[0].Value = bson.A{schema[0].Value, "null"}
+		}
+		return schema
+	case reflect.Struct:
+	default:
+		panic("mongo: no json schema for " + t.String())
+	}
+
+	properties := bson.D{}
+	required := bson.A{}
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		properties = append(properties, bson.E{Key: name, Value: jsonSchema(f.Type)})
+
+		if !strings.Contains(opts, "omitempty") && name != "_id" {
+			required = append(required, name)
+		}
+	}
+
+	schema := bson.D{{Key: "bsonType", Value: "object"}}
+	if len(required) > 0 {
+		schema = append(schema, bson.E{Key: "required", Value: required})
+	}
+
+	return append(schema, bson.E{Key: "properties", Value: properties})
+}
+
+func hasIndex(existing []*mongo.IndexSpecification, keys bson.D) bool {
+	for _, spec := range existing {
+		if sameKeys(spec.KeysDocument, keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameKeys сравнивает ключи индекса по полям и направлению; числовой тип значения не важен.
+func sameKeys(raw bson.Raw, want bson.D) bool {
+	elems, err := raw.Elements()
+	if err != nil || len(elems) != len(want) {
+		return false
+	}
+
+	for i, el := range elems {
+		if el.Key() != want[i].Key {
+			return false
+		}
+
+		got, ok := el.Value().AsInt64OK()
+		if !ok {
+			// Текстовые и гео-индексы задаются строкой
+			if s, ok := el.Value().StringValueOK(); !ok || s != fmt.Sprint(want[i].Value) {
+				return false
+			}
+			continue
+		}
+
+		if n, ok := want[i].Value.(int); !ok || int64(n) != got {
+			return false
+		}
+	}
+
+	return true
+}
+
+func indexKeys(keys bson.D) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s:%v", k.Key, k.Value)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
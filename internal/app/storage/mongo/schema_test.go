@@ -0,0 +1,246 @@
+package mongo
+
+import (
+	"contact-api/internal/app/config"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJSONSchemaScalars(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want bson.D
+	}{
+		{"string", "", bson.D{{Key: "bsonType", Value: "string"}}},
+		{"bool", false, bson.D{{Key: "bsonType", Value: "bool"}}},
+		{"int", 0, bson.D{{Key: "bsonType", Value: bson.A{"int", "long"}}}},
+		{"uint8", uint8(0), bson.D{{Key: "bsonType", Value: bson.A{"int", "long"}}}},
+		{"float", 0.0, bson.D{{Key: "bsonType", Value: "number"}}},
+		{"object id", primitive.ObjectID{}, bson.D{{Key: "bsonType", Value: "objectId"}}},
+		{"time", time.Time{}, bson.D{{Key: "bsonType", Value: "date"}}},
+		{"slice", []string{}, bson.D{{Key: "bsonType", Value: "array"}, {Key: "items", Value: bson.D{{Key: "bsonType", Value: "string"}}}}},
+		{"map", map[string]float64{}, bson.D{{Key: "bsonType", Value: "object"}, {Key: "additionalProperties", Value: bson.D{{Key: "bsonType", Value: "number"}}}}},
+		{"any", map[string]any{}, bson.D{{Key: "bsonType", Value: "object"}, {Key: "additionalProperties", Value: bson.D{}}}},
+		{"pointer", new(string), bson.D{{Key: "bsonType", Value: bson.A{"string", "null"}}}},
+		{"pointer to int", new(int), bson.D{{Key: "bsonType", Value: bson.A{"int", "long", "null"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonSchema(reflect.TypeOf(tt.v)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("jsonSchema = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaStruct(t *testing.T) {
+	type inner struct {
+		Label string `bson:"label"`
+	}
+	type doc struct {
+		ID       primitive.ObjectID `bson:"_id,omitempty"`
+		Required string             `bson:"required"`
+		Optional []inner            `bson:"optional,omitempty"`
+		Untagged int
+		Skipped  string `bson:"-"`
+		hidden   string
+	}
+	_ = doc{}.hidden
+
+	want := bson.D{
+		{Key: "bsonType", Value: "object"},
+		{Key: "required", Value: bson.A{"required", "untagged"}},
+		{Key: "properties", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "bsonType", Value: "objectId"}}},
+			{Key: "required", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+			{Key: "optional", Value: bson.D{
+				{Key: "bsonType", Value: "array"},
+				{Key: "items", Value: bson.D{
+					{Key: "bsonType", Value: "object"},
+					{Key: "required", Value: bson.A{"label"}},
+					{Key: "properties", Value: bson.D{{Key: "label", Value: bson.D{{Key: "bsonType", Value: "string"}}}}},
+				}},
+			}},
+			// Без тега драйвер называет поле именем в нижнем регистре
+			{Key: "untagged", Value: bson.D{{Key: "bsonType", Value: bson.A{"int", "long"}}}},
+		}},
+	}
+
+	if got := jsonSchema(reflect.TypeOf(doc{})); !reflect.DeepEqual(got, want) {
+		t.Errorf("jsonSchema =\n%v\nwant\n%v", got, want)
+	}
+
+	// Структура без обязательных полей не получает пустой required: MongoDB его не принимает
+	type optional struct {
+		A string `bson:"a,omitempty"`
+	}
+	if got := jsonSchema(reflect.TypeOf(optional{})); len(got) != 2 || got[1].Key != "properties" {
+		t.Errorf("jsonSchema of optional struct = %v", got)
+	}
+}
+
+func TestJSONSchemaUnsupported(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("jsonSchema accepted a channel")
+		}
+	}()
+
+	jsonSchema(reflect.TypeOf(make(chan int)))
+}
+
+// lookupSchema спускается по properties схемы к вложенному полю.
+func lookupSchema(t *testing.T, schema bson.D, path ...string) bson.D {
+	t.Helper()
+
+	for _, name := range path {
+		props, _ := valueOf(schema, "properties").(bson.D)
+		next, ok := valueOf(props, name).(bson.D)
+		if !ok {
+			t.Fatalf("schema has no property %v", path)
+		}
+		schema = next
+	}
+	return schema
+}
+
+func valueOf(d bson.D, key string) any {
+	for _, e := range d {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+func TestContactSchema(t *testing.T) {
+	if got := valueOf(contactSchema, "required"); !reflect.DeepEqual(got, bson.A{"username"}) {
+		t.Errorf("required = %v, want only username", got)
+	}
+
+	// Каждое поле документа описано в схеме
+	props, _ := valueOf(contactSchema, "properties").(bson.D)
+	ct := reflect.TypeOf(Contact{})
+	if len(props) != ct.NumField() {
+		t.Errorf("schema has %d properties, Contact has %d fields", len(props), ct.NumField())
+	}
+
+	tests := []struct {
+		path []string
+		key  string
+		want any
+	}{
+		{[]string{"_id"}, "bsonType", "objectId"},
+		{[]string{"updated_at"}, "bsonType", "date"},
+		{[]string{"schema_version"}, "bsonType", bson.A{"int", "long"}},
+		{[]string{"name", "display"}, "bsonType", "string"},
+		{[]string{"groups"}, "items", bson.D{{Key: "bsonType", Value: "objectId"}}},
+		{[]string{"fields"}, "additionalProperties", bson.D{}},
+	}
+
+	for _, tt := range tests {
+		if got := valueOf(lookupSchema(t, contactSchema, tt.path...), tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v.%s = %v, want %v", tt.path, tt.key, got, tt.want)
+		}
+	}
+
+	// Элементы списков обязаны иметь метку и значение
+	email, _ := valueOf(lookupSchema(t, contactSchema, "emails"), "items").(bson.D)
+	if got := valueOf(email, "required"); !reflect.DeepEqual(got, bson.A{"label", "address"}) {
+		t.Errorf("emails.items.required = %v", got)
+	}
+
+	if _, err := bson.Marshal(bson.D{{Key: "$jsonSchema", Value: contactSchema}}); err != nil {
+		t.Errorf("schema does not marshal: %v", err)
+	}
+}
+
+// collectionResponse - ответ listCollections с коллекцией контактов и заданными параметрами.
+func collectionResponse(options bson.D) bson.D {
+	return mtest.CreateCursorResponse(0, "test.$cmd.listCollections", mtest.FirstBatch, bson.D{
+		{Key: "name", Value: "contacts"},
+		{Key: "type", Value: "collection"},
+		{Key: "options", Value: options},
+		{Key: "info", Value: bson.D{{Key: "readOnly", Value: false}}},
+	})
+}
+
+// indexResponse - ответ listIndexes со всеми индексами из contactIndexes и extra.
+func indexResponse(extra ...bson.D) bson.D {
+	docs := []bson.D{{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}, {Key: "name", Value: "_id_"}}}
+	for _, idx := range contactIndexes {
+		keys := idx.Keys.(bson.D)
+		docs = append(docs, bson.D{{Key: "v", Value: 2}, {Key: "key", Value: keys}, {Key: "name", Value: indexKeys(keys)}})
+	}
+	docs = append(docs, extra...)
+
+	return mtest.CreateCursorResponse(0, "test.contacts", mtest.FirstBatch, docs...)
+}
+
+func TestSchemaDrift(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock).CollectionName("contacts"))
+
+	schemaDB := func(mt *mtest.T) *DB {
+		return &DB{log: discardLogger(), contacts: mt.Coll, schemaCfg: config.Schema{Mode: config.SchemaApply, ValidationAction: "error"}}
+	}
+
+	mt.Run("up to date", func(mt *mtest.T) {
+		db := schemaDB(mt)
+		mt.AddMockResponses(collectionResponse(db.validatorOptions()), indexResponse())
+
+		drift, err := db.SchemaDrift(context.Background())
+		if err != nil || len(drift) != 0 {
+			mt.Errorf("SchemaDrift = %+v, %v; want no drift", drift, err)
+		}
+	})
+
+	mt.Run("missing collection", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.$cmd.listCollections", mtest.FirstBatch))
+
+		drift, err := schemaDB(mt).SchemaDrift(context.Background())
+		if err != nil || len(drift) != 1 || drift[0].Kind != "collection" || !drift[0].Fixable {
+			mt.Errorf("SchemaDrift = %+v, %v", drift, err)
+		}
+	})
+
+	mt.Run("outdated validator and indexes", func(mt *mtest.T) {
+		db := schemaDB(mt)
+
+		// Валидатор прежней модели, где email был обязательным
+		options := db.validatorOptions()
+		options[0].Value = bson.D{{Key: "$jsonSchema", Value: bson.D{
+			{Key: "bsonType", Value: "object"},
+			{Key: "required", Value: bson.A{"username", "email"}},
+		}}}
+		options[2].Value = "warn"
+
+		indexes := indexResponse(bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "email", Value: 1}}}, {Key: "name", Value: "email_1"}})
+
+		mt.AddMockResponses(collectionResponse(options), indexes)
+
+		drift, err := db.SchemaDrift(context.Background())
+		if err != nil {
+			mt.Fatalf("SchemaDrift: %v", err)
+		}
+
+		var got []string
+		for _, d := range drift {
+			got = append(got, d.Detail)
+			if d.Fixable == (d.Detail == "unexpected index email_1") {
+				mt.Errorf("%q: fixable = %t", d.Detail, d.Fixable)
+			}
+		}
+		want := []string{"validator differs from the Contact model", "validationAction differs from the Contact model", "unexpected index email_1"}
+		if !slices.Equal(got, want) {
+			mt.Errorf("drift = %q, want %q", got, want)
+		}
+	})
+}
@@ -1,7 +1,110 @@
 package storage
 
-import "errors"
+import (
+	"contact-api/internal/app/domain/models"
+	"errors"
+	"time"
+)
 
 var (
 	ErrContactNotFound = errors.New("contact not found")
+	ErrStorageNotReady = errors.New("storage is not ready")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrFieldNotFound   = errors.New("custom field not found")
+	// Тип существующего поля не меняется: сохранённые значения перестали бы ему соответствовать
+	ErrFieldTypeChanged = errors.New("custom field type cannot be changed")
+	ErrGroupNotFound    = errors.New("group not found")
+	ErrGroupExists      = errors.New("group with this name already exists")
+	// Контакт менялся другими запросами при каждой попытке частичного обновления
+	ErrContactChanged = errors.New("contact was changed concurrently")
+)
+
+// MaxBatchSize - наибольшее число контактов в одном запросе на изменение членства или импорт
+const MaxBatchSize = 1000
+
+// MaxPageSize - наибольший размер страницы списка контактов
+const MaxPageSize = 1000
+
+// ListOptions - параметры постраничного чтения контактов. Без SortField контакты идут в порядке идентификаторов.
+type ListOptions struct {
+	// 0 - вернуть все контакты одним ответом
+	Limit int64
+	// Курсор, полученный вместе с предыдущей страницей; действителен только с той же сортировкой
+	After string
+	// Условия на пользовательские поля, объединяются по И
+	Filters []FieldFilter
+	// Имя пользовательского поля для сортировки; контакты без значения идут первыми по возрастанию
+	SortField string
+	SortDesc  bool
+	// Только контакты со всеми перечисленными метками
+	Tags []string
+	// Только контакты из группы с этим идентификатором
+	Group string
+}
+
+// Операции сравнения FieldFilter
+const (
+	FilterEq  = "eq"
+	FilterNe  = "ne"
+	FilterGt  = "gt"
+	FilterGte = "gte"
+	FilterLt  = "lt"
+	FilterLte = "lte"
 )
+
+// FieldFilter - условие на значение пользовательского поля.
+type FieldFilter struct {
+	Field string
+	Op    string
+	// Значение, приведённое FieldDefinition.ParseValue
+	Value any
+}
+
+// MembershipChange - итог изменения членства: сколько контактов было добавлено и убрано.
+// Контакты, которые уже были в нужном состоянии или не существуют, не учитываются.
+type MembershipChange struct {
+	Added   int64
+	Removed int64
+}
+
+// SaveResult - итог сохранения одного контакта при импорте: идентификатор или ошибка записи.
+type SaveResult struct {
+	ID  string
+	Err error
+}
+
+// TagCount - метка и число контактов с ней.
+type TagCount struct {
+	Tag   string
+	Count int64
+}
+
+// ContactRecord - контакт вместе со служебными полями хранилища; используется при резервном копировании.
+type ContactRecord struct {
+	Contact models.Contact
+	// Время последнего изменения; нулевое у документов, записанных до появления поля
+	UpdatedAt time.Time
+}
+
+// Коллекции, удаления в которых записываются в DeletionRecord
+const (
+	DeletedContacts = "contacts"
+	DeletedGroups   = "groups"
+	DeletedFields   = "fields"
+)
+
+// DeletionRecord - отметка об удалении; по ним инкрементальная копия переносит удаления.
+type DeletionRecord struct {
+	Collection string
+	// Идентификатор контакта или группы, имя поля; пустой у контактов означает удаление всех контактов
+	ID        string
+	DeletedAt time.Time
+}
+
+// QuotaRecord - суточный счётчик записей клиента.
+type QuotaRecord struct {
+	Key       string
+	Day       string
+	Count     int64
+	CreatedAt time.Time
+}
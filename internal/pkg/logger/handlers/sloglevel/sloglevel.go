@@ -0,0 +1,47 @@
+package sloglevel
+
+import (
+	"context"
+	"log/slog"
+)
+
+// OverrideFunc возвращает уровень, действующий для контекста записи, если он переопределён.
+type OverrideFunc func(ctx context.Context) (slog.Level, bool)
+
+// Handler пропускает запись, если её уровень не ниже общего уровня
+// или уровня, переопределённого для контекста. Вложенный обработчик
+// должен пропускать все уровни, иначе переопределение не подействует.
+type Handler struct {
+	next     slog.Handler
+	level    slog.Leveler
+	override OverrideFunc
+}
+
+func New(next slog.Handler, level slog.Leveler, override OverrideFunc) *Handler {
+	return &Handler{next: next, level: level, override: override}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level >= h.level.Level() {
+		return true
+	}
+
+	if h.override == nil {
+		return false
+	}
+
+	threshold, ok := h.override(ctx)
+	return ok && level >= threshold
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs), level: h.level, override: h.override}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), level: h.level, override: h.override}
+}
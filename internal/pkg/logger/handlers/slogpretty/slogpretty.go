@@ -1,96 +1,277 @@
 package slogpretty
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 	"io"
-	stdLog "log"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 )
 
+const timeFormat = "[15:04:05.000]"
+
 type PrettyHandlerOptions struct {
 	SlogOpts *slog.HandlerOptions
+	// Отключить цвета; также отключаются при NO_COLOR и выводе не в терминал
+	NoColor bool
+	// Печатать запись одной строкой вместо многострочного JSON
+	Compact bool
 }
 
+// PrettyHandler выводит записи в удобном для чтения в терминале виде:
+// время, уровень, источник, сообщение и атрибуты с учётом групп.
 type PrettyHandler struct {
-	opts PrettyHandlerOptions
-	slog.Handler
-	l     *stdLog.Logger
-	attrs []slog.Attr
+	opts  PrettyHandlerOptions
+	color bool
+
+	mu  *sync.Mutex
+	out io.Writer
+
+	// Атрибуты из WithAttrs вместе с группами, открытыми на момент их добавления
+	attrs  []groupedAttr
+	groups []string
+}
+
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// multiline - строковое значение с переводами строк, которое печатается отдельным блоком.
+type multiline struct {
+	key   string
+	value string
 }
 
 func (opts PrettyHandlerOptions) NewPrettyHandler(
 	out io.Writer,
 ) *PrettyHandler {
-	h := &PrettyHandler{
-		Handler: slog.NewJSONHandler(out, opts.SlogOpts),
-		l:       stdLog.New(out, "", 0),
+	if opts.SlogOpts == nil {
+		opts.SlogOpts = &slog.HandlerOptions{}
+	}
+
+	return &PrettyHandler{
+		opts:  opts,
+		color: !opts.NoColor && os.Getenv("NO_COLOR") == "" && isTerminal(out),
+		mu:    &sync.Mutex{},
+		out:   out,
 	}
+}
 
-	return h
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.SlogOpts.Level != nil {
+		minLevel = h.opts.SlogOpts.Level.Level()
+	}
+
+	return level >= minLevel
 }
 
 func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
-	level := r.Level.String() + ":"
+	fields := make(map[string]any)
+	var blocks []multiline
 
-	switch r.Level {
-	case slog.LevelDebug:
-		level = color.MagentaString(level)
-	case slog.LevelInfo:
-		level = color.BlueString(level)
-	case slog.LevelWarn:
-		level = color.YellowString(level)
-	case slog.LevelError:
-		level = color.RedString(level)
+	for _, ga := range h.attrs {
+		h.addAttr(fields, &blocks, ga.groups, ga.attr)
 	}
 
-	fields := make(map[string]interface{}, r.NumAttrs())
-
 	r.Attrs(func(a slog.Attr) bool {
-		fields[a.Key] = a.Value.Any()
-
+		h.addAttr(fields, &blocks, h.groups, a)
 		return true
 	})
 
-	for _, a := range h.attrs {
-		fields[a.Key] = a.Value.Any()
+	buf := &bytes.Buffer{}
+
+	if !r.Time.IsZero() {
+		buf.WriteString(r.Time.Format(timeFormat))
+		buf.WriteByte(' ')
+	}
+
+	buf.WriteString(h.paint(levelColor(r.Level), r.Level.String()+":"))
+	buf.WriteByte(' ')
+
+	if h.opts.SlogOpts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			src := fmt.Sprintf("%s/%s:%d", filepath.Base(filepath.Dir(frame.File)), filepath.Base(frame.File), frame.Line)
+			buf.WriteString(h.paint(color.FgHiBlack, src))
+			buf.WriteByte(' ')
+		}
 	}
 
-	var b []byte
-	var err error
+	buf.WriteString(h.paint(color.FgCyan, r.Message))
 
 	if len(fields) > 0 {
-		b, err = json.MarshalIndent(fields, "", "  ")
+		var b []byte
+		var err error
+
+		if h.opts.Compact {
+			b, err = json.Marshal(fields)
+		} else {
+			b, err = json.MarshalIndent(fields, "", "  ")
+		}
 		if err != nil {
 			return err
 		}
+
+		buf.WriteByte(' ')
+		buf.WriteString(h.paint(color.FgWhite, string(b)))
 	}
 
-	timeStr := r.Time.Format("[15:05:05.000]")
-	msg := color.CyanString(r.Message)
+	for _, block := range blocks {
+		buf.WriteString("\n  ")
+		buf.WriteString(h.paint(color.FgWhite, block.key+":"))
+		for _, line := range strings.Split(strings.TrimRight(block.value, "\n"), "\n") {
+			buf.WriteString("\n    ")
+			buf.WriteString(line)
+		}
+	}
 
-	h.l.Println(
-		timeStr,
-		level,
-		msg,
-		color.WhiteString(string(b)),
-	)
+	buf.WriteByte('\n')
 
-	return nil
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.out.Write(buf.Bytes())
+	return err
 }
 
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &PrettyHandler{
-		Handler: h.Handler,
-		l:       h.l,
-		attrs:   attrs,
+	if len(attrs) == 0 {
+		return h
 	}
+
+	h2 := h.clone()
+	for _, a := range attrs {
+		h2.attrs = append(h2.attrs, groupedAttr{groups: h.groups, attr: a})
+	}
+
+	return h2
 }
 
 func (h *PrettyHandler) WithGroup(name string) slog.Handler {
-	return &PrettyHandler{
-		Handler: h.Handler.WithGroup(name),
-		l:       h.l,
+	if name == "" {
+		return h
 	}
+
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+
+	return h2
+}
+
+func (h *PrettyHandler) clone() *PrettyHandler {
+	h2 := *h
+	h2.attrs = append([]groupedAttr(nil), h.attrs...)
+	h2.groups = append([]string(nil), h.groups...)
+	return &h2
+}
+
+// addAttr кладёт атрибут во вложенную карту по пути из групп.
+// Пустые атрибуты и пустые группы пропускаются, группы без ключа встраиваются в текущий уровень.
+func (h *PrettyHandler) addAttr(fields map[string]any, blocks *[]multiline, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() != slog.KindGroup && h.opts.SlogOpts.ReplaceAttr != nil {
+		a = h.opts.SlogOpts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+
+		path := groups
+		if a.Key != "" {
+			path = append(append([]string(nil), groups...), a.Key)
+		}
+		for _, ga := range attrs {
+			h.addAttr(fields, blocks, path, ga)
+		}
+
+		return
+	}
+
+	value := attrValue(a.Value)
+
+	if s, ok := value.(string); ok && !h.opts.Compact && strings.Contains(s, "\n") {
+		*blocks = append(*blocks, multiline{key: strings.Join(append(append([]string(nil), groups...), a.Key), "."), value: s})
+		return
+	}
+
+	target := fields
+	for _, g := range groups {
+		next, ok := target[g].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			target[g] = next
+		}
+		target = next
+	}
+
+	target[a.Key] = value
+}
+
+func attrValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return v.Any()
+	default:
+		return v.Any()
+	}
+}
+
+func levelColor(level slog.Level) color.Attribute {
+	switch {
+	case level >= slog.LevelError:
+		return color.FgRed
+	case level >= slog.LevelWarn:
+		return color.FgYellow
+	case level >= slog.LevelInfo:
+		return color.FgBlue
+	default:
+		return color.FgMagenta
+	}
+}
+
+func (h *PrettyHandler) paint(attr color.Attribute, s string) string {
+	if !h.color {
+		return s
+	}
+
+	c := color.New(attr)
+	c.EnableColor()
+
+	return c.Sprint(s)
+}
+
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
 }
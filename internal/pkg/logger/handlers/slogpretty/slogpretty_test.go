@@ -0,0 +1,130 @@
+package slogpretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+// parseRecords разбирает вывод обработчика обратно в записи: "[время] LEVEL: сообщение {атрибуты}".
+// Атрибуты читаются json.Decoder, поэтому подходят и однострочный, и многострочный JSON.
+func parseRecords(out string) ([]map[string]any, error) {
+	var records []map[string]any
+
+	for out != "" {
+		m := make(map[string]any)
+
+		if strings.HasPrefix(out, "[") {
+			end := strings.IndexByte(out, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated time in %q", out)
+			}
+			m[slog.TimeKey] = out[1:end]
+			out = strings.TrimPrefix(out[end+1:], " ")
+		}
+
+		level, rest, ok := strings.Cut(out, ": ")
+		if !ok {
+			return nil, fmt.Errorf("no level in %q", out)
+		}
+		m[slog.LevelKey] = level
+		out = rest
+
+		header, _, _ := strings.Cut(out, "\n")
+		if msg, _, ok := strings.Cut(header, " {"); ok {
+			m[slog.MessageKey] = msg
+			out = out[len(msg)+1:]
+
+			dec := json.NewDecoder(strings.NewReader(out))
+			dec.UseNumber()
+			if err := dec.Decode(&m); err != nil {
+				return nil, fmt.Errorf("failed to decode attrs: %w", err)
+			}
+			out = out[dec.InputOffset():]
+		} else {
+			m[slog.MessageKey] = header
+			out = out[len(header):]
+		}
+
+		if !strings.HasPrefix(out, "\n") {
+			return nil, fmt.Errorf("record is not terminated by newline: %q", out)
+		}
+		out = out[1:]
+
+		records = append(records, m)
+	}
+
+	return records, nil
+}
+
+func TestHandlerConformance(t *testing.T) {
+	for _, compact := range []bool{true, false} {
+		t.Run(fmt.Sprintf("compact=%t", compact), func(t *testing.T) {
+			var buf bytes.Buffer
+			h := PrettyHandlerOptions{NoColor: true, Compact: compact}.NewPrettyHandler(&buf)
+
+			err := slogtest.TestHandler(h, func() []map[string]any {
+				records, err := parseRecords(buf.String())
+				if err != nil {
+					t.Fatalf("%v\noutput:\n%s", err, buf.String())
+				}
+				return records
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestHandlerFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(PrettyHandlerOptions{
+		SlogOpts: &slog.HandlerOptions{Level: slog.LevelDebug},
+		NoColor:  true,
+	}.NewPrettyHandler(&buf))
+
+	log.With(slog.String("op", "test")).WithGroup("req").Debug("done",
+		slog.Duration("took", 1500*time.Millisecond),
+		slog.Any("err", errors.New("boom")),
+		slog.String("body", "line 1\nline 2\n"),
+	)
+
+	out := buf.String()
+	for _, want := range []string{
+		"DEBUG: done {\n",
+		`"op": "test"`,
+		`"took": "1.5s"`,
+		`"err": "boom"`,
+		"\n  req.body:\n    line 1\n    line 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("colors are not disabled:\n%q", out)
+	}
+}
+
+func TestHandlerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(PrettyHandlerOptions{NoColor: true, Compact: true}.NewPrettyHandler(&buf))
+
+	log.Debug("hidden")
+	log.Info("shown", slog.Int("n", 1))
+
+	records, err := parseRecords(buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0][slog.MessageKey] != "shown" || records[0][slog.LevelKey] != "INFO" {
+		t.Errorf("records = %v, want only the info record", records)
+	}
+}
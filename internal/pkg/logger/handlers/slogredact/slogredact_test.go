@@ -0,0 +1,250 @@
+package slogredact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/slogtest"
+)
+
+const uri = "mongodb://app:s3cret@db:27017/contacts"
+
+var testOptions = Options{
+	URICredentials: true,
+	Email:          ModeMask,
+	Phone:          ModeMask,
+	EmailKeys:      []string{"email"},
+	PhoneKeys:      []string{"phone", "Mobile"},
+}
+
+// newTestLogger возвращает логгер с Handler поверх JSON-обработчика и буфер его вывода.
+func newTestLogger(opts Options) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return slog.New(New(slog.NewJSONHandler(&buf, nil), opts)), &buf
+}
+
+// lastRecord разбирает последнюю запись из вывода JSON-обработчика.
+func lastRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	var m map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &m); err != nil {
+		t.Fatalf("failed to decode %q: %v", lines[len(lines)-1], err)
+	}
+	return m
+}
+
+// contactValue - значение с собственным представлением в логе.
+type contactValue struct{ email string }
+
+func (c contactValue) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("email", c.email), slog.String("name", "Ann"))
+}
+
+// dsn печатается через String(), а не как структура.
+type dsn struct{ host string }
+
+func (d dsn) String() string { return "mongodb://root:hunter2@" + d.host }
+
+func TestURICredentials(t *testing.T) {
+	u, _ := url.Parse(uri)
+
+	tests := []struct {
+		name string
+		attr slog.Attr
+	}{
+		{"text", slog.String("note", "connecting to "+uri)},
+		{"string", slog.String("uri", uri)},
+		{"error", slog.Any("uri", errors.New("connect "+uri+": refused"))},
+		{"stringer", slog.Any("uri", u)},
+		{"custom stringer", slog.Any("uri", dsn{host: "db"})},
+		{"struct", slog.Any("uri", struct{ URI string }{uri})},
+		{"map", slog.Any("uri", map[string]string{"uri": uri})},
+		{"slice", slog.Any("uri", []string{"first", uri})},
+		{"bson", slog.Any("uri", bson.D{{Key: "uri", Value: uri}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Текстовый обработчик печатает значения через fmt, как и большинство форматов логов
+			var buf bytes.Buffer
+			log := slog.New(New(slog.NewTextHandler(&buf, nil), testOptions))
+			log.Info("connecting", tt.attr)
+
+			out := buf.String()
+			if strings.Contains(out, "s3cret") || strings.Contains(out, "hunter2") {
+				t.Fatalf("password leaked: %s", out)
+			}
+			if !strings.Contains(out, ":REDACTED@") {
+				t.Errorf("password is not replaced: %s", out)
+			}
+		})
+	}
+
+	log, buf := newTestLogger(testOptions)
+	log.Info("connecting to " + uri)
+	if msg := lastRecord(t, buf)["msg"]; msg != "connecting to mongodb://app:REDACTED@db:27017/contacts" {
+		t.Errorf("msg = %q", msg)
+	}
+
+	// Без совпадений значение не превращается в строку и сохраняет структуру
+	log, buf = newTestLogger(testOptions)
+	log.Info("clean", slog.Any("hosts", []string{"db:27017"}))
+	if hosts, ok := lastRecord(t, buf)["hosts"].([]any); !ok || len(hosts) != 1 {
+		t.Errorf("clean value changed: %s", buf)
+	}
+
+	// С выключенной опцией строка подключения не трогается
+	log, buf = newTestLogger(Options{})
+	log.Info("connecting", slog.String("uri", uri))
+	if lastRecord(t, buf)["uri"] != uri {
+		t.Errorf("uri changed with URICredentials off: %s", buf)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		want string
+	}{
+		{ModeMask, "a***@example.com"},
+		{ModeDrop, "[email]"},
+		{ModeNone, "ann@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			opts := testOptions
+			opts.Email = tt.mode
+
+			log, buf := newTestLogger(opts)
+			log.Info("sent to ann@example.com", slog.String("note", "cc ann@example.com"))
+
+			rec := lastRecord(t, buf)
+			if rec["msg"] != "sent to "+tt.want || rec["note"] != "cc "+tt.want {
+				t.Errorf("msg %q, note %q; want %q inside", rec["msg"], rec["note"], tt.want)
+			}
+		})
+	}
+
+	t.Run("by key", func(t *testing.T) {
+		opts := testOptions
+		opts.Email = ModeDrop
+
+		log, buf := newTestLogger(opts)
+		log.Info("saved", slog.String("Email", "not-an-address"), slog.String("other", "kept"))
+
+		rec := lastRecord(t, buf)
+		if _, ok := rec["Email"]; ok || rec["other"] != "kept" {
+			t.Errorf("drop by key: %v", rec)
+		}
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		opts := testOptions
+		opts.Email = ModeHash
+		opts.HashSalt = "salt"
+
+		log, buf := newTestLogger(opts)
+		log.Info("saved", slog.String("email", "Ann@Example.com"), slog.String("note", "ann@example.com"))
+
+		rec := lastRecord(t, buf)
+		hash, _ := rec["email"].(string)
+		// Хеш не зависит от регистра, поэтому одно и то же значение сопоставляется между записями
+		if !strings.HasPrefix(hash, "sha256:") || rec["note"] != hash {
+			t.Errorf("email %q, note %q", rec["email"], rec["note"])
+		}
+	})
+}
+
+func TestPhone(t *testing.T) {
+	log, buf := newTestLogger(testOptions)
+	log.Info("saved",
+		slog.String("phone", "+1 (555) 010-2345"),
+		slog.String("MOBILE", "12"),
+		slog.String("home", "+1 555 010 9999"))
+
+	rec := lastRecord(t, buf)
+	if rec["phone"] != "***45" || rec["MOBILE"] != "***" {
+		t.Errorf("phone %q, mobile %q", rec["phone"], rec["MOBILE"])
+	}
+	// home нет в PhoneKeys, а по содержимому телефоны не ищутся
+	if rec["home"] != "+1 555 010 9999" {
+		t.Errorf("home = %q", rec["home"])
+	}
+}
+
+func TestGroupsAndLogValuer(t *testing.T) {
+	log, buf := newTestLogger(testOptions)
+	log.Info("saved",
+		slog.Group("contact",
+			slog.String("email", "ann@example.com"),
+			slog.Group("phones", slog.String("phone", "5550102345")),
+		),
+		slog.Any("owner", contactValue{email: "bob@example.com"}),
+	)
+
+	rec := lastRecord(t, buf)
+
+	contact, _ := rec["contact"].(map[string]any)
+	phones, _ := contact["phones"].(map[string]any)
+	if contact["email"] != "a***@example.com" || phones["phone"] != "***45" {
+		t.Errorf("contact = %v", rec["contact"])
+	}
+
+	owner, _ := rec["owner"].(map[string]any)
+	if owner["email"] != "b***@example.com" || owner["name"] != "Ann" {
+		t.Errorf("owner = %v", rec["owner"])
+	}
+}
+
+func TestWithAttrsAndGroup(t *testing.T) {
+	opts := testOptions
+	opts.Phone = ModeDrop
+
+	log, buf := newTestLogger(opts)
+	log.With(slog.String("email", "ann@example.com"), slog.String("phone", "5550102345")).
+		WithGroup("req").
+		Info("saved", slog.String("email", "bob@example.com"), slog.String("uri", uri))
+
+	rec := lastRecord(t, buf)
+	if rec["email"] != "a***@example.com" {
+		t.Errorf("email from WithAttrs = %v", rec["email"])
+	}
+	if _, ok := rec["phone"]; ok {
+		t.Errorf("dropped phone from WithAttrs is logged: %v", rec["phone"])
+	}
+
+	req, _ := rec["req"].(map[string]any)
+	if req["email"] != "b***@example.com" || strings.Contains(req["uri"].(string), "s3cret") {
+		t.Errorf("attrs inside WithGroup = %v", req)
+	}
+}
+
+func TestSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(slog.NewJSONHandler(&buf, nil), testOptions)
+
+	results := func() []map[string]any {
+		var records []map[string]any
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, m)
+		}
+		return records
+	}
+
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
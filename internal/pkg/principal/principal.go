@@ -0,0 +1,16 @@
+package principal
+
+import "context"
+
+type ctxKey struct{}
+
+// WithPrincipal сохраняет имя аутентифицированного клиента в контексте запроса.
+func WithPrincipal(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, name)
+}
+
+// FromContext возвращает имя клиента или пустую строку, если запрос анонимный.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(ctxKey{}).(string)
+	return name
+}
@@ -0,0 +1,16 @@
+package routepath
+
+import "strings"
+
+// Normalize приводит шаблоны chi вида /v1/contact/{uid}/ и /v1/contact// к виду без лишних слешей,
+// в котором маршруты записаны в спецификации и в переопределениях уровня логирования.
+func Normalize(route string) string {
+	for strings.Contains(route, "//") {
+		route = strings.ReplaceAll(route, "//", "/")
+	}
+	if len(route) > 1 {
+		route = strings.TrimSuffix(route, "/")
+	}
+
+	return route
+}
@@ -0,0 +1,22 @@
+package routepath
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"/":                       "/",
+		"//":                      "/",
+		"/v1/contact":             "/v1/contact",
+		"/v1/contact/":            "/v1/contact",
+		"/v1/contact//{uid}/":     "/v1/contact/{uid}",
+		"/v1///group/{gid}//":     "/v1/group/{gid}",
+		"/v1/group/{gid}/members": "/v1/group/{gid}/members",
+	}
+
+	for in, want := range tests {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tokenbucket
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock - управляемое время для лимитера.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(rate, burst)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestAllowBurstThenReject(t *testing.T) {
+	l, _ := newTestLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		res := l.Allow("a")
+		if !res.Allowed {
+			t.Fatalf("request %d rejected within burst", i)
+		}
+		if res.Remaining != 2-i {
+			t.Errorf("request %d: remaining %d, want %d", i, res.Remaining, 2-i)
+		}
+	}
+
+	res := l.Allow("a")
+	if res.Allowed {
+		t.Fatal("request over burst allowed")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("retry after %v, want 1s", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Errorf("reset %v, want 3s", res.Reset)
+	}
+
+	if !l.Allow("b").Allowed {
+		t.Error("other key shares the bucket")
+	}
+}
+
+func TestAllowRefill(t *testing.T) {
+	l, clock := newTestLimiter(2, 2)
+
+	l.Allow("a")
+	l.Allow("a")
+	if l.Allow("a").Allowed {
+		t.Fatal("empty bucket allowed a request")
+	}
+
+	// 2 токена в секунду: через 500ms появляется ровно один
+	clock.advance(500 * time.Millisecond)
+	if !l.Allow("a").Allowed {
+		t.Fatal("token was not refilled")
+	}
+	if l.Allow("a").Allowed {
+		t.Fatal("refilled more than one token")
+	}
+
+	// Долгий простой не копит токены сверх burst
+	clock.advance(time.Hour)
+	for i := 0; i < 2; i++ {
+		if !l.Allow("a").Allowed {
+			t.Fatalf("request %d rejected after full refill", i)
+		}
+	}
+	if l.Allow("a").Allowed {
+		t.Fatal("bucket exceeded burst after idle period")
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	l, clock := newTestLimiter(1, 2)
+
+	l.Allow("full")
+	l.Allow("empty")
+	l.Allow("empty")
+
+	clock.advance(time.Second)
+	l.Cleanup()
+
+	if _, ok := l.buckets["full"]; ok {
+		t.Error("refilled bucket was not removed")
+	}
+	if _, ok := l.buckets["empty"]; !ok {
+		t.Error("partially refilled bucket was removed")
+	}
+}
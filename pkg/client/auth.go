@@ -0,0 +1,31 @@
+package client
+
+import "net/http"
+
+// Authenticator добавляет к запросу учётные данные.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthFunc позволяет использовать функцию как Authenticator, например для токенов с обновлением.
+type AuthFunc func(req *http.Request) error
+
+func (f AuthFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// APIKey передаёт ключ в заголовке X-API-Key; сервер использует его как ключ клиента для лимитов.
+func APIKey(key string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("X-API-Key", key)
+		return nil
+	})
+}
+
+// BearerToken передаёт токен в заголовке Authorization.
+func BearerToken(token string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
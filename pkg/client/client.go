@@ -0,0 +1,202 @@
+// Package client - типизированный клиент HTTP API контактов (/v1/contact).
+//
+//	c, err := client.New("https://contacts.example.com", client.WithAuth(client.APIKey(key)))
+//	for contact, err := range c.All(ctx, 100) {
+//		...
+//	}
+//
+// Идемпотентные запросы (GET, PUT, PATCH, DELETE) повторяются при сетевых ошибках и ответах 502, 503, 504.
+// Ответ 429 повторяется для любых запросов с учётом Retry-After, потому что сервер не начинал их обработку.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// RequestIDHeader - заголовок, по которому запрос находится в логах сервера
+	RequestIDHeader = "X-Request-ID"
+
+	defaultTimeout   = 30 * time.Second
+	defaultUserAgent = "contact-api-client/1"
+
+	mergePatchType = "application/merge-patch+json"
+)
+
+// Client - клиент API контактов. Безопасен для использования из нескольких горутин.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	auth       Authenticator
+	retry      RetryPolicy
+	userAgent  string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задаёт HTTP-клиент, например с собственным транспортом или таймаутом.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithAuth задаёт способ аутентификации запросов.
+func WithAuth(auth Authenticator) Option {
+	return func(c *Client) {
+		c.auth = auth
+	}
+}
+
+// WithRetry заменяет политику повторов; RetryPolicy{} отключает повторы.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// New создаёт клиент для сервера baseURL, например https://contacts.example.com.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: scheme and host are required", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		retry:      DefaultRetryPolicy,
+		userAgent:  defaultUserAgent,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// response - ответ сервера с уже прочитанным телом.
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do выполняет запрос с повторами и декодирует успешный ответ в out, если он не nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) (*response, error) {
+	contentType := "application/json"
+	if _, ok := in.(mergePatch); ok {
+		contentType = mergePatchType
+	}
+
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	idempotent := method != http.MethodPost
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, method, path, query, contentType, payload)
+
+		wait, retry := c.retry.next(attempt, idempotent, resp, err)
+		if !retry {
+			if err != nil {
+				return nil, err
+			}
+
+			return resp, c.decode(resp, out)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = c.decode(resp, nil)
+			}
+			return nil, errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, contentType string, payload []byte) (*response, error) {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return &response{status: httpResp.StatusCode, header: httpResp.Header, body: data}, nil
+}
+
+func (c *Client) decode(resp *response, out any) error {
+	if resp.status < 200 || resp.status > 299 {
+		return newAPIError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(resp.body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
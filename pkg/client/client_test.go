@@ -0,0 +1,123 @@
+package client_test
+
+import (
+	"contact-api/pkg/client"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, baseURL string, opts ...client.Option) *client.Client {
+	t.Helper()
+
+	c, err := client.New(baseURL, opts...)
+	if err != nil {
+		t.Fatalf("client.New: %v", err)
+	}
+	return c
+}
+
+func TestRetry(t *testing.T) {
+	policy := client.RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		method   func(ctx context.Context, c *client.Client) error
+		statuses []int
+		calls    int32
+		wantErr  error
+	}{
+		{
+			name:     "get retried on 503",
+			method:   func(ctx context.Context, c *client.Client) error { _, err := c.Get(ctx, "a"); return err },
+			statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			calls:    3,
+		},
+		{
+			name: "create not retried on 503",
+			method: func(ctx context.Context, c *client.Client) error {
+				_, err := c.Create(ctx, client.Contact{})
+				return err
+			},
+			statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+			calls:    1,
+			wantErr:  client.ErrServer,
+		},
+		{
+			name: "create retried on 429",
+			method: func(ctx context.Context, c *client.Client) error {
+				_, err := c.Create(ctx, client.Contact{})
+				return err
+			},
+			statuses: []int{http.StatusTooManyRequests, http.StatusOK},
+			calls:    2,
+		},
+		{
+			name: "patch retried on 503",
+			method: func(ctx context.Context, c *client.Client) error {
+				_, err := c.MergePatch(ctx, "a", map[string]any{"title": "x"})
+				return err
+			},
+			statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+			calls:    2,
+		},
+		{
+			name: "batch not retried on 503",
+			method: func(ctx context.Context, c *client.Client) error {
+				_, err := c.Batch(ctx, []client.Contact{{}})
+				return err
+			},
+			statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+			calls:    1,
+			wantErr:  client.ErrServer,
+		},
+		{
+			name:     "attempts exhausted",
+			method:   func(ctx context.Context, c *client.Client) error { return c.Delete(ctx, "a") },
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			calls:    3,
+			wantErr:  client.ErrServer,
+		},
+		{
+			name:     "client error not retried",
+			method:   func(ctx context.Context, c *client.Client) error { _, err := c.Get(ctx, "a"); return err },
+			statuses: []int{http.StatusBadRequest, http.StatusOK},
+			calls:    1,
+			wantErr:  client.ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[calls.Add(1)-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				_, _ = io.WriteString(w, `{"_id":"a","id":"a","slug":"test"}`)
+			}))
+			defer srv.Close()
+
+			c := newTestClient(t, srv.URL, client.WithRetry(policy))
+
+			err := tt.method(context.Background(), c)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.calls {
+				t.Errorf("server got %d requests, want %d", got, tt.calls)
+			}
+		})
+	}
+}
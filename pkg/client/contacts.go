@@ -0,0 +1,544 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+const contactsPath = "/v1/contact"
+
+// MaxPageSize - наибольший размер страницы, который принимает сервер
+const MaxPageSize = 1000
+
+var ErrMissingID = errors.New("contact id is required")
+
+// Метки элементов списков контакта
+const (
+	LabelHome   = "home"
+	LabelWork   = "work"
+	LabelMobile = "mobile"
+	LabelFax    = "fax"
+	LabelOther  = "other"
+)
+
+type Contact struct {
+	// Назначается сервером, при создании игнорируется
+	ID       string `json:"_id,omitempty"`
+	UserName string `json:"username"`
+	Name     Name   `json:"name"`
+
+	Emails    []Email   `json:"emails,omitempty"`
+	Phones    []Phone   `json:"phones,omitempty"`
+	Addresses []Address `json:"addresses,omitempty"`
+	URLs      []URL     `json:"urls,omitempty"`
+
+	Organization string `json:"organization,omitempty"`
+	Title        string `json:"title,omitempty"`
+	// YYYY-MM-DD или --MM-DD, если год неизвестен
+	Birthday string `json:"birthday,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+
+	// Значения пользовательских полей по имени, см. FieldDefinition. Сервер возвращает
+	// числа как float64; при записи пустая строка или nil удаляют значение.
+	Fields map[string]any `json:"fields,omitempty"`
+
+	// Метки контакта; повторы и пробелы по краям сервер убирает
+	Tags []string `json:"tags,omitempty"`
+	// Идентификаторы групп, только для чтения: состав групп меняется через UpdateMembers
+	Groups []string `json:"groups,omitempty"`
+
+	// Устарело: сервер учитывает Email и Telephone, только если соответствующий список пуст,
+	// поэтому у прочитанного контакта менять нужно списки.
+	Email     string    `json:"email,omitempty"`
+	Telephone Telephone `json:"telephone"`
+}
+
+type Name struct {
+	Display string `json:"display,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Family  string `json:"family,omitempty"`
+}
+
+type Email struct {
+	// LabelHome, LabelWork, LabelOther
+	Label   string `json:"label"`
+	Address string `json:"address"`
+}
+
+type Phone struct {
+	// LabelMobile, LabelHome, LabelWork, LabelFax, LabelOther
+	Label  string `json:"label"`
+	Number string `json:"number"`
+}
+
+type Address struct {
+	// LabelHome, LabelWork, LabelOther
+	Label      string `json:"label"`
+	Street     string `json:"street,omitempty"`
+	City       string `json:"city,omitempty"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+type URL struct {
+	// LabelHome, LabelWork, LabelOther
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Telephone - устаревшая пара телефонов, см. Contact.Telephone
+type Telephone struct {
+	Mobile string `json:"mobile,omitempty"`
+	Home   string `json:"home,omitempty"`
+}
+
+type ListOptions struct {
+	// 0 - вся коллекция одним ответом
+	Limit int
+	// NextCursor предыдущей страницы, полученной с той же сортировкой
+	Cursor string
+	// Условия по пользовательским полям, объединяются через И
+	Filters []FieldFilter
+	// Сортировка по пользовательскому полю: "field.имя" или "-field.имя" по убыванию.
+	// Пусто - в порядке идентификаторов.
+	Sort string
+	// Только контакты со всеми указанными метками
+	Tags []string
+	// Только участники группы с этим идентификатором
+	Group string
+}
+
+// Операции сравнения в FieldFilter
+const (
+	FilterEq  = "eq"
+	FilterNe  = "ne"
+	FilterGt  = "gt"
+	FilterGte = "gte"
+	FilterLt  = "lt"
+	FilterLte = "lte"
+)
+
+// FieldFilter - условие на значение пользовательского поля.
+type FieldFilter struct {
+	Field string
+	// FilterEq, если пусто
+	Op    string
+	Value string
+}
+
+type Page struct {
+	Contacts []Contact
+	// Пусто на последней странице
+	NextCursor string
+}
+
+// List возвращает одну страницу контактов в порядке идентификаторов или opts.Sort.
+func (c *Client) List(ctx context.Context, opts ListOptions) (*Page, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	for _, f := range opts.Filters {
+		key := "field[" + f.Field + "]"
+		if f.Op != "" && f.Op != FilterEq {
+			key += "[" + f.Op + "]"
+		}
+		query.Add(key, f.Value)
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	for _, tag := range opts.Tags {
+		query.Add("tag", tag)
+	}
+	if opts.Group != "" {
+		query.Set("group", opts.Group)
+	}
+
+	return c.page(ctx, contactsPath, query)
+}
+
+// page запрашивает страницу контактов по path и достаёт курсор следующей из заголовка Link.
+func (c *Client) page(ctx context.Context, path string, query url.Values) (*Page, error) {
+	var contacts []Contact
+
+	resp, err := c.do(ctx, http.MethodGet, path, query, nil, &contacts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page{
+		Contacts:   contacts,
+		NextCursor: nextCursor(resp.header.Get("Link")),
+	}, nil
+}
+
+// All перебирает все контакты, запрашивая их страницами по pageSize.
+// Ошибка возвращается последним элементом, после неё перебор прекращается.
+func (c *Client) All(ctx context.Context, pageSize int) iter.Seq2[Contact, error] {
+	return c.Query(ctx, ListOptions{Limit: pageSize})
+}
+
+// Query перебирает контакты, подходящие под opts.Filters, в порядке opts.Sort,
+// запрашивая их страницами по opts.Limit. opts.Cursor задаёт начальную позицию.
+func (c *Client) Query(ctx context.Context, opts ListOptions) iter.Seq2[Contact, error] {
+	if opts.Limit <= 0 || opts.Limit > MaxPageSize {
+		opts.Limit = MaxPageSize
+	}
+
+	return func(yield func(Contact, error) bool) {
+		// Копия, чтобы повторный перебор начинался с исходного курсора
+		opts := opts
+
+		for {
+			page, err := c.List(ctx, opts)
+			if err != nil {
+				yield(Contact{}, err)
+				return
+			}
+
+			for _, contact := range page.Contacts {
+				if !yield(contact, nil) {
+					return
+				}
+			}
+
+			if page.NextCursor == "" {
+				return
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+}
+
+func (c *Client) Get(ctx context.Context, id string) (*Contact, error) {
+	if id == "" {
+		return nil, ErrMissingID
+	}
+
+	var contact Contact
+
+	if _, err := c.do(ctx, http.MethodGet, contactPath(id), nil, nil, &contact); err != nil {
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+// Create сохраняет контакт и возвращает назначенный сервером идентификатор.
+func (c *Client) Create(ctx context.Context, contact Contact) (string, error) {
+	contact.ID = ""
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+
+	if _, err := c.do(ctx, http.MethodPost, contactsPath, nil, contact, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.ID, nil
+}
+
+// Update заменяет контакт с идентификатором contact.ID целиком.
+func (c *Client) Update(ctx context.Context, contact Contact) error {
+	if contact.ID == "" {
+		return ErrMissingID
+	}
+
+	_, err := c.do(ctx, http.MethodPut, contactPath(contact.ID), nil, contact, nil)
+	return err
+}
+
+func (c *Client) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return ErrMissingID
+	}
+
+	_, err := c.do(ctx, http.MethodDelete, contactPath(id), nil, nil, nil)
+	return err
+}
+
+// DeleteAll удаляет все контакты и возвращает их число.
+func (c *Client) DeleteAll(ctx context.Context) (int64, error) {
+	var resp struct {
+		Deleted int64 `json:"deleted"`
+	}
+
+	if _, err := c.do(ctx, http.MethodDelete, contactsPath, nil, nil, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.Deleted, nil
+}
+
+// Patch читает контакт, применяет к нему fn и отправляет серверу только изменённые поля через MergePatch.
+// Поля, которые fn не трогала, не перезаписываются, поэтому их изменения другими клиентами между
+// чтением и записью сохраняются. Возвращает контакт после изменения; если fn ничего не изменила,
+// запрос на запись не отправляется.
+func (c *Client) Patch(ctx context.Context, id string, fn func(*Contact) error) (*Contact, error) {
+	contact, err := c.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := toMap(contact)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fn(contact); err != nil {
+		return nil, err
+	}
+
+	after, err := toMap(contact)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := mergeDiff(before, after)
+	// Идентификатор и группы только для чтения
+	delete(patch, "_id")
+	delete(patch, "groups")
+
+	if len(patch) == 0 {
+		contact.ID = id
+		return contact, nil
+	}
+
+	return c.MergePatch(ctx, id, patch)
+}
+
+// mergePatch - тело запроса в формате JSON Merge Patch, см. Client.do
+type mergePatch map[string]any
+
+// MergePatch изменяет контакт по JSON Merge Patch (RFC 7396): ключи, которых нет в patch, не меняются,
+// nil удаляет значение, списки заменяются целиком. Возвращает контакт после изменения.
+// Если контакт менялся другими запросами при каждой попытке сервера, возвращается ErrConflict.
+func (c *Client) MergePatch(ctx context.Context, id string, patch map[string]any) (*Contact, error) {
+	if id == "" {
+		return nil, ErrMissingID
+	}
+
+	var contact Contact
+
+	if _, err := c.do(ctx, http.MethodPatch, contactPath(id), nil, mergePatch(patch), &contact); err != nil {
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+func toMap(contact *Contact) (map[string]any, error) {
+	data, err := json.Marshal(contact)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode contact: %w", err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to encode contact: %w", err)
+	}
+
+	return m, nil
+}
+
+// mergeDiff возвращает JSON Merge Patch, превращающий from в to. Вложенные объекты сравниваются
+// по ключам, остальные значения, включая списки, заменяются целиком.
+func mergeDiff(from, to map[string]any) map[string]any {
+	patch := map[string]any{}
+
+	for k, fv := range from {
+		tv, ok := to[k]
+		if !ok {
+			patch[k] = nil
+			continue
+		}
+
+		fm, fok := fv.(map[string]any)
+		tm, tok := tv.(map[string]any)
+		if fok && tok {
+			if diff := mergeDiff(fm, tm); len(diff) > 0 {
+				patch[k] = diff
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(fv, tv) {
+			patch[k] = tv
+		}
+	}
+
+	for k, tv := range to {
+		if _, ok := from[k]; !ok {
+			patch[k] = tv
+		}
+	}
+
+	return patch
+}
+
+// BatchResult - итог создания одного контакта из пакета.
+type BatchResult struct {
+	// Позиция контакта во входных данных
+	Index int
+	ID    string
+	// *APIError с кодом, который получил бы контакт, созданный отдельным запросом
+	Err error
+}
+
+// Batch создаёт контакты запросами импорта по MaxBatchSize контактов. Сервер проверяет и сохраняет
+// каждый контакт отдельно: отклонённые не мешают остальным, причина отказа возвращается в Err
+// их результата. Ошибка запроса прерывает импорт, и results содержит итоги уже обработанных пакетов.
+// Результаты идут в порядке входных данных.
+func (c *Client) Batch(ctx context.Context, contacts []Contact) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(contacts))
+
+	for start := 0; start < len(contacts); start += MaxBatchSize {
+		chunk, err := c.batch(ctx, contacts[start:min(start+MaxBatchSize, len(contacts))], start)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, chunk...)
+	}
+
+	return results, nil
+}
+
+// batch отправляет один запрос импорта; offset - позиция первого контакта во входных данных Batch.
+func (c *Client) batch(ctx context.Context, contacts []Contact, offset int) ([]BatchResult, error) {
+	// Идентификаторы назначает сервер
+	body := make([]Contact, len(contacts))
+	for i, contact := range contacts {
+		contact.ID = ""
+		body[i] = contact
+	}
+
+	var out struct {
+		Results []struct {
+			Index      int         `json:"index"`
+			ID         string      `json:"id"`
+			Status     int         `json:"status"`
+			Slug       string      `json:"slug"`
+			Violations []Violation `json:"violations"`
+		} `json:"results"`
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, contactsPath+"/import", nil, body, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(out.Results))
+	for i, r := range out.Results {
+		results[i] = BatchResult{Index: offset + r.Index, ID: r.ID}
+
+		if r.Status != 0 {
+			results[i].Err = &APIError{
+				StatusCode: r.Status,
+				Slug:       r.Slug,
+				Violations: r.Violations,
+				RequestID:  resp.header.Get(RequestIDHeader),
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// Import читает контакты из JSON-массива или из потока JSON-объектов (NDJSON) и создаёт их через Batch.
+// Если вход не удалось разобрать, ни один контакт не отправляется.
+func (c *Client) Import(ctx context.Context, r io.Reader) ([]BatchResult, error) {
+	contacts, err := decodeContacts(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Batch(ctx, contacts)
+}
+
+func decodeContacts(r io.Reader) ([]Contact, error) {
+	br := bufio.NewReader(r)
+
+	first, err := peekNonSpace(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	dec := json.NewDecoder(br)
+	dec.DisallowUnknownFields()
+
+	if first == '[' {
+		var contacts []Contact
+		if err := dec.Decode(&contacts); err != nil {
+			return nil, fmt.Errorf("failed to decode contacts: %w", err)
+		}
+		return contacts, nil
+	}
+
+	var contacts []Contact
+	for {
+		var contact Contact
+		if err := dec.Decode(&contact); err != nil {
+			if errors.Is(err, io.EOF) {
+				return contacts, nil
+			}
+			return nil, fmt.Errorf("failed to decode contact %d: %w", len(contacts)+1, err)
+		}
+		contacts = append(contacts, contact)
+	}
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b, br.UnreadByte()
+	}
+}
+
+func contactPath(id string) string {
+	return contactsPath + "/" + url.PathEscape(id)
+}
+
+var nextLinkRe = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="?next"?`)
+
+// nextCursor извлекает курсор из ссылки rel="next" заголовка Link.
+func nextCursor(link string) string {
+	m := nextLinkRe.FindStringSubmatch(link)
+	if m == nil {
+		return ""
+	}
+
+	u, err := url.Parse(m[1])
+	if err != nil {
+		return ""
+	}
+
+	return u.Query().Get("cursor")
+}
@@ -0,0 +1,452 @@
+package client_test
+
+import (
+	"contact-api/internal/app/apitest"
+	"contact-api/pkg/client"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestContactsCRUD(t *testing.T) {
+	srv, _ := apitest.NewServer(t)
+	c := newTestClient(t, srv.URL)
+	ctx := context.Background()
+
+	id, err := c.Create(ctx, client.Contact{
+		UserName: "ann",
+		Name:     client.Name{Display: "Ann"},
+		Emails:   []client.Email{{Label: client.LabelWork, Address: "ann@example.com"}},
+		Tags:     []string{"vip"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := c.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.ID != id || got.UserName != "ann" || len(got.Emails) != 1 || got.Emails[0].Address != "ann@example.com" {
+		t.Errorf("Get = %+v", got)
+	}
+
+	got.Title = "CTO"
+	if err := c.Update(ctx, *got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := c.Get(ctx, id); got.Title != "CTO" {
+		t.Errorf("title after Update = %q", got.Title)
+	}
+
+	if err := c.Update(ctx, client.Contact{UserName: "x"}); !errors.Is(err, client.ErrMissingID) {
+		t.Errorf("Update without id: err = %v", err)
+	}
+
+	if err := c.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	_, err = c.Get(ctx, id)
+	var apiErr *client.APIError
+	if !errors.Is(err, client.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.RequestID == "" {
+		t.Errorf("Get deleted: err = %v, want ErrNotFound with request id", err)
+	}
+	if err := c.Delete(ctx, id); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Delete deleted: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestInvalidRequest(t *testing.T) {
+	srv, _ := apitest.NewServer(t)
+	c := newTestClient(t, srv.URL)
+
+	_, err := c.Get(context.Background(), "not-an-object-id")
+
+	var apiErr *client.APIError
+	if !errors.Is(err, client.ErrInvalidRequest) || errors.Is(err, client.ErrNotFound) || !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want ErrInvalidRequest", err)
+	}
+	if len(apiErr.Violations) == 0 {
+		t.Error("spec violations are not decoded")
+	}
+}
+
+func TestListPages(t *testing.T) {
+	srv, _ := apitest.NewServer(t)
+	c := newTestClient(t, srv.URL)
+	ctx := context.Background()
+
+	var ids []string
+	for i := range 5 {
+		id, err := c.Create(ctx, client.Contact{UserName: fmt.Sprintf("user%d", i), Tags: []string{fmt.Sprintf("t%d", i%2)}})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	page, err := c.List(ctx, client.ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(page.Contacts) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page: %d contacts, cursor %q", len(page.Contacts), page.NextCursor)
+	}
+
+	var all []string
+	for contact, err := range c.All(ctx, 2) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		all = append(all, contact.ID)
+	}
+	if !slices.Equal(all, ids) {
+		t.Errorf("All = %v, want %v", all, ids)
+	}
+
+	var tagged []string
+	for contact, err := range c.Query(ctx, client.ListOptions{Limit: 1, Tags: []string{"t0"}}) {
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		tagged = append(tagged, contact.UserName)
+	}
+	if !slices.Equal(tagged, []string{"user0", "user2", "user4"}) {
+		t.Errorf("Query by tag = %v", tagged)
+	}
+
+	deleted, err := c.DeleteAll(ctx)
+	if err != nil || deleted != 5 {
+		t.Errorf("DeleteAll = %d, %v; want 5", deleted, err)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	srv, _ := apitest.NewServer(t)
+	c := newTestClient(t, srv.URL)
+	other := newTestClient(t, srv.URL)
+	ctx := context.Background()
+
+	id, err := c.Create(ctx, client.Contact{
+		UserName: "ann",
+		Name:     client.Name{Display: "Ann", Given: "Ann", Family: "Lee"},
+		Phones:   []client.Phone{{Label: client.LabelMobile, Number: "+100"}},
+		Fields:   map[string]any{},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := c.Patch(ctx, id, func(contact *client.Contact) error {
+		// Другой клиент меняет контакт между чтением и записью
+		if _, err := other.MergePatch(ctx, id, map[string]any{"organization": "Acme"}); err != nil {
+			return err
+		}
+
+		contact.Title = "CTO"
+		contact.Name.Given = ""
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+
+	if got.ID != id || got.Title != "CTO" || got.Organization != "Acme" {
+		t.Errorf("Patch = %+v, want title from the patch and organization from the other client", got)
+	}
+	if got.Name != (client.Name{Display: "Ann", Family: "Lee"}) {
+		t.Errorf("name = %+v, want given removed", got.Name)
+	}
+	if got.Telephone.Mobile != "+100" || len(got.Phones) != 1 {
+		t.Errorf("untouched phones changed: %+v %+v", got.Phones, got.Telephone)
+	}
+
+	stored, err := c.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.Title != "CTO" || stored.Organization != "Acme" || stored.Name.Given != "" {
+		t.Errorf("stored contact = %+v", stored)
+	}
+
+	errStop := errors.New("stop")
+	if _, err := c.Patch(ctx, id, func(*client.Contact) error { return errStop }); !errors.Is(err, errStop) {
+		t.Errorf("Patch with failing fn: err = %v", err)
+	}
+
+	if _, err := c.Patch(ctx, id, func(*client.Contact) error { return nil }); err != nil {
+		t.Errorf("Patch without changes: %v", err)
+	}
+
+	if _, err := c.MergePatch(ctx, id, map[string]any{"birthday": "tomorrow"}); !errors.Is(err, client.ErrInvalidRequest) {
+		t.Errorf("MergePatch with invalid birthday: err = %v, want ErrInvalidRequest", err)
+	}
+
+	got, err = c.MergePatch(ctx, id, map[string]any{"title": nil, "phones": nil})
+	if err != nil {
+		t.Fatalf("MergePatch: %v", err)
+	}
+	if got.Title != "" || len(got.Phones) != 0 || got.Telephone.Mobile != "" || got.Organization != "Acme" {
+		t.Errorf("MergePatch with nulls = %+v", got)
+	}
+
+	if err := c.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.MergePatch(ctx, id, map[string]any{"title": "x"}); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("MergePatch deleted: err = %v, want ErrNotFound", err)
+	}
+	if _, err := c.MergePatch(ctx, "", map[string]any{"title": "x"}); !errors.Is(err, client.ErrMissingID) {
+		t.Errorf("MergePatch without id: err = %v", err)
+	}
+}
+
+func TestMergePatchContentType(t *testing.T) {
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		_, _ = io.WriteString(w, `{"_id":"a"}`)
+	}))
+	defer srv.Close()
+
+	if _, err := newTestClient(t, srv.URL).MergePatch(context.Background(), "a", map[string]any{"title": nil}); err != nil {
+		t.Fatalf("MergePatch: %v", err)
+	}
+	if contentType != "application/merge-patch+json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	srv, store := apitest.NewServer(t)
+	c := newTestClient(t, srv.URL)
+
+	contacts := []client.Contact{
+		{UserName: "a"},
+		{UserName: "b", Fields: map[string]any{"unknown": "x"}},
+		{ID: "ignored", UserName: "c"},
+	}
+
+	results, err := c.Batch(context.Background(), contacts)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if len(results) != len(contacts) {
+		t.Fatalf("%d results for %d contacts", len(results), len(contacts))
+	}
+
+	for i, res := range results {
+		if res.Index != i {
+			t.Errorf("result %d has index %d", i, res.Index)
+		}
+	}
+	if results[0].Err != nil || results[2].Err != nil || results[0].ID == "" || results[2].ID == "ignored" {
+		t.Errorf("valid contacts failed: %+v", results)
+	}
+
+	var apiErr *client.APIError
+	if !errors.Is(results[1].Err, client.ErrInvalidRequest) || !errors.As(results[1].Err, &apiErr) {
+		t.Fatalf("invalid contact: err = %v, want ErrInvalidRequest", results[1].Err)
+	}
+	if len(apiErr.Violations) != 1 || apiErr.Violations[0].Field != "fields.unknown" || apiErr.RequestID == "" {
+		t.Errorf("invalid contact: %+v", apiErr)
+	}
+
+	// Отклонённый контакт не мешает остальным
+	if n := store.Len(); n != 2 {
+		t.Errorf("%d contacts stored, want 2", n)
+	}
+
+	// Контакт, нарушающий спецификацию, отклоняет весь запрос
+	_, err = c.Batch(context.Background(), []client.Contact{{UserName: "d", Birthday: "never"}})
+	if !errors.Is(err, client.ErrInvalidRequest) {
+		t.Errorf("Batch with spec violation: err = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestBatchChunks(t *testing.T) {
+	srv, store := apitest.NewServer(t)
+	c := newTestClient(t, srv.URL)
+
+	contacts := make([]client.Contact, client.MaxBatchSize+1)
+	for i := range contacts {
+		contacts[i].UserName = fmt.Sprintf("user%d", i)
+	}
+	contacts[client.MaxBatchSize].Fields = map[string]any{"unknown": "x"}
+
+	results, err := c.Batch(context.Background(), contacts)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if len(results) != len(contacts) {
+		t.Fatalf("%d results for %d contacts", len(results), len(contacts))
+	}
+
+	last := results[client.MaxBatchSize]
+	if last.Index != client.MaxBatchSize || last.Err == nil {
+		t.Errorf("last result = %+v, want an error at index %d", last, client.MaxBatchSize)
+	}
+	if n := store.Len(); n != client.MaxBatchSize {
+		t.Errorf("%d contacts stored, want %d", n, client.MaxBatchSize)
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "array", input: ` [{"username":"a"},{"username":"b"}]`, want: []string{"a", "b"}},
+		{name: "ndjson", input: "{\"username\":\"a\"}\n{\"username\":\"b\"}\n", want: []string{"a", "b"}},
+		{name: "empty", input: " \n"},
+		{name: "unknown field", input: `[{"username":"a","nickname":"b"}]`, wantErr: true},
+		{name: "broken second record", input: "{\"username\":\"a\"}\n{\"username\":", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, store := apitest.NewServer(t)
+			c := newTestClient(t, srv.URL)
+
+			results, err := c.Import(context.Background(), strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected a parse error")
+				}
+				if store.Len() != 0 {
+					t.Errorf("%d contacts stored after a parse error", store.Len())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			var names []string
+			for _, res := range results {
+				if res.Err != nil {
+					t.Fatalf("result %d: %v", res.Index, res.Err)
+				}
+				stored, err := store.ContactById(context.Background(), res.ID)
+				if err != nil {
+					t.Fatalf("result %d: %v", res.Index, err)
+				}
+				names = append(names, stored.UserName)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("imported %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	srv, _ := apitest.NewServer(t)
+	ctx := context.Background()
+
+	anon := newTestClient(t, srv.URL)
+	admin := newTestClient(t, srv.URL, client.WithAuth(client.BearerToken(apitest.AdminToken)))
+
+	def := client.FieldDefinition{Name: "level", Type: client.FieldEnum, Values: []string{"gold", "silver"}}
+
+	if _, err := anon.PutField(ctx, def); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("PutField without token: err = %v, want ErrUnauthorized", err)
+	}
+
+	created, err := admin.PutField(ctx, def)
+	if err != nil || !created {
+		t.Fatalf("PutField = %v, %v; want created", created, err)
+	}
+	if created, err := admin.PutField(ctx, def); err != nil || created {
+		t.Errorf("second PutField = %v, %v; want replaced", created, err)
+	}
+
+	def.Type = client.FieldNumber
+	def.Values = nil
+	if _, err := admin.PutField(ctx, def); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("type change: err = %v, want ErrConflict", err)
+	}
+
+	defs, err := anon.Fields(ctx)
+	if err != nil || len(defs) != 1 || defs[0].Name != "level" {
+		t.Errorf("Fields = %+v, %v", defs, err)
+	}
+
+	if _, err := anon.Create(ctx, client.Contact{UserName: "x", Fields: map[string]any{"level": "bronze"}}); !errors.Is(err, client.ErrInvalidRequest) {
+		t.Errorf("invalid enum value: err = %v, want ErrInvalidRequest", err)
+	}
+
+	if err := admin.DeleteField(ctx, "level"); err != nil {
+		t.Fatalf("DeleteField: %v", err)
+	}
+	if err := admin.DeleteField(ctx, "level"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("DeleteField twice: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGroupsAndTags(t *testing.T) {
+	srv, _ := apitest.NewServer(t)
+	c := newTestClient(t, srv.URL)
+	ctx := context.Background()
+
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		id, err := c.Create(ctx, client.Contact{UserName: name})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	gid, err := c.CreateGroup(ctx, client.Group{Name: "Friends", Color: "#00ff00"})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if _, err := c.CreateGroup(ctx, client.Group{Name: "Friends"}); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("duplicate group: err = %v, want ErrConflict", err)
+	}
+
+	change, count, err := c.UpdateMembers(ctx, gid, ids[:2], nil)
+	if err != nil || change.Added != 2 || count != 2 {
+		t.Fatalf("UpdateMembers = %+v, %d, %v", change, count, err)
+	}
+
+	page, err := c.Members(ctx, gid, client.ListOptions{Limit: 10})
+	if err != nil || len(page.Contacts) != 2 {
+		t.Fatalf("Members = %+v, %v", page, err)
+	}
+	if !slices.Contains(page.Contacts[0].Groups, gid) {
+		t.Errorf("member groups = %v", page.Contacts[0].Groups)
+	}
+
+	group, err := c.Group(ctx, gid)
+	if err != nil || group.MemberCount != 2 {
+		t.Errorf("Group = %+v, %v", group, err)
+	}
+
+	tagChange, err := c.UpdateTags(ctx, ids, []string{"team"}, nil)
+	if err != nil || tagChange.Added != 3 {
+		t.Fatalf("UpdateTags = %+v, %v", tagChange, err)
+	}
+	tags, err := c.Tags(ctx)
+	if err != nil || len(tags) != 1 || tags[0] != (client.TagCount{Tag: "team", Count: 3}) {
+		t.Errorf("Tags = %+v, %v", tags, err)
+	}
+
+	removed, err := c.DeleteGroup(ctx, gid)
+	if err != nil || removed != 2 {
+		t.Errorf("DeleteGroup = %d, %v", removed, err)
+	}
+	if _, err := c.Group(ctx, gid); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("deleted group: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Ошибки для проверки через errors.Is; конкретный ответ сервера доступен через errors.As и *APIError.
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrConflict       = errors.New("conflict")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrServer         = errors.New("server error")
+	ErrTimeout        = errors.New("server timeout")
+)
+
+// Violation - нарушение спецификации API в запросе.
+type Violation struct {
+	In      string `json:"in"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// APIError - ответ сервера с кодом не 2xx.
+type APIError struct {
+	StatusCode int
+	// Краткое описание ошибки от сервера
+	Slug string
+	// Текст внутренней ошибки, если на сервере включён debug_errors
+	Detail     string
+	Violations []Violation
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("contact api: %d %s", e.StatusCode, e.Slug)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.RequestID != "" {
+		msg += " (request id " + e.RequestID + ")"
+	}
+
+	return msg
+}
+
+// Slug ответов 400 об отсутствующих контакте, пользовательском поле и группе
+var notFoundSlugs = []string{"contact not found", "custom field not found", "group not found"}
+
+// Is сопоставляет ответ с ошибками пакета. Сервер отвечает 400 и на отсутствующий контакт,
+// поле или группу, поэтому ErrNotFound определяется по slug.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || slices.Contains(notFoundSlugs, e.Slug)
+	case ErrInvalidRequest:
+		return e.StatusCode == http.StatusBadRequest && !slices.Contains(notFoundSlugs, e.Slug)
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrTimeout:
+		return e.StatusCode == http.StatusGatewayTimeout
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+func newAPIError(resp *response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.status,
+		RequestID:  resp.header.Get(RequestIDHeader),
+	}
+
+	var body struct {
+		Slug       string      `json:"slug"`
+		Error      string      `json:"error"`
+		Violations []Violation `json:"violations"`
+	}
+	if err := json.Unmarshal(resp.body, &body); err == nil {
+		apiErr.Slug = body.Slug
+		apiErr.Detail = body.Error
+		apiErr.Violations = body.Violations
+	}
+
+	if apiErr.Slug == "" {
+		apiErr.Slug = http.StatusText(resp.status)
+	}
+
+	return apiErr
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const fieldsPath = "/v1/field"
+
+var ErrMissingFieldName = errors.New("field name is required")
+
+// Типы пользовательских полей
+const (
+	FieldString = "string"
+	FieldNumber = "number"
+	// Значения - строки YYYY-MM-DD
+	FieldDate = "date"
+	FieldEnum = "enum"
+	// Значения - абсолютные ссылки http или https
+	FieldURL = "url"
+)
+
+// FieldDefinition - пользовательское поле контактов.
+type FieldDefinition struct {
+	// Задаётся в пути запроса, в теле PutField игнорируется
+	Name     string `json:"name,omitempty"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Допустимые значения, только для FieldEnum
+	Values      []string `json:"values,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Fields возвращает описания пользовательских полей в порядке имён.
+func (c *Client) Fields(ctx context.Context) ([]FieldDefinition, error) {
+	var defs []FieldDefinition
+
+	if _, err := c.do(ctx, http.MethodGet, fieldsPath, nil, nil, &defs); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// PutField создаёт или заменяет поле def.Name и сообщает, было ли оно создано.
+// Требует токен администратора; смена типа существующего поля возвращает ErrConflict.
+func (c *Client) PutField(ctx context.Context, def FieldDefinition) (bool, error) {
+	if def.Name == "" {
+		return false, ErrMissingFieldName
+	}
+
+	name := def.Name
+	def.Name = ""
+
+	resp, err := c.do(ctx, http.MethodPut, fieldPath(name), nil, def, nil)
+	if err != nil {
+		return false, err
+	}
+
+	return resp.status == http.StatusCreated, nil
+}
+
+// DeleteField удаляет поле и его значения во всех контактах. Требует токен администратора.
+func (c *Client) DeleteField(ctx context.Context, name string) error {
+	if name == "" {
+		return ErrMissingFieldName
+	}
+
+	_, err := c.do(ctx, http.MethodDelete, fieldPath(name), nil, nil, nil)
+	return err
+}
+
+func fieldPath(name string) string {
+	return fieldsPath + "/" + url.PathEscape(name)
+}
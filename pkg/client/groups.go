@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const groupsPath = "/v1/group"
+
+// MaxBatchSize - наибольшее число контактов в одном изменении меток или состава группы и в одном запросе импорта
+const MaxBatchSize = 1000
+
+var ErrMissingGroupID = errors.New("group id is required")
+
+// Group - именованный набор контактов.
+type Group struct {
+	// Назначается сервером, при создании игнорируется
+	ID          string `json:"_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// #rrggbb
+	Color string `json:"color,omitempty"`
+	// Только для чтения
+	MemberCount int64 `json:"member_count,omitempty"`
+}
+
+// MembershipChange - итог изменения меток или состава группы: сколько контактов
+// изменено добавлением и удалением. Контакты, уже бывшие в нужном состоянии, не учитываются.
+type MembershipChange struct {
+	Added   int64 `json:"added"`
+	Removed int64 `json:"removed"`
+}
+
+// Groups возвращает все группы в порядке имён.
+func (c *Client) Groups(ctx context.Context) ([]Group, error) {
+	var groups []Group
+
+	if _, err := c.do(ctx, http.MethodGet, groupsPath, nil, nil, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (c *Client) Group(ctx context.Context, id string) (*Group, error) {
+	if id == "" {
+		return nil, ErrMissingGroupID
+	}
+
+	var group Group
+
+	if _, err := c.do(ctx, http.MethodGet, groupPath(id), nil, nil, &group); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+// CreateGroup создаёт пустую группу и возвращает её идентификатор.
+// Занятое имя возвращает ErrConflict.
+func (c *Client) CreateGroup(ctx context.Context, group Group) (string, error) {
+	group.ID = ""
+	group.MemberCount = 0
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+
+	if _, err := c.do(ctx, http.MethodPost, groupsPath, nil, group, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.ID, nil
+}
+
+// UpdateGroup заменяет имя, описание и цвет группы group.ID; состав группы не меняется.
+func (c *Client) UpdateGroup(ctx context.Context, group Group) error {
+	if group.ID == "" {
+		return ErrMissingGroupID
+	}
+
+	id := group.ID
+	group.ID = ""
+	group.MemberCount = 0
+
+	_, err := c.do(ctx, http.MethodPut, groupPath(id), nil, group, nil)
+	return err
+}
+
+// DeleteGroup удаляет группу и возвращает число контактов, из которых она убрана.
+// Сами контакты не удаляются.
+func (c *Client) DeleteGroup(ctx context.Context, id string) (int64, error) {
+	if id == "" {
+		return 0, ErrMissingGroupID
+	}
+
+	var resp struct {
+		Detached int64 `json:"detached"`
+	}
+
+	if _, err := c.do(ctx, http.MethodDelete, groupPath(id), nil, nil, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.Detached, nil
+}
+
+// Members возвращает одну страницу участников группы в порядке идентификаторов.
+// Из opts учитываются только Limit и Cursor; для перебора всех участников
+// с фильтрами и сортировкой подходит Query с ListOptions.Group.
+func (c *Client) Members(ctx context.Context, id string, opts ListOptions) (*Page, error) {
+	if id == "" {
+		return nil, ErrMissingGroupID
+	}
+
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+
+	return c.page(ctx, groupPath(id)+"/members", query)
+}
+
+// UpdateMembers добавляет контакты add в группу и убирает из неё контакты remove.
+// Вместе add и remove - не больше MaxBatchSize контактов. Возвращает изменение
+// и число участников группы после него.
+func (c *Client) UpdateMembers(ctx context.Context, id string, add, remove []string) (MembershipChange, int64, error) {
+	if id == "" {
+		return MembershipChange{}, 0, ErrMissingGroupID
+	}
+
+	req := struct {
+		Add    []string `json:"add,omitempty"`
+		Remove []string `json:"remove,omitempty"`
+	}{add, remove}
+
+	var resp struct {
+		MembershipChange
+		MemberCount int64 `json:"member_count"`
+	}
+
+	if _, err := c.do(ctx, http.MethodPatch, groupPath(id)+"/members", nil, req, &resp); err != nil {
+		return MembershipChange{}, 0, err
+	}
+
+	return resp.MembershipChange, resp.MemberCount, nil
+}
+
+func groupPath(id string) string {
+	return groupsPath + "/" + url.PathEscape(id)
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy - повторы с экспоненциальной задержкой и случайным разбросом.
+type RetryPolicy struct {
+	// Общее число попыток, включая первую; 0 или 1 отключает повторы
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       4,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// next решает, нужно ли повторить попытку attempt, и возвращает задержку перед повтором.
+func (p RetryPolicy) next(attempt int, idempotent bool, resp *response, err error) (time.Duration, bool) {
+	if attempt >= p.Attempts {
+		return 0, false
+	}
+
+	switch {
+	case err != nil:
+		// Отмена контекста вызывающим не повторяется, как и сетевые ошибки неидемпотентных запросов
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || !idempotent {
+			return 0, false
+		}
+	case resp.status == http.StatusTooManyRequests:
+		if wait, ok := retryAfter(resp.header); ok {
+			// Суточная квота сбрасывается в полночь, ждать её нет смысла
+			if wait > p.MaxBackoff {
+				return 0, false
+			}
+			return wait, true
+		}
+	case resp.status == http.StatusBadGateway, resp.status == http.StatusServiceUnavailable, resp.status == http.StatusGatewayTimeout:
+		if !idempotent {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	return p.backoff(attempt), true
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.MaxBackoff)
+
+	// Разброс от половины до полной задержки, чтобы клиенты не повторяли запросы одновременно
+	return d/2 + rand.N(d/2+1)
+}
+
+func retryAfter(header http.Header) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+const tagsPath = "/v1/tag"
+
+var ErrNoTagChanges = errors.New("tags to add or remove are required")
+
+// TagCount - метка и число контактов с ней.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// Tags возвращает все метки контактов по алфавиту.
+func (c *Client) Tags(ctx context.Context) ([]TagCount, error) {
+	var tags []TagCount
+
+	if _, err := c.do(ctx, http.MethodGet, tagsPath, nil, nil, &tags); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// UpdateTags убирает метки remove и добавляет метки add у контактов ids, не больше
+// MaxBatchSize за раз. Контакты, у которых стало бы больше 50 меток, не меняются.
+func (c *Client) UpdateTags(ctx context.Context, ids, add, remove []string) (MembershipChange, error) {
+	if len(ids) == 0 {
+		return MembershipChange{}, ErrMissingID
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return MembershipChange{}, ErrNoTagChanges
+	}
+
+	req := struct {
+		IDs    []string `json:"ids"`
+		Add    []string `json:"add,omitempty"`
+		Remove []string `json:"remove,omitempty"`
+	}{ids, add, remove}
+
+	var change MembershipChange
+
+	if _, err := c.do(ctx, http.MethodPatch, tagsPath, nil, req, &change); err != nil {
+		return MembershipChange{}, err
+	}
+
+	return change, nil
+}